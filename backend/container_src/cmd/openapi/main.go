@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"server/internal/openapi"
+)
+
+func main() {
+	out := flag.String("o", "openapi.json", "output file")
+	flag.Parse()
+
+	data, err := openapi.Spec().MarshalIndent()
+	if err != nil {
+		log.Fatalf("failed to marshal spec: %v", err)
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("failed to write spec: %v", err)
+	}
+}
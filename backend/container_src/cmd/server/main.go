@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,15 +11,24 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-
+	"server/internal/auth"
 	"server/internal/client/astrometry"
 	"server/internal/client/gemini"
 	"server/internal/client/kv"
 	"server/internal/config"
 	"server/internal/controller"
+	"server/internal/device"
+	"server/internal/openapi"
+	"server/internal/ratelimit"
+	"server/internal/service/history"
+	"server/internal/service/meteor"
+	"server/internal/service/minorbody"
 	"server/internal/service/object"
+	"server/internal/service/observinglist"
+	"server/internal/service/satellite"
 	"server/internal/service/solve"
+	"server/internal/service/tonight"
+	"server/internal/store"
 )
 
 func main() {
@@ -30,24 +40,15 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	astrometryClient := astrometry.NewClient(cfg.AstrometryAPIKey)
-	kvClient := kv.NewClient(cfg.CloudflareAccountID, cfg.CloudflareNamespaceID, cfg.CloudflareAPIToken)
-	geminiClient := gemini.NewClient(cfg.GeminiAPIKey)
-	solveService := solve.NewService(astrometryClient)
-	objectService := object.NewService(kvClient, geminiClient)
-	solveController := controller.NewSolveController(solveService)
-	objectController := controller.NewObjectController(objectService)
-
-	router := chi.NewRouter()
-	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("StarSeek API"))
-	})
-	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("ok"))
-	})
-	router.Post("/api/solve", solveController.SubmitImage)
-	router.Get("/api/solve/{jobId}", solveController.GetSolveStatus)
-	router.Get("/api/object/{name}", objectController.GetObjectDetail)
+	app, err := newApp(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router := app.router()
+
+	for _, route := range openapi.Undocumented(openapi.Spec(), router) {
+		log.Printf("Route %s is missing from the OpenAPI spec", route)
+	}
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -75,3 +76,166 @@ func main() {
 
 	log.Println("Server shutdown successfully")
 }
+
+// newApp wires the clients, stores and services described by cfg.
+func newApp(cfg *config.Config) (*app, error) {
+	astrometryClient := astrometry.NewClient(cfg.AstrometryAPIKey)
+	kvClient := kv.NewClient(cfg.CloudflareAccountID, cfg.CloudflareNamespaceID, cfg.CloudflareAPIToken)
+	geminiClient := gemini.NewClient(cfg.GeminiAPIKey)
+
+	keyStore, err := newKeyStore(cfg, kvClient)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := newRateLimits(cfg, kvClient)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceService, err := newDeviceService(cfg, kvClient)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := newJobStore(cfg, kvClient)
+	images := newImageStore(cfg, kvClient)
+	minorBodies := minorbody.NewService(cfg.MinorBodiesFile, cfg.MinorBodyLimitMag)
+	solveService := solve.NewService(astrometryClient, jobs, images, minorBodies, satellite.NewService(cfg.TLEFile), meteor.NewService())
+	tonightService := tonight.NewService()
+
+	return &app{
+		cfg:            cfg,
+		solveService:   solveService,
+		historyService: history.NewService(jobs, images, solveService),
+		tonightService: tonightService,
+		listService:    observinglist.NewService(solveService, tonightService),
+		minorBodies:    minorBodies,
+		objectService:  object.NewService(kvClient, geminiClient),
+		keyService:     auth.NewService(keyStore),
+		deviceService:  deviceService,
+		authenticator:  auth.NewAuthenticator(keyStore, cfg.RequireAPIKey, controller.WriteError),
+		quotas:         auth.NewQuotas(newQuotaCounter(cfg, kvClient)),
+		limits:         limits,
+		health:         controller.NewHealthController(astrometryClient),
+	}, nil
+}
+
+func newKeyStore(cfg *config.Config, kvClient *kv.Client) (auth.KeyStore, error) {
+	memory := auth.NewMemoryKeyStore()
+	if cfg.APIKeysFile != "" {
+		if err := memory.LoadFile(cfg.APIKeysFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.AdminAPIKey != "" {
+		err := memory.Put(context.Background(), &auth.Key{
+			ID:     "bootstrap-admin",
+			Name:   "Bootstrap admin",
+			Hash:   auth.HashKey(cfg.AdminAPIKey),
+			Scopes: []auth.Scope{auth.ScopeAdmin},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add bootstrap admin key: %w", err)
+		}
+	}
+
+	if !cfg.KVEnabled() {
+		return memory, nil
+	}
+	return auth.NewKVKeyStore(kvClient, memory), nil
+}
+
+func newRateLimits(cfg *config.Config, kvClient *kv.Client) (*rateLimits, error) {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	switch cfg.RateLimitStore {
+	case "memory":
+	case "kv":
+		if !cfg.KVEnabled() {
+			return nil, errors.New("RATE_LIMIT_STORE=kv requires Cloudflare KV credentials")
+		}
+		shared := ratelimit.NewKVStore(kvClient)
+		go shared.Run(context.Background())
+		store = shared
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", cfg.RateLimitStore)
+	}
+
+	submit, err := ratelimit.ParseRule("submit", cfg.RateLimitSubmit)
+	if err != nil {
+		return nil, err
+	}
+	poll, err := ratelimit.ParseRule("poll", cfg.RateLimitPoll)
+	if err != nil {
+		return nil, err
+	}
+	object, err := ratelimit.ParseRule("object", cfg.RateLimitObject)
+	if err != nil {
+		return nil, err
+	}
+	register, err := ratelimit.ParseRule("register", cfg.RateLimitRegister)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := ratelimit.NewLimiter(store, controller.WriteError)
+	return &rateLimits{
+		submit:   limiter.Middleware(submit),
+		poll:     limiter.Middleware(poll),
+		object:   limiter.Middleware(object),
+		register: limiter.Middleware(register),
+	}, nil
+}
+
+func newQuotaCounter(cfg *config.Config, kvClient *kv.Client) auth.Counter {
+	if !cfg.KVEnabled() {
+		return auth.NewMemoryCounter()
+	}
+	counter := auth.NewKVCounter(kvClient)
+	go counter.Run(context.Background())
+	return counter
+}
+
+type jobStore interface {
+	solve.JobStore
+	history.JobStore
+}
+
+func newJobStore(cfg *config.Config, kvClient *kv.Client) jobStore {
+	if !cfg.KVEnabled() {
+		return store.NewMemoryJobStore()
+	}
+	return store.NewKVJobStore(kvClient)
+}
+
+type imageStore interface {
+	solve.ImageStore
+	history.ImageStore
+}
+
+func newImageStore(cfg *config.Config, kvClient *kv.Client) imageStore {
+	if !cfg.KVEnabled() {
+		return store.NewMemoryImageStore()
+	}
+	return store.NewKVImageStore(kvClient)
+}
+
+func newDeviceService(cfg *config.Config, kvClient *kv.Client) (*device.Service, error) {
+	keys, err := device.ParseKeySet(cfg.DeviceTokenKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.KVEnabled() {
+		if keys.Active() == "" {
+			log.Println("DEVICE_TOKEN_KEYS not set, device tokens will not survive a restart")
+		}
+		return device.NewService(keys, device.NewMemoryRevocationList(), cfg.DeviceTokenTTL, cfg.DeviceTokenGrace), nil
+	}
+
+	if err := keys.WithKV(context.Background(), kvClient); err != nil {
+		return nil, err
+	}
+	return device.NewService(keys, device.NewKVRevocationList(kvClient), cfg.DeviceTokenTTL, cfg.DeviceTokenGrace), nil
+}
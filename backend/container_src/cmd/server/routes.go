@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"server/internal/auth"
+	"server/internal/config"
+	"server/internal/controller"
+	"server/internal/device"
+	appmiddleware "server/internal/middleware"
+	"server/internal/openapi"
+	"server/internal/service/history"
+	"server/internal/service/minorbody"
+	"server/internal/service/object"
+	"server/internal/service/observinglist"
+	"server/internal/service/solve"
+	"server/internal/service/tonight"
+)
+
+type rateLimits struct {
+	submit   func(http.Handler) http.Handler
+	poll     func(http.Handler) http.Handler
+	object   func(http.Handler) http.Handler
+	register func(http.Handler) http.Handler
+}
+
+type app struct {
+	cfg            *config.Config
+	solveService   *solve.Service
+	historyService *history.Service
+	tonightService *tonight.Service
+	listService    *observinglist.Service
+	minorBodies    *minorbody.Service
+	objectService  *object.Service
+	keyService     *auth.Service
+	deviceService  *device.Service
+	authenticator  *auth.Authenticator
+	quotas         *auth.Quotas
+	limits         *rateLimits
+	health         *controller.HealthController
+}
+
+func (a *app) router() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("StarSeek API"))
+	})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Get("/openapi.json", openapi.JSONHandler(openapi.SpecJSON()))
+	router.Get("/docs", openapi.DocsHandler)
+	router.Get("/docs/{file}", openapi.DocsAssetHandler)
+
+	v1 := a.apiRoutes(controller.V1Views{}, false)
+	v2 := a.apiRoutes(controller.V2Views{}, true)
+	v1Deprecation := appmiddleware.Deprecation(a.cfg.V1DeprecatedAt, a.cfg.V1Sunset, "/api/v2")
+	router.Route("/api", func(r chi.Router) {
+		r.Use(a.authenticator.Middleware)
+		r.Route("/admin", a.adminRoutes)
+		r.Route("/v1", func(r chi.Router) {
+			r.Use(v1Deprecation)
+			v1(r)
+		})
+		r.Route("/v2", v2)
+		r.Group(func(r chi.Router) {
+			r.Use(v1Deprecation)
+			v1(r)
+		})
+	})
+	return router
+}
+
+// apiRoutes mounts one API version. Deprecated versions keep the endpoints
+// they shipped with; everything added since exists only in current ones.
+func (a *app) apiRoutes(views controller.Views, current bool) func(chi.Router) {
+	solveController := controller.NewSolveController(a.solveService, views)
+	objectController := controller.NewObjectController(a.objectService, views)
+	deviceController := controller.NewDeviceController(a.deviceService)
+	historyController := controller.NewHistoryController(a.historyService)
+	tonightController := controller.NewTonightController(a.tonightService)
+	listController := controller.NewObservingListController(a.listService)
+	solveScope := a.authenticator.RequireScope(auth.ScopeSolve)
+	objectScope := a.authenticator.RequireScope(auth.ScopeObject)
+
+	return func(r chi.Router) {
+		if current {
+			// Registration takes recently expired tokens too, so a device
+			// can renew its token and keep its ID and history.
+			r.With(a.deviceService.RefreshMiddleware(controller.WriteError), a.limits.register).
+				Post("/devices", deviceController.RegisterDevice)
+		}
+		r.Group(func(r chi.Router) {
+			r.Use(a.deviceService.Middleware(controller.WriteError))
+			r.With(solveScope, a.limits.submit, a.quotas.Middleware(auth.MetricSolve, controller.WriteError)).
+				Post("/solve", solveController.SubmitImage)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}", solveController.GetSolveStatus)
+			r.With(objectScope, a.limits.object, a.quotas.Middleware(auth.MetricFunFact, controller.WriteError)).
+				Get("/object/{name}", objectController.GetObjectDetail)
+			if !current {
+				return
+			}
+
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/grid", solveController.GetGrid)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/chart.svg", solveController.GetChart)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/wcs.fits", solveController.GetWCSFITS)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/wcs.txt", solveController.GetWCSText)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/image-with-avm.jpg", solveController.GetImageWithAVM)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/sky.kml", solveController.GetKML)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/sky.kmz", solveController.GetKMZ)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/list", listController.GetSolveList)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/annotated.png", solveController.GetAnnotatedPNG)
+			r.With(solveScope, a.limits.poll).Get("/solve/{jobId}/annotated.jpg", solveController.GetAnnotatedJPEG)
+			r.With(objectScope, a.limits.object).Get("/tonight", tonightController.GetTonight)
+			r.With(objectScope, a.limits.object).Get("/tonight/list", listController.GetTonightList)
+			r.Route("/history", func(r chi.Router) {
+				r.Use(device.RequireDevice(controller.WriteError), a.limits.poll)
+				r.Get("/", historyController.ListHistory)
+				r.Get("/{id}", historyController.GetHistoryEntry)
+				r.Delete("/{id}", historyController.DeleteHistoryEntry)
+				r.Get("/{id}/thumbnail.jpg", historyController.GetThumbnail)
+			})
+		})
+	}
+}
+
+func (a *app) adminRoutes(r chi.Router) {
+	admin := controller.NewAdminController(a.keyService, a.deviceService, a.minorBodies)
+	r.Use(a.authenticator.RequireScope(auth.ScopeAdmin))
+	r.Post("/keys", admin.CreateAPIKey)
+	r.Delete("/keys/{id}", admin.RevokeAPIKey)
+	r.Post("/devices/{id}/revoke", admin.RevokeDevice)
+	r.Post("/device-keys/rotate", admin.RotateDeviceKeys)
+	r.Delete("/device-keys/{kid}", admin.RetireDeviceKey)
+	r.Post("/minor-bodies", admin.RefreshMinorBodies)
+	// Session diagnostics carry upstream error text, so they stay private.
+	r.Get("/astrometry-session", a.health.GetAstrometrySession)
+}
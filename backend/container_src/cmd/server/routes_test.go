@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"server/internal/config"
+	"server/internal/openapi"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	for _, key := range []string{"CLOUDFLARE_ACCOUNT_ID", "CLOUDFLARE_NAMESPACE_ID", "CLOUDFLARE_API_TOKEN", "API_KEYS_FILE"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("ASTROMETRY_API_KEY", "test")
+
+	app, err := newApp(config.Load())
+	if err != nil {
+		t.Fatalf("failed to build app: %v", err)
+	}
+
+	for _, route := range openapi.Undocumented(openapi.Spec(), app.router()) {
+		t.Errorf("route %s is missing from the OpenAPI spec", route)
+	}
+}
@@ -2,4 +2,7 @@ module server
 
 go 1.23
 
-require github.com/go-chi/chi/v5 v5.2.5
+require (
+	github.com/go-chi/chi/v5 v5.2.5
+	golang.org/x/sync v0.10.0
+)
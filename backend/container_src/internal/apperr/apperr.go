@@ -0,0 +1,154 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeInvalidRequest      Code = "invalid_request"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeForbidden           Code = "forbidden"
+	CodeNotFound            Code = "not_found"
+	CodeInvalidImage        Code = "invalid_image"
+	CodeImageTooLarge       Code = "image_too_large"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeUpstreamError       Code = "upstream_error"
+	CodeRateLimited         Code = "rate_limited"
+	CodeQuotaExceeded       Code = "quota_exceeded"
+	CodeSolveTimedOut       Code = "solve_timed_out"
+	CodeLLMRefused          Code = "llm_refused"
+	CodeInternal            Code = "internal"
+)
+
+var (
+	ErrInvalidRequest      = &Error{Code: CodeInvalidRequest}
+	ErrUnauthorized        = &Error{Code: CodeUnauthorized}
+	ErrForbidden           = &Error{Code: CodeForbidden}
+	ErrNotFound            = &Error{Code: CodeNotFound}
+	ErrInvalidImage        = &Error{Code: CodeInvalidImage}
+	ErrImageTooLarge       = &Error{Code: CodeImageTooLarge}
+	ErrUpstreamUnavailable = &Error{Code: CodeUpstreamUnavailable}
+	ErrUpstreamError       = &Error{Code: CodeUpstreamError}
+	ErrRateLimited         = &Error{Code: CodeRateLimited}
+	ErrQuotaExceeded       = &Error{Code: CodeQuotaExceeded}
+	ErrSolveTimedOut       = &Error{Code: CodeSolveTimedOut}
+	ErrLLMRefused          = &Error{Code: CodeLLMRefused}
+)
+
+var statuses = map[Code]int{
+	CodeInvalidRequest:      http.StatusBadRequest,
+	CodeUnauthorized:        http.StatusUnauthorized,
+	CodeForbidden:           http.StatusForbidden,
+	CodeNotFound:            http.StatusNotFound,
+	CodeInvalidImage:        http.StatusUnprocessableEntity,
+	CodeImageTooLarge:       http.StatusRequestEntityTooLarge,
+	CodeUpstreamUnavailable: http.StatusServiceUnavailable,
+	CodeUpstreamError:       http.StatusBadGateway,
+	CodeRateLimited:         http.StatusTooManyRequests,
+	CodeQuotaExceeded:       http.StatusTooManyRequests,
+	CodeSolveTimedOut:       http.StatusGatewayTimeout,
+	CodeLLMRefused:          http.StatusUnprocessableEntity,
+	CodeInternal:            http.StatusInternalServerError,
+}
+
+var messages = map[Code]string{
+	CodeInvalidRequest:      "Invalid request",
+	CodeUnauthorized:        "Authentication required",
+	CodeForbidden:           "Not allowed",
+	CodeNotFound:            "Resource not found",
+	CodeInvalidImage:        "The uploaded file is not a supported image",
+	CodeImageTooLarge:       "The uploaded image is too large",
+	CodeUpstreamUnavailable: "An upstream service is temporarily unavailable, please try again later",
+	CodeUpstreamError:       "An upstream service returned an unexpected response",
+	CodeRateLimited:         "Too many requests, please slow down",
+	CodeQuotaExceeded:       "Usage quota exceeded",
+	CodeSolveTimedOut:       "Plate solving took too long and was abandoned",
+	CodeLLMRefused:          "The fun fact generator declined to answer for this object",
+	CodeInternal:            "Internal server error",
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]any
+	Err     error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = messages[e.Code]
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) WithDetail(key string, value any) *Error {
+	details := make(map[string]any, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Code: CodeInternal, Err: err}
+}
+
+func Status(code Code) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func (e *Error) PublicMessage() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if msg, ok := messages[e.Code]; ok {
+		return msg
+	}
+	return messages[CodeInternal]
+}
+
+func FromHTTPStatus(status int, err error) *Error {
+	code := CodeUpstreamError
+	switch {
+	case status == http.StatusTooManyRequests:
+		code = CodeRateLimited
+	case status >= 500:
+		code = CodeUpstreamUnavailable
+	}
+	return Wrap(code, "", err).WithDetail("upstreamStatus", status)
+}
@@ -0,0 +1,341 @@
+package astro
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Reference values are the worked examples in Meeus, Astronomical
+// Algorithms (2nd ed.), cited by example number.
+
+func hms(h, m, s float64) float64 { return (h + m/60 + s/3600) * 15 }
+func dms(d, m, s float64) float64 { return math.Copysign(math.Abs(d)+m/60+s/3600, d) }
+
+func assertNear(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.6f, want %.6f (±%g)", name, got, want, tolerance)
+	}
+}
+
+func assertTimeNear(t *testing.T, name string, got, want time.Time, tolerance time.Duration) {
+	t.Helper()
+	if d := got.Sub(want); d > tolerance || d < -tolerance {
+		t.Errorf("%s = %s, want %s (±%s)", name, got.Format(time.RFC3339), want.Format(time.RFC3339), tolerance)
+	}
+}
+
+func TestJulianDate(t *testing.T) {
+	// Example 7.a: 1957 October 4.81 UT.
+	sputnik := time.Date(1957, 10, 4, 19, 26, 24, 0, time.UTC)
+	assertNear(t, "JD", JulianDate(sputnik), 2436116.31, 1e-6)
+	assertTimeNear(t, "round trip", FromJulianDate(JulianDate(sputnik)), sputnik, time.Millisecond)
+
+	assertNear(t, "J2000", JulianDate(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)), J2000, 1e-9)
+}
+
+func TestSiderealTime(t *testing.T) {
+	// Example 12.a: 1987 April 10, 0h UT.
+	jd := JulianDate(time.Date(1987, 4, 10, 0, 0, 0, 0, time.UTC))
+	assertNear(t, "mean", MeanSiderealTime(jd), hms(13, 10, 46.3668), 1e-5)
+	assertNear(t, "apparent", ApparentSiderealTime(jd), hms(13, 10, 46.1351), 0.5/3600*15)
+
+	// Example 12.b: 1987 April 10, 19h21m00s UT.
+	jd = JulianDate(time.Date(1987, 4, 10, 19, 21, 0, 0, time.UTC))
+	assertNear(t, "mean", MeanSiderealTime(jd), hms(8, 34, 57.0896), 1e-5)
+}
+
+func TestNutation(t *testing.T) {
+	// Example 22.a: 1987 April 10, 0h TD.
+	dPsi, dEpsilon := Nutation(2446895.5)
+	assertNear(t, "dPsi", dPsi*3600, -3.788, 0.5)
+	assertNear(t, "dEpsilon", dEpsilon*3600, 9.443, 0.5)
+	assertNear(t, "true obliquity", TrueObliquity(2446895.5), dms(23, 26, 36.850), 0.5/3600)
+}
+
+func TestPrecess(t *testing.T) {
+	// Example 21.b: θ Persei from J2000.0 to 2028 November 13.19 TD. The
+	// example includes proper motion, which is applied here first.
+	jd := 2462088.69
+	years := (jd - J2000) / 365.25
+	start := Equatorial{
+		RA:  hms(2, 44, 11.986+0.03425*years),
+		Dec: dms(49, 13, 42.48-0.0895*years),
+	}
+
+	got := Precess(start, J2000, jd)
+	assertNear(t, "RA", got.RA, hms(2, 46, 11.331), 0.001/3600*15)
+	assertNear(t, "Dec", got.Dec, dms(49, 20, 54.54), 0.01/3600)
+
+	back := Precess(got, jd, J2000)
+	assertNear(t, "RA round trip", back.RA, start.RA, 1e-6)
+	assertNear(t, "Dec round trip", back.Dec, start.Dec, 1e-6)
+}
+
+func TestPrecessNearPole(t *testing.T) {
+	polaris := Equatorial{RA: hms(2, 31, 49.09), Dec: dms(89, 15, 50.8)}
+	got := Precess(polaris, J2000, JulianDate(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	// Polaris reaches its closest approach to the pole around 2100, at
+	// about 27' from it.
+	assertNear(t, "Dec", got.Dec, 89.54, 0.02)
+}
+
+func TestToHorizontal(t *testing.T) {
+	// Example 13.b: Venus from the US Naval Observatory at 1987 April 10,
+	// 19h21m00s UT. Meeus measures azimuth from the south.
+	venus := Equatorial{RA: hms(23, 9, 16.641), Dec: dms(-6, 43, 11.61)}
+	usno := Observer{Latitude: dms(38, 55, 17), Longitude: -dms(77, 3, 56)}
+	jd := JulianDate(time.Date(1987, 4, 10, 19, 21, 0, 0, time.UTC))
+
+	assertNear(t, "hour angle", HourAngle(venus, usno, jd), 64.352133, 0.001)
+	got := ToHorizontal(venus, usno, jd)
+	assertNear(t, "altitude", got.Altitude, 15.1249, 0.001)
+	assertNear(t, "azimuth", got.Azimuth, 68.0337+180, 0.001)
+}
+
+func TestRefraction(t *testing.T) {
+	// At the horizon refraction is close to 29', and vanishes at the zenith.
+	assertNear(t, "horizon", Refraction(0)*60, 28.98, 0.05)
+	assertNear(t, "45 degrees", Refraction(45)*60, 1.005, 0.01)
+	assertNear(t, "zenith", Refraction(90), 0, 1e-9)
+	assertNear(t, "below -2 degrees", Refraction(-5), 0, 1e-9)
+}
+
+func TestAirmass(t *testing.T) {
+	for _, tc := range []struct {
+		altitude, want, tolerance float64
+	}{
+		{90, 1, 0.001},
+		{60, 1.1547, 0.001},
+		{30, 1.9954, 0.002},
+		{10, 5.60, 0.02},
+		{0.001, 37.9, 0.1},
+	} {
+		got, ok := Airmass(tc.altitude)
+		if !ok {
+			t.Errorf("Airmass(%g) reported below the horizon", tc.altitude)
+			continue
+		}
+		assertNear(t, "airmass", got, tc.want, tc.tolerance)
+	}
+
+	if _, ok := Airmass(-1); ok {
+		t.Error("Airmass(-1) should report below the horizon")
+	}
+}
+
+func TestRiseTransitSet(t *testing.T) {
+	// Example 15.a: Venus from Boston on 1988 March 20, with the apparent
+	// positions for March 19-21 interpolated as in the example.
+	boston := Observer{Latitude: 42.3333, Longitude: -71.0833}
+	day := JulianDate(time.Date(1988, 3, 20, 0, 0, 0, 0, time.UTC))
+	ra := [3]float64{40.68021, 41.73129, 42.78204}
+	dec := [3]float64{18.04761, 18.44092, 18.82742}
+	venus := func(jd float64) Equatorial {
+		n := jd - day
+		return Equatorial{RA: interpolate(ra, n), Dec: interpolate(dec, n)}
+	}
+
+	got := RiseTransitSet(venus, boston, time.Date(1988, 3, 20, 18, 0, 0, 0, time.UTC), HorizonStar)
+	if got.Rise == nil || got.Set == nil {
+		t.Fatalf("expected Venus to rise and set, got %+v", got)
+	}
+
+	assertTimeNear(t, "transit", got.Transit, time.Date(1988, 3, 20, 19, 40, 18, 0, time.UTC), time.Minute)
+	assertTimeNear(t, "rise", *got.Rise, time.Date(1988, 3, 20, 12, 25, 26, 0, time.UTC), time.Minute)
+
+	// The example's setting time belongs to the previous passage; check
+	// the following one against the horizon altitude instead.
+	setAltitude := ToHorizontal(venus(JulianDate(*got.Set)), boston, JulianDate(*got.Set)).Altitude
+	assertNear(t, "altitude at set", setAltitude, HorizonStar, 0.01)
+	if !got.Set.After(got.Transit) || !got.Rise.Before(got.Transit) {
+		t.Errorf("events out of order: %+v", got)
+	}
+}
+
+func TestRiseTransitSetCircumpolar(t *testing.T) {
+	london := Observer{Latitude: 51.5, Longitude: 0}
+	near := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	polaris := RiseTransitSet(Fixed(Equatorial{RA: 37.95, Dec: 89.26}), london, near, HorizonStar)
+	if !polaris.Circumpolar || polaris.Rise != nil || polaris.Set != nil {
+		t.Errorf("Polaris should be circumpolar from London, got %+v", polaris)
+	}
+
+	canopus := RiseTransitSet(Fixed(Equatorial{RA: 95.99, Dec: -52.70}), london, near, HorizonStar)
+	if !canopus.NeverRises || canopus.Rise != nil {
+		t.Errorf("Canopus should never rise from London, got %+v", canopus)
+	}
+}
+
+func TestTransitIsNearestToRequestedTime(t *testing.T) {
+	sirius := Fixed(Equatorial{RA: hms(6, 45, 8.9), Dec: dms(-16, 42, 58)})
+	obs := Observer{Latitude: 40, Longitude: -74}
+	near := time.Date(2026, 2, 1, 3, 0, 0, 0, time.UTC)
+
+	got := RiseTransitSet(sirius, obs, near, HorizonStar)
+	if d := got.Transit.Sub(near); d > 12*time.Hour || d < -12*time.Hour {
+		t.Errorf("transit %s is more than half a day from %s", got.Transit, near)
+	}
+
+	jd := JulianDate(got.Transit)
+	assertNear(t, "hour angle at transit", HourAngle(sirius(jd), obs, jd), 0, 0.01)
+}
+
+func TestLocalMidnight(t *testing.T) {
+	// 22:00 local time at 75°W is 03:00 UT the next day; the night's
+	// midnight is 05:00 UT.
+	got := LocalMidnight(time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), -75)
+	assertTimeNear(t, "midnight", got, time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC), 0)
+
+	got = LocalMidnight(time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC), 15)
+	assertTimeNear(t, "midnight", got, time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC), 0)
+}
+
+func TestSeparation(t *testing.T) {
+	// Example 17.a: Arcturus and Spica.
+	arcturus := Equatorial{RA: 213.9154, Dec: 19.1825}
+	spica := Equatorial{RA: 201.2983, Dec: -11.1614}
+	assertNear(t, "separation", Separation(arcturus, spica), 32.7930, 0.0001)
+}
+
+// interpolate evaluates Meeus 3.3 for three tabular values at offset n from
+// the middle one.
+func interpolate(y [3]float64, n float64) float64 {
+	a, b := y[1]-y[0], y[2]-y[1]
+	return y[1] + n/2*(a+b+n*(b-a))
+}
+
+func TestSun(t *testing.T) {
+	// Example 25.a: 1992 October 13, 0h TD.
+	jd := 2448908.5
+	ecl, distance := SunEcliptic(jd)
+	assertNear(t, "longitude", ecl.Longitude, 199.90895, 0.001)
+	assertNear(t, "distance", distance, 0.99766, 0.0001)
+
+	eq := Sun(jd)
+	assertNear(t, "RA", eq.RA, 198.38083, 0.002)
+	assertNear(t, "Dec", eq.Dec, -7.78507, 0.002)
+}
+
+func TestMoon(t *testing.T) {
+	// Example 47.a: 1992 April 12, 0h TD. The truncated series is expected
+	// to agree with the full one to a few hundredths of a degree.
+	jd := 2448724.5
+	ecl, distance := MoonEcliptic(jd)
+	assertNear(t, "longitude", ecl.Longitude, 133.167265, 0.03)
+	assertNear(t, "latitude", ecl.Latitude, -3.229126, 0.03)
+	assertNear(t, "distance", distance, 368409.7, 100)
+
+	eq := Moon(jd)
+	assertNear(t, "RA", eq.RA, 134.688470, 0.03)
+	assertNear(t, "Dec", eq.Dec, 13.768368, 0.03)
+	assertNear(t, "parallax", MoonParallax(distance), 0.991990, 0.001)
+}
+
+func TestMoonPhase(t *testing.T) {
+	// Example 48.a: 1992 April 12, 0h TD, illuminated fraction 0.6786.
+	phase := MoonPhaseAt(2448724.5)
+	assertNear(t, "illumination", phase.Illumination, 0.6786, 0.005)
+	if phase.Name != "Waxing Gibbous" {
+		t.Errorf("phase = %q, want Waxing Gibbous", phase.Name)
+	}
+
+	// The full moon of 2026 March 3 (11:38 UT) and new moon of March 19.
+	full := MoonPhaseAt(JulianDate(time.Date(2026, 3, 3, 11, 38, 0, 0, time.UTC)))
+	assertNear(t, "full illumination", full.Illumination, 1, 0.01)
+	if full.Name != "Full Moon" {
+		t.Errorf("phase = %q, want Full Moon", full.Name)
+	}
+	newMoon := MoonPhaseAt(JulianDate(time.Date(2026, 3, 19, 1, 23, 0, 0, time.UTC)))
+	assertNear(t, "new illumination", newMoon.Illumination, 0, 0.01)
+}
+
+func TestVSOP87(t *testing.T) {
+	// Example 32.a: Venus on 1992 December 20, 0h TD, ecliptic of date.
+	tau := Centuries(2448976.5) / 10
+	venus := vsop87["Venus"]
+	assertNear(t, "Venus L", Normalize(venus.l.eval(tau)*180/math.Pi), 26.11428, 0.0001)
+	assertNear(t, "Venus B", venus.b.eval(tau)*180/math.Pi, -2.62070, 0.0001)
+	assertNear(t, "Venus R", venus.r.eval(tau), 0.724603, 0.000001)
+
+	// Example 25.b: the Earth on 1992 October 13, 0h TD.
+	tau = Centuries(2448908.5) / 10
+	earth := vsop87["Earth"]
+	assertNear(t, "Earth L", earth.l.eval(tau), -43.63484796, 1e-8)
+	assertNear(t, "Earth B", earth.b.eval(tau), -0.00000312, 1e-8)
+	assertNear(t, "Earth R", earth.r.eval(tau), 0.99760775, 1e-8)
+}
+
+func TestPrecessEcliptic(t *testing.T) {
+	// Example 21.c: Venus from J2000 to -214 June 30.0 TD.
+	got := precessEcliptic(Ecliptic{Longitude: 149.48194, Latitude: 1.76549}, J2000, 1643074.5)
+	assertNear(t, "longitude", got.Longitude, 118.704, 0.001)
+	assertNear(t, "latitude", got.Latitude, 1.615, 0.001)
+}
+
+func TestPlanetPosition(t *testing.T) {
+	// Example 33.a: Venus on 1992 December 20, 0h TD, apparent
+	// 21h04m41.454s -18°53'16.84" and 0.910947 AU away. The missing
+	// aberration allows about 20".
+	jd := 2448976.5
+	eq, distance, ok := PlanetPosition("Venus", jd)
+	if !ok {
+		t.Fatal("Venus not found")
+	}
+	assertNear(t, "distance", distance, 0.910947, 0.00001)
+
+	apparent := Planet("Venus")(jd)
+	assertNear(t, "RA", apparent.RA, hms(21, 4, 41.454), 0.01)
+	assertNear(t, "Dec", apparent.Dec, dms(-18, 53, 16.84), 0.01)
+
+	back := CatalogPlace(apparent, jd)
+	assertNear(t, "catalog RA", back.RA, eq.RA, 1e-6)
+	assertNear(t, "catalog Dec", back.Dec, eq.Dec, 1e-6)
+
+	if _, _, ok := PlanetPosition("Pluto", jd); ok {
+		t.Error("Pluto should not be a known planet")
+	}
+}
+
+func TestTopocentric(t *testing.T) {
+	// Example 40.a: Mars from Palomar on 2003 August 28, 3h17m UT.
+	jd := 2452879.63681
+	palomar := Observer{Latitude: dms(33, 21, 22), Longitude: -dms(116, 51, 47), Elevation: 1706}
+	eq := Equatorial{RA: hms(22, 38, 7.25), Dec: dms(-15, 46, 15.9)}
+
+	got := Topocentric(eq, 23.592/3600, palomar, jd)
+	assertNear(t, "RA", got.RA, hms(22, 38, 8.54), 0.0001)
+	assertNear(t, "Dec", got.Dec, dms(-15, 46, 30.0), 0.0001)
+}
+
+func TestOrbitMatchesPlanet(t *testing.T) {
+	// Mars' J2000 mean elements (Standish) must give about the planet's
+	// J2000 position; they leave out the perturbations VSOP87 includes.
+	a, e, i, l, peri, node := 1.52371034, 0.09339410, 1.84969142, -4.55343205, -23.94362959, 49.55953891
+	orbit := EllipticOrbit(a, e, i, node, peri-node, l-peri, J2000)
+
+	want, wantDistance, _ := PlanetPosition("Mars", J2000)
+	got, distance, _ := orbit.Position(J2000)
+	assertNear(t, "RA", got.RA, want.RA, 0.02)
+	assertNear(t, "Dec", got.Dec, want.Dec, 0.02)
+	assertNear(t, "distance", distance, wantDistance, 0.001)
+}
+
+func TestOrbitNearParabolic(t *testing.T) {
+	// The elliptic, parabolic and hyperbolic solutions meet at e = 1.
+	for _, dt := range []float64{-200, -30, 0, 5, 90} {
+		parabola := Orbit{PerihelionDistance: 0.5, Eccentricity: 1, Inclination: 40, Node: 100, ArgPerihelion: 60, PerihelionTime: J2000}
+		want := parabola.Heliocentric(J2000 + dt)
+		for _, e := range []float64{0.99999, 1.00001} {
+			orbit := parabola
+			orbit.Eccentricity = e
+			got := orbit.Heliocentric(J2000 + dt)
+			for i := range got {
+				assertNear(t, "coordinate", got[i], want[i], 0.001)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package astro
+
+import "math"
+
+// Equatorial coordinates in degrees.
+type Equatorial struct {
+	RA  float64
+	Dec float64
+}
+
+// Horizontal coordinates in degrees. Azimuth is measured from north through east.
+type Horizontal struct {
+	Altitude float64
+	Azimuth  float64
+}
+
+// Observer is a location on Earth. Longitude is east-positive and elevation
+// is in metres.
+type Observer struct {
+	Latitude  float64
+	Longitude float64
+	Elevation float64
+}
+
+// Precess moves a mean position between two epochs given as Julian Dates,
+// using the rigorous method of Meeus 21.2-21.4.
+func Precess(eq Equatorial, fromJD, toJD float64) Equatorial {
+	T := Centuries(fromJD)
+	t := (toJD - fromJD) / 36525
+
+	base := 2306.2181 + 1.39656*T - 0.000139*T*T
+	zeta := (base*t + (0.30188-0.000344*T)*t*t + 0.017998*t*t*t) / 3600
+	z := (base*t + (1.09468+0.000066*T)*t*t + 0.018203*t*t*t) / 3600
+	theta := ((2004.3109-0.85330*T-0.000217*T*T)*t - (0.42665+0.000217*T)*t*t - 0.041833*t*t*t) / 3600
+
+	a := cosd(eq.Dec) * sind(eq.RA+zeta)
+	b := cosd(theta)*cosd(eq.Dec)*cosd(eq.RA+zeta) - sind(theta)*sind(eq.Dec)
+	c := sind(theta)*cosd(eq.Dec)*cosd(eq.RA+zeta) + cosd(theta)*sind(eq.Dec)
+
+	dec := asind(c)
+	if math.Abs(c) > 0.99 {
+		// Near the poles the arcsine loses precision; use the cosine form.
+		dec = math.Copysign(acosd(math.Hypot(a, b)), c)
+	}
+	return Equatorial{RA: Normalize(atan2d(a, b) + z), Dec: dec}
+}
+
+// ApparentPlace converts a J2000 catalog position to the true equator and
+// equinox of date, applying precession and nutation (Meeus 23.1). Annual
+// aberration (up to 20") is not applied.
+func ApparentPlace(eq Equatorial, jd float64) Equatorial {
+	p := Precess(eq, J2000, jd)
+	dPsi, dEpsilon := Nutation(jd)
+	epsilon := TrueObliquity(jd)
+
+	dRA := (cosd(epsilon)+sind(epsilon)*sind(p.RA)*tand(p.Dec))*dPsi - cosd(p.RA)*tand(p.Dec)*dEpsilon
+	dDec := sind(epsilon)*cosd(p.RA)*dPsi + sind(p.RA)*dEpsilon
+	return Equatorial{RA: Normalize(p.RA + dRA), Dec: p.Dec + dDec}
+}
+
+// HourAngle returns the local hour angle of a position of date, in
+// [-180, 180); negative values are east of the meridian.
+func HourAngle(eq Equatorial, obs Observer, jd float64) float64 {
+	return NormalizeSigned(LocalSiderealTime(jd, obs.Longitude) - eq.RA)
+}
+
+// ToHorizontal converts a position of date to geometric (unrefracted)
+// altitude and azimuth (Meeus 13.5-13.6).
+func ToHorizontal(eq Equatorial, obs Observer, jd float64) Horizontal {
+	h := HourAngle(eq, obs, jd)
+	alt := asind(sind(obs.Latitude)*sind(eq.Dec) + cosd(obs.Latitude)*cosd(eq.Dec)*cosd(h))
+	az := atan2d(sind(h), cosd(h)*sind(obs.Latitude)-tand(eq.Dec)*cosd(obs.Latitude))
+	return Horizontal{Altitude: alt, Azimuth: Normalize(az + 180)}
+}
+
+// Separation returns the angular distance between two positions.
+func Separation(a, b Equatorial) float64 {
+	// Haversine form, stable for small separations.
+	sdDec := sind((b.Dec - a.Dec) / 2)
+	sdRA := sind((b.RA - a.RA) / 2)
+	return 2 * asind(math.Sqrt(sdDec*sdDec+cosd(a.Dec)*cosd(b.Dec)*sdRA*sdRA))
+}
+
+// CatalogPlace is the inverse of ApparentPlace: it removes nutation from a
+// position of date and precesses it back to J2000.
+func CatalogPlace(eq Equatorial, jd float64) Equatorial {
+	dPsi, dEpsilon := Nutation(jd)
+	epsilon := TrueObliquity(jd)
+
+	dRA := (cosd(epsilon)+sind(epsilon)*sind(eq.RA)*tand(eq.Dec))*dPsi - cosd(eq.RA)*tand(eq.Dec)*dEpsilon
+	dDec := sind(epsilon)*cosd(eq.RA)*dPsi + sind(eq.RA)*dEpsilon
+	mean := Equatorial{RA: Normalize(eq.RA - dRA), Dec: eq.Dec - dDec}
+	return Precess(mean, jd, J2000)
+}
+
+// Topocentric shifts a geocentric position of date to where it appears
+// from the observer, given the body's horizontal parallax in degrees
+// (Meeus 40.6-40.7). Only the Moon moves by more than a few arcseconds.
+func Topocentric(eq Equatorial, parallax float64, obs Observer, jd float64) Equatorial {
+	u := atand(0.99664719 * tand(obs.Latitude))
+	height := obs.Elevation / (earthRadiusKm * 1000)
+	rhoSin := 0.99664719*sind(u) + height*sind(obs.Latitude)
+	rhoCos := cosd(u) + height*cosd(obs.Latitude)
+
+	h := HourAngle(eq, obs, jd)
+	sinPi := sind(parallax)
+	denominator := cosd(eq.Dec) - rhoCos*sinPi*cosd(h)
+	dRA := atan2d(-rhoCos*sinPi*sind(h), denominator)
+	dec := atan2d((sind(eq.Dec)-rhoSin*sinPi)*cosd(dRA), denominator)
+	return Equatorial{RA: Normalize(eq.RA + dRA), Dec: dec}
+}
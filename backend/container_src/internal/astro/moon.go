@@ -0,0 +1,176 @@
+package astro
+
+import "math"
+
+// Periodic terms of the lunar theory, the largest of Meeus tables 47.A and
+// 47.B (a truncated ELP-2000/82). They give the Moon's position to roughly
+// 0.02°, a fraction of its own diameter.
+type lunarTerm struct {
+	d, m, mp, f int
+	l, r        float64
+}
+
+var lunarLongitudeDistance = []lunarTerm{
+	{0, 0, 1, 0, 6288774, -20905355},
+	{2, 0, -1, 0, 1274027, -3699111},
+	{2, 0, 0, 0, 658314, -2955968},
+	{0, 0, 2, 0, 213618, -569925},
+	{0, 1, 0, 0, -185116, 48888},
+	{0, 0, 0, 2, -114332, -3149},
+	{2, 0, -2, 0, 58793, 246158},
+	{2, -1, -1, 0, 57066, -152138},
+	{2, 0, 1, 0, 53322, -170733},
+	{2, -1, 0, 0, 45758, -204586},
+	{0, 1, -1, 0, -40923, -129620},
+	{1, 0, 0, 0, -34720, 108743},
+	{0, 1, 1, 0, -30383, 104755},
+	{2, 0, 0, -2, 15327, 10321},
+	{0, 0, 1, 2, -12528, 0},
+	{0, 0, 1, -2, 10980, 79661},
+	{4, 0, -1, 0, 10675, -34782},
+	{0, 0, 3, 0, 10034, -23210},
+	{4, 0, -2, 0, 8548, -21636},
+	{2, 1, -1, 0, -7888, 24208},
+	{2, 1, 0, 0, -6766, 30824},
+	{1, 0, -1, 0, -5163, -8379},
+	{1, 1, 0, 0, 4987, -16675},
+	{2, -1, 1, 0, 4036, -12831},
+	{2, 0, 2, 0, 3994, -10445},
+	{4, 0, 0, 0, 3861, -11650},
+	{2, 0, -3, 0, 3665, 14403},
+	{0, 1, -2, 0, -2689, -7003},
+	{2, 0, -1, 2, -2602, 0},
+	{2, -1, -2, 0, 2390, 10056},
+	{1, 0, 1, 0, -2348, 6322},
+	{2, -2, 0, 0, 2236, -9884},
+}
+
+var lunarLatitude = []lunarTerm{
+	{0, 0, 0, 1, 5128122, 0},
+	{0, 0, 1, 1, 280602, 0},
+	{0, 0, 1, -1, 277693, 0},
+	{2, 0, 0, -1, 173237, 0},
+	{2, 0, -1, 1, 55413, 0},
+	{2, 0, -1, -1, 46271, 0},
+	{2, 0, 0, 1, 32573, 0},
+	{0, 0, 2, 1, 17198, 0},
+	{2, 0, 1, -1, 9266, 0},
+	{0, 0, 2, -1, 8822, 0},
+	{2, -1, 0, -1, 8216, 0},
+	{2, 0, -2, -1, 4324, 0},
+	{2, 0, 1, 1, 4200, 0},
+	{2, 1, 0, -1, -3359, 0},
+	{2, -1, -1, 1, 2463, 0},
+	{2, -1, 0, 1, 2211, 0},
+	{2, -1, -1, -1, 2065, 0},
+	{0, 1, -1, -1, -1870, 0},
+	{4, 0, -1, -1, 1828, 0},
+	{0, 1, 0, 1, -1794, 0},
+}
+
+const earthRadiusKm = 6378.14
+
+// MoonEcliptic returns the Moon's apparent geocentric ecliptic position and
+// its distance in kilometres (Meeus chapter 47).
+func MoonEcliptic(jd float64) (Ecliptic, float64) {
+	t := Centuries(jd)
+	lp := 218.3164477 + 481267.88123421*t - 0.0015786*t*t + t*t*t/538841 - t*t*t*t/65194000
+	d := 297.8501921 + 445267.1114034*t - 0.0018819*t*t + t*t*t/545868 - t*t*t*t/113065000
+	m := 357.5291092 + 35999.0502909*t - 0.0001536*t*t + t*t*t/24490000
+	mp := 134.9633964 + 477198.8675055*t + 0.0087414*t*t + t*t*t/69699 - t*t*t*t/14712000
+	f := 93.2720950 + 483202.0175233*t - 0.0036539*t*t - t*t*t/3526000 + t*t*t*t/863310000
+	e := 1 - 0.002516*t - 0.0000074*t*t
+
+	a1 := 119.75 + 131.849*t
+	a2 := 53.09 + 479264.290*t
+	a3 := 313.45 + 481266.484*t
+
+	var sumL, sumR, sumB float64
+	for _, term := range lunarLongitudeDistance {
+		arg := float64(term.d)*d + float64(term.m)*m + float64(term.mp)*mp + float64(term.f)*f
+		scale := math.Pow(e, math.Abs(float64(term.m)))
+		sumL += term.l * scale * sind(arg)
+		sumR += term.r * scale * cosd(arg)
+	}
+	for _, term := range lunarLatitude {
+		arg := float64(term.d)*d + float64(term.m)*m + float64(term.mp)*mp + float64(term.f)*f
+		sumB += term.l * math.Pow(e, math.Abs(float64(term.m))) * sind(arg)
+	}
+
+	sumL += 3958*sind(a1) + 1962*sind(lp-f) + 318*sind(a2)
+	sumB += -2235*sind(lp) + 382*sind(a3) + 175*sind(a1-f) + 175*sind(a1+f) + 127*sind(lp-mp) - 115*sind(lp+mp)
+
+	dPsi, _ := Nutation(jd)
+	ecl := Ecliptic{Longitude: Normalize(lp + sumL/1e6 + dPsi), Latitude: sumB / 1e6}
+	return ecl, 385000.56 + sumR/1000
+}
+
+// Moon returns the Moon's apparent geocentric equatorial position; it is a
+// Body. Use TopocentricAltitude for altitudes seen from the ground.
+func Moon(jd float64) Equatorial {
+	ecl, _ := MoonEcliptic(jd)
+	return EclipticToEquatorial(ecl, TrueObliquity(jd))
+}
+
+// MoonParallax returns the Moon's equatorial horizontal parallax for a
+// distance in kilometres.
+func MoonParallax(distance float64) float64 {
+	return asind(earthRadiusKm / distance)
+}
+
+// MoonHorizon returns the geocentric altitude of the Moon's centre at rising
+// and setting for a distance in kilometres (Meeus 15).
+func MoonHorizon(distance float64) float64 {
+	return 0.7275*MoonParallax(distance) + HorizonStar
+}
+
+// TopocentricAltitude corrects a geocentric altitude for parallax, which
+// only matters for the Moon (up to about a degree).
+func TopocentricAltitude(altitude, parallax float64) float64 {
+	return altitude - parallax*cosd(altitude)
+}
+
+type MoonPhase struct {
+	// Illumination is the illuminated fraction of the disc, 0 to 1.
+	Illumination float64
+	// Elongation is the Moon's ecliptic longitude minus the Sun's, 0 to
+	// 360; below 180 the Moon is waxing.
+	Elongation float64
+	Name       string
+}
+
+// MoonPhaseAt returns the Moon's phase (Meeus 48.2-48.3).
+func MoonPhaseAt(jd float64) MoonPhase {
+	moon, moonDistance := MoonEcliptic(jd)
+	sun, sunDistance := SunEcliptic(jd)
+
+	psi := angularDistance(moon, sun)
+	sunKm := sunDistance * 149597870.7
+	phaseAngle := atan2d(sunKm*sind(psi), moonDistance-sunKm*cosd(psi))
+	elongation := Normalize(moon.Longitude - sun.Longitude)
+
+	return MoonPhase{
+		Illumination: (1 + cosd(phaseAngle)) / 2,
+		Elongation:   elongation,
+		Name:         phaseName(elongation),
+	}
+}
+
+// principalPhaseWidth is how far either side of 0°, 90°, 180° and 270° a
+// principal phase name applies, roughly a day of lunar motion.
+const principalPhaseWidth = 12.0
+
+func phaseName(elongation float64) string {
+	switch principal := math.Round(elongation / 90); {
+	case math.Abs(elongation-principal*90) <= principalPhaseWidth:
+		return []string{"New Moon", "First Quarter", "Full Moon", "Last Quarter", "New Moon"}[int(principal)]
+	case elongation < 90:
+		return "Waxing Crescent"
+	case elongation < 180:
+		return "Waxing Gibbous"
+	case elongation < 270:
+		return "Waning Gibbous"
+	default:
+		return "Waning Crescent"
+	}
+}
@@ -0,0 +1,25 @@
+package astro
+
+// Nutation returns the nutation in longitude and obliquity using the
+// four-term series of Meeus chapter 22, good to about half an arcsecond.
+func Nutation(jd float64) (dPsi, dEpsilon float64) {
+	t := Centuries(jd)
+	omega := 125.04452 - 1934.136261*t
+	sun := 280.4665 + 36000.7698*t
+	moon := 218.3165 + 481267.8813*t
+
+	dPsi = -17.20*sind(omega) - 1.32*sind(2*sun) - 0.23*sind(2*moon) + 0.21*sind(2*omega)
+	dEpsilon = 9.20*cosd(omega) + 0.57*cosd(2*sun) + 0.10*cosd(2*moon) - 0.09*cosd(2*omega)
+	return dPsi / 3600, dEpsilon / 3600
+}
+
+// MeanObliquity returns the mean obliquity of the ecliptic (Meeus 22.2).
+func MeanObliquity(jd float64) float64 {
+	t := Centuries(jd)
+	return 23.4392911 + (-46.8150*t-0.00059*t*t+0.001813*t*t*t)/3600
+}
+
+func TrueObliquity(jd float64) float64 {
+	_, dEpsilon := Nutation(jd)
+	return MeanObliquity(jd) + dEpsilon
+}
@@ -0,0 +1,123 @@
+package astro
+
+import "math"
+
+// gaussK is the Gaussian gravitational constant in degrees per day, the
+// mean motion of a body one AU from the Sun.
+const gaussK = 0.9856076686
+
+// Orbit is a heliocentric Keplerian orbit referred to the J2000 ecliptic,
+// described by its perihelion so that elliptic, parabolic and hyperbolic
+// orbits share one form. Angles are in degrees.
+type Orbit struct {
+	// PerihelionDistance is q in AU.
+	PerihelionDistance float64
+	Eccentricity       float64
+	Inclination        float64
+	Node               float64
+	ArgPerihelion      float64
+	// PerihelionTime is the Julian Date (TT) of perihelion passage.
+	PerihelionTime float64
+}
+
+// EllipticOrbit builds an Orbit from the mean anomaly at an epoch, the form
+// the MPC uses for asteroids.
+func EllipticOrbit(a, e, inclination, node, argPerihelion, meanAnomaly, epoch float64) Orbit {
+	n := gaussK / math.Pow(a, 1.5)
+	return Orbit{
+		PerihelionDistance: a * (1 - e),
+		Eccentricity:       e,
+		Inclination:        inclination,
+		Node:               node,
+		ArgPerihelion:      argPerihelion,
+		PerihelionTime:     epoch - NormalizeSigned(meanAnomaly)/n,
+	}
+}
+
+// Heliocentric returns the body's position in AU in J2000 ecliptic
+// coordinates (Meeus chapters 30, 33 and 34).
+func (o Orbit) Heliocentric(jd float64) [3]float64 {
+	v, r := o.anomaly(jd - o.PerihelionTime)
+	u := o.ArgPerihelion + v
+	return [3]float64{
+		r * (cosd(o.Node)*cosd(u) - sind(o.Node)*sind(u)*cosd(o.Inclination)),
+		r * (sind(o.Node)*cosd(u) + cosd(o.Node)*sind(u)*cosd(o.Inclination)),
+		r * sind(u) * sind(o.Inclination),
+	}
+}
+
+// Position returns the geocentric astrometric J2000 position corrected for
+// light time, the distance from Earth and the distance from the Sun, both
+// in AU.
+func (o Orbit) Position(jd float64) (Equatorial, float64, float64) {
+	eq, distance, helio := geocentric(o.Heliocentric, jd)
+	return eq, distance, math.Sqrt(helio[0]*helio[0] + helio[1]*helio[1] + helio[2]*helio[2])
+}
+
+// anomaly returns the true anomaly in degrees and the radius vector in AU
+// dt days after perihelion.
+func (o Orbit) anomaly(dt float64) (float64, float64) {
+	q, e := o.PerihelionDistance, o.Eccentricity
+	switch {
+	case math.Abs(e-1) < 1e-6:
+		// Barker's equation for a parabola.
+		w := 3 * gaussK * math.Pi / 180 / math.Sqrt2 * dt / math.Pow(q, 1.5)
+		y := math.Cbrt(w/2 + math.Sqrt(w*w/4+1))
+		s := y - 1/y
+		return 2 * atand(s), q * (1 + s*s)
+	case e < 1:
+		a := q / (1 - e)
+		m := NormalizeSigned(gaussK/math.Pow(a, 1.5)*dt) * math.Pi / 180
+		ecc := m
+		if e > 0.8 {
+			ecc = math.Copysign(math.Pi, m)
+		}
+		for range 50 {
+			step := (ecc - e*math.Sin(ecc) - m) / (1 - e*math.Cos(ecc))
+			ecc -= step
+			if math.Abs(step) < 1e-12 {
+				break
+			}
+		}
+		v := 2 * atan2d(math.Sqrt(1+e)*math.Sin(ecc/2), math.Sqrt(1-e)*math.Cos(ecc/2))
+		return v, a * (1 - e*math.Cos(ecc))
+	default:
+		a := q / (e - 1)
+		m := gaussK / math.Pow(a, 1.5) * dt * math.Pi / 180
+		h := math.Asinh(m / e)
+		for range 50 {
+			step := (e*math.Sinh(h) - h - m) / (e*math.Cosh(h) - 1)
+			h -= step
+			if math.Abs(step) < 1e-12 {
+				break
+			}
+		}
+		v := 2 * atand(math.Sqrt((e+1)/(e-1))*math.Tanh(h/2))
+		return v, a * (e*math.Cosh(h) - 1)
+	}
+}
+
+// geocentric turns a heliocentric ecliptic position function into a
+// geocentric J2000 equatorial position, iterating for light time. It also
+// returns the heliocentric position at the time the light left.
+func geocentric(heliocentricAt func(jd float64) [3]float64, jd float64) (Equatorial, float64, [3]float64) {
+	earth := heliocentric(vsop87["Earth"], jd)
+	var p [3]float64
+	var x, y, z, distance, tau float64
+	for range 3 {
+		p = heliocentricAt(jd - tau)
+		x, y, z = p[0]-earth[0], p[1]-earth[1], p[2]-earth[2]
+		distance = math.Sqrt(x*x + y*y + z*z)
+		tau = lightTimePerAU * distance
+	}
+
+	ecl := Ecliptic{Longitude: Normalize(atan2d(y, x)), Latitude: asind(z / distance)}
+	return EclipticToEquatorial(ecl, obliquityJ2000), distance, p
+}
+
+// PhaseAngle returns the Sun-body-Earth angle in degrees for a body r AU
+// from the Sun and delta AU from Earth, with Earth sunDistance AU from the
+// Sun.
+func PhaseAngle(r, delta, sunDistance float64) float64 {
+	return acosd((r*r + delta*delta - sunDistance*sunDistance) / (2 * r * delta))
+}
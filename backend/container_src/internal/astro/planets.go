@@ -0,0 +1,60 @@
+package astro
+
+// Planets lists the bodies PlanetPosition knows, in order from the Sun.
+var Planets = []string{"Mercury", "Venus", "Mars", "Jupiter", "Saturn", "Uranus", "Neptune"}
+
+// planetRadii are equatorial radii in kilometres.
+var planetRadii = map[string]float64{
+	"Mercury": 2439.7,
+	"Venus":   6051.8,
+	"Mars":    3396.2,
+	"Jupiter": 71492,
+	"Saturn":  60268,
+	"Uranus":  25559,
+	"Neptune": 24764,
+}
+
+const (
+	// AU is the astronomical unit in kilometres.
+	AU = 149597870.7
+	// lightTimePerAU is the light travel time across one AU, in days.
+	lightTimePerAU = 0.0057755183
+	// obliquityJ2000 is the mean obliquity of the ecliptic at J2000.
+	obliquityJ2000 = 23.4392911
+
+	SunRadiusKm  = 695700.0
+	MoonRadiusKm = 1737.4
+)
+
+// PlanetPosition returns a planet's geocentric astrometric position
+// referred to the J2000 equator, corrected for light time, and its distance
+// in AU. It reports false for names not in Planets.
+func PlanetPosition(name string, jd float64) (Equatorial, float64, bool) {
+	planet, ok := vsop87[name]
+	if !ok || name == "Earth" {
+		return Equatorial{}, 0, false
+	}
+
+	eq, distance, _ := geocentric(func(jd float64) [3]float64 { return heliocentric(planet, jd) }, jd)
+	return eq, distance, true
+}
+
+// Planet returns a Body for one of Planets, giving its apparent position of
+// date.
+func Planet(name string) Body {
+	return func(jd float64) Equatorial {
+		eq, _, _ := PlanetPosition(name, jd)
+		return ApparentPlace(eq, jd)
+	}
+}
+
+// PlanetRadius returns a planet's equatorial radius in kilometres.
+func PlanetRadius(name string) float64 {
+	return planetRadii[name]
+}
+
+// AngularRadius returns the apparent radius in degrees of a body of the
+// given radius at the given distance, both in kilometres.
+func AngularRadius(radius, distance float64) float64 {
+	return asind(radius / distance)
+}
@@ -0,0 +1,27 @@
+package astro
+
+import "math"
+
+// Refraction returns the atmospheric refraction for a geometric altitude at
+// standard pressure and temperature (Saemundsson, Meeus 16.4).
+func Refraction(altitude float64) float64 {
+	if altitude < -1.9 {
+		return 0
+	}
+	r := 1.02 / tand(altitude+10.3/(altitude+5.11)) / 60
+	return math.Max(r, 0)
+}
+
+// ApparentAltitude adds refraction to a geometric altitude.
+func ApparentAltitude(altitude float64) float64 {
+	return altitude + Refraction(altitude)
+}
+
+// Airmass returns the relative air mass for an apparent altitude using
+// Kasten and Young (1989). It reports false below the horizon.
+func Airmass(altitude float64) (float64, bool) {
+	if altitude <= 0 {
+		return 0, false
+	}
+	return 1 / (sind(altitude) + 0.50572*math.Pow(altitude+6.07995, -1.6364)), true
+}
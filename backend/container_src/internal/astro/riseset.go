@@ -0,0 +1,85 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Standard altitudes of the geometric centre at rising and setting,
+// accounting for refraction and, for the Sun, its semi-diameter.
+const (
+	HorizonStar = -0.5667
+	HorizonSun  = -0.8333
+)
+
+// Body returns the apparent position of date of a body at a Julian Date.
+type Body func(jd float64) Equatorial
+
+// Fixed returns a Body for a J2000 catalog position.
+func Fixed(eq Equatorial) Body {
+	return func(jd float64) Equatorial { return ApparentPlace(eq, jd) }
+}
+
+type RiseSet struct {
+	Rise        *time.Time
+	Transit     time.Time
+	Set         *time.Time
+	Circumpolar bool
+	NeverRises  bool
+}
+
+// iterations is enough for the Moon, the fastest-moving body, to converge
+// to well under a minute.
+const iterations = 4
+
+// RiseTransitSet finds the upper transit nearest to near and the rising and
+// setting around it. Rise and Set are nil when the body stays above or below
+// the horizon altitude for the whole day.
+func RiseTransitSet(body Body, obs Observer, near time.Time, horizon float64) RiseSet {
+	transit := JulianDate(near)
+	for i := 0; i < iterations; i++ {
+		transit -= HourAngle(body(transit), obs, transit) / siderealRate
+	}
+
+	result := RiseSet{Transit: FromJulianDate(transit).Round(time.Second)}
+	h0, ok := semiDiurnalArc(body(transit), obs, horizon)
+	switch {
+	case !ok && ToHorizontal(body(transit), obs, transit).Altitude > horizon:
+		result.Circumpolar = true
+		return result
+	case !ok:
+		result.NeverRises = true
+		return result
+	}
+
+	rise, set := transit-h0/siderealRate, transit+h0/siderealRate
+	for i := 0; i < iterations; i++ {
+		rise = refineCrossing(body, obs, rise, horizon, -1)
+		set = refineCrossing(body, obs, set, horizon, 1)
+	}
+
+	riseTime, setTime := FromJulianDate(rise).Round(time.Second), FromJulianDate(set).Round(time.Second)
+	result.Rise, result.Set = &riseTime, &setTime
+	return result
+}
+
+// semiDiurnalArc returns the hour angle at which a body crosses the horizon
+// altitude (Meeus 15.1), or false if it never does.
+func semiDiurnalArc(eq Equatorial, obs Observer, horizon float64) (float64, bool) {
+	cosH := (sind(horizon) - sind(obs.Latitude)*sind(eq.Dec)) / (cosd(obs.Latitude) * cosd(eq.Dec))
+	if math.IsNaN(cosH) || cosH < -1 || cosH > 1 {
+		return 0, false
+	}
+	return acosd(cosH), true
+}
+
+// refineCrossing moves jd towards the crossing on the given side of the
+// meridian, recomputing the body's position at the current estimate.
+func refineCrossing(body Body, obs Observer, jd, horizon, side float64) float64 {
+	eq := body(jd)
+	h0, ok := semiDiurnalArc(eq, obs, horizon)
+	if !ok {
+		return jd
+	}
+	return jd - NormalizeSigned(HourAngle(eq, obs, jd)-side*h0)/siderealRate
+}
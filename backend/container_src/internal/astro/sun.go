@@ -0,0 +1,56 @@
+package astro
+
+// Ecliptic coordinates of date in degrees.
+type Ecliptic struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// HorizonAstronomicalTwilight and friends are the solar altitudes that
+// bound each stage of twilight.
+const (
+	HorizonCivilTwilight        = -6.0
+	HorizonNauticalTwilight     = -12.0
+	HorizonAstronomicalTwilight = -18.0
+)
+
+// SunEcliptic returns the Sun's apparent ecliptic position and distance in
+// AU using the low-accuracy theory of Meeus chapter 25 (about 0.01°).
+func SunEcliptic(jd float64) (Ecliptic, float64) {
+	t := Centuries(jd)
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := 357.52911 + 35999.05029*t - 0.0001537*t*t
+	e := 0.016708634 - 0.000042037*t - 0.0000001267*t*t
+
+	c := (1.914602-0.004817*t-0.000014*t*t)*sind(m) + (0.019993-0.000101*t)*sind(2*m) + 0.000289*sind(3*m)
+	omega := 125.04 - 1934.136*t
+	longitude := l0 + c - 0.00569 - 0.00478*sind(omega)
+	distance := 1.000001018 * (1 - e*e) / (1 + e*cosd(m+c))
+	return Ecliptic{Longitude: Normalize(longitude)}, distance
+}
+
+// Sun returns the Sun's apparent equatorial position; it is a Body.
+func Sun(jd float64) Equatorial {
+	ecl, _ := SunEcliptic(jd)
+	return EclipticToEquatorial(ecl, TrueObliquity(jd))
+}
+
+// EclipticToEquatorial rotates ecliptic coordinates by the given obliquity
+// (Meeus 13.3-13.4).
+func EclipticToEquatorial(ecl Ecliptic, obliquity float64) Equatorial {
+	ra := atan2d(sind(ecl.Longitude)*cosd(obliquity)-tand(ecl.Latitude)*sind(obliquity), cosd(ecl.Longitude))
+	dec := asind(sind(ecl.Latitude)*cosd(obliquity) + cosd(ecl.Latitude)*sind(obliquity)*sind(ecl.Longitude))
+	return Equatorial{RA: Normalize(ra), Dec: dec}
+}
+
+// EquatorialToEcliptic is the inverse of EclipticToEquatorial.
+func EquatorialToEcliptic(eq Equatorial, obliquity float64) Ecliptic {
+	lon := atan2d(sind(eq.RA)*cosd(obliquity)+tand(eq.Dec)*sind(obliquity), cosd(eq.RA))
+	lat := asind(sind(eq.Dec)*cosd(obliquity) - cosd(eq.Dec)*sind(obliquity)*sind(eq.RA))
+	return Ecliptic{Longitude: Normalize(lon), Latitude: lat}
+}
+
+// angularDistance is Separation for ecliptic coordinates.
+func angularDistance(a, b Ecliptic) float64 {
+	return Separation(Equatorial{RA: a.Longitude, Dec: a.Latitude}, Equatorial{RA: b.Longitude, Dec: b.Latitude})
+}
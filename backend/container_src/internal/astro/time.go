@@ -0,0 +1,92 @@
+// Package astro implements the positional astronomy StarSeek needs: sidereal
+// time, precession, nutation, refraction and rise/transit/set times. The
+// formulae follow Meeus, Astronomical Algorithms (2nd ed.) and are accurate
+// to a few arcseconds over the current century, which is well below what a
+// plate-solved phone photo can resolve. Angles are in degrees throughout.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// J2000 is the Julian Date of the J2000.0 epoch.
+	J2000 = 2451545.0
+
+	unixEpochJD   = 2440587.5
+	siderealRate  = 360.98564736629
+	secondsPerDay = 86400
+)
+
+// JulianDate converts t to a Julian Date. UT and TT are not distinguished;
+// the ~70 s difference is negligible at the precision of this package.
+func JulianDate(t time.Time) float64 {
+	return float64(t.UnixNano())/(secondsPerDay*1e9) + unixEpochJD
+}
+
+func FromJulianDate(jd float64) time.Time {
+	return time.Unix(0, int64(math.Round((jd-unixEpochJD)*secondsPerDay*1e9))).UTC()
+}
+
+// Centuries returns Julian centuries since J2000.0.
+func Centuries(jd float64) float64 {
+	return (jd - J2000) / 36525
+}
+
+// MeanSiderealTime returns Greenwich mean sidereal time (Meeus 12.4).
+func MeanSiderealTime(jd float64) float64 {
+	t := Centuries(jd)
+	return Normalize(280.46061837 + siderealRate*(jd-J2000) + 0.000387933*t*t - t*t*t/38710000)
+}
+
+// ApparentSiderealTime corrects mean sidereal time for nutation.
+func ApparentSiderealTime(jd float64) float64 {
+	dPsi, _ := Nutation(jd)
+	return Normalize(MeanSiderealTime(jd) + dPsi*cosd(TrueObliquity(jd)))
+}
+
+// LocalSiderealTime returns apparent sidereal time at an east-positive longitude.
+func LocalSiderealTime(jd, longitude float64) float64 {
+	return Normalize(ApparentSiderealTime(jd) + longitude)
+}
+
+// LocalMidnight returns the local mean solar midnight nearest t at the given
+// east-positive longitude, which is how StarSeek defines "that night".
+func LocalMidnight(t time.Time, longitude float64) time.Time {
+	offset := time.Duration(longitude / 15 * float64(time.Hour))
+	local := t.UTC().Add(offset)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	if local.Sub(midnight) > 12*time.Hour {
+		midnight = midnight.AddDate(0, 0, 1)
+	}
+	return midnight.Add(-offset)
+}
+
+// Normalize reduces an angle to [0, 360).
+func Normalize(a float64) float64 {
+	a = math.Mod(a, 360)
+	if a < 0 {
+		a += 360
+	}
+	return a
+}
+
+// NormalizeSigned reduces an angle to [-180, 180).
+func NormalizeSigned(a float64) float64 {
+	return Normalize(a+180) - 180
+}
+
+func sind(a float64) float64 { return math.Sin(a * math.Pi / 180) }
+func cosd(a float64) float64 { return math.Cos(a * math.Pi / 180) }
+func tand(a float64) float64 { return math.Tan(a * math.Pi / 180) }
+
+func asind(x float64) float64 { return math.Asin(clamp(x, -1, 1)) * 180 / math.Pi }
+func acosd(x float64) float64 { return math.Acos(clamp(x, -1, 1)) * 180 / math.Pi }
+
+func atand(x float64) float64     { return math.Atan(x) * 180 / math.Pi }
+func atan2d(y, x float64) float64 { return math.Atan2(y, x) * 180 / math.Pi }
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
@@ -0,0 +1,65 @@
+package astro
+
+import "math"
+
+// vsopTerm is one periodic term A cos(B + C t) of a VSOP87 series.
+type vsopTerm struct {
+	a, b, c float64
+}
+
+// vsopSeries holds a coordinate's series by power of t: the first is
+// multiplied by t^0, the second by t^1 and so on.
+type vsopSeries [][]vsopTerm
+
+type vsopPlanet struct {
+	l, b, r vsopSeries
+}
+
+// eval sums the series at t Julian millennia from J2000.
+func (s vsopSeries) eval(t float64) float64 {
+	var sum, power float64 = 0, 1
+	for _, terms := range s {
+		var part float64
+		for _, term := range terms {
+			part += term.a * math.Cos(term.b+term.c*t)
+		}
+		sum += part * power
+		power *= t
+	}
+	return sum * 1e-8
+}
+
+// heliocentric returns a planet's heliocentric position in AU in J2000
+// ecliptic coordinates. The series give the ecliptic and equinox of date,
+// which are precessed back to J2000.
+func heliocentric(p vsopPlanet, jd float64) [3]float64 {
+	t := Centuries(jd) / 10
+	ecl := Ecliptic{
+		Longitude: Normalize(p.l.eval(t) * 180 / math.Pi),
+		Latitude:  p.b.eval(t) * 180 / math.Pi,
+	}
+	r := p.r.eval(t)
+
+	ecl = precessEcliptic(ecl, jd, J2000)
+	return [3]float64{
+		r * cosd(ecl.Latitude) * cosd(ecl.Longitude),
+		r * cosd(ecl.Latitude) * sind(ecl.Longitude),
+		r * sind(ecl.Latitude),
+	}
+}
+
+// precessEcliptic moves ecliptic coordinates from the mean ecliptic and
+// equinox of one date to another (Meeus 21.5-21.7).
+func precessEcliptic(ecl Ecliptic, fromJD, toJD float64) Ecliptic {
+	T := Centuries(fromJD)
+	t := (toJD - fromJD) / 36525
+
+	eta := ((47.0029-0.06603*T+0.000598*T*T)*t + (-0.03302+0.000598*T)*t*t + 0.000060*t*t*t) / 3600
+	pi := 174.876384 + (3289.4789*T+0.60622*T*T-(869.8089+0.50491*T)*t+0.03536*t*t)/3600
+	p := ((5029.0966+2.22226*T-0.000042*T*T)*t + (1.11113-0.000042*T)*t*t - 0.000006*t*t*t) / 3600
+
+	a := cosd(eta)*cosd(ecl.Latitude)*sind(pi-ecl.Longitude) - sind(eta)*sind(ecl.Latitude)
+	b := cosd(ecl.Latitude) * cosd(pi-ecl.Longitude)
+	c := cosd(eta)*sind(ecl.Latitude) + sind(eta)*cosd(ecl.Latitude)*sind(pi-ecl.Longitude)
+	return Ecliptic{Longitude: Normalize(p + pi - atan2d(a, b)), Latitude: asind(c)}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeSolve  Scope = "solve"
+	ScopeObject Scope = "object"
+	ScopeAdmin  Scope = "admin"
+)
+
+const keyPrefix = "sk_"
+
+type Quota struct {
+	DailySolves     int `json:"dailySolves"`
+	MonthlySolves   int `json:"monthlySolves"`
+	DailyFunFacts   int `json:"dailyFunFacts"`
+	MonthlyFunFacts int `json:"monthlyFunFacts"`
+}
+
+type Key struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Scopes    []Scope   `json:"scopes"`
+	Quota     Quota     `json:"quota"`
+	CreatedAt time.Time `json:"createdAt"`
+	Revoked   bool      `json:"revoked"`
+}
+
+func (k *Key) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func HashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func GenerateKey() (id, raw string, err error) {
+	idBytes := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate key id: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+	return hex.EncodeToString(idBytes), keyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server/internal/apperr"
+)
+
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, err error)
+
+type contextKey struct{}
+
+func WithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+func FromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(*Key)
+	return key, ok
+}
+
+type Authenticator struct {
+	store    KeyStore
+	required bool
+	onError  ErrorWriter
+}
+
+func NewAuthenticator(store KeyStore, required bool, onError ErrorWriter) *Authenticator {
+	return &Authenticator{store: store, required: required, onError: onError}
+}
+
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := extractKey(r)
+		if raw == "" {
+			if a.required {
+				a.onError(w, r, apperr.New(apperr.CodeUnauthorized, "API key required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := a.store.Lookup(r.Context(), HashKey(raw))
+		if err != nil {
+			a.onError(w, r, err)
+			return
+		}
+
+		if key == nil || key.Revoked {
+			a.onError(w, r, apperr.New(apperr.CodeUnauthorized, "Invalid API key"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithKey(r.Context(), key)))
+	})
+}
+
+func (a *Authenticator) RequireScope(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := FromContext(r.Context())
+			if !ok {
+				if a.required || scope == ScopeAdmin {
+					a.onError(w, r, apperr.New(apperr.CodeUnauthorized, "API key required"))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !key.HasScope(scope) {
+				a.onError(w, r, apperr.New(apperr.CodeForbidden, "API key lacks the "+string(scope)+" scope"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type meterKey struct{}
+
+// meter holds the quota unit reserved for a request until the handler
+// either charges it or returns without doing so.
+type meter struct {
+	reservation *Reservation
+	charged     bool
+	w           http.ResponseWriter
+}
+
+// Middleware reserves one unit of the key's quota for metric and turns the
+// request away once the quota is used up. The handler calls Charge once the
+// metered work succeeds; a request that never does, because it failed or
+// was answered from cache, gets its unit back.
+func (q *Quotas) Middleware(metric Metric, onError ErrorWriter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := FromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reservation, err := q.Reserve(r.Context(), key, metric)
+			if err != nil {
+				if reservation != nil {
+					setQuotaHeaders(w, reservation.Usage)
+				}
+				onError(w, r, err)
+				return
+			}
+
+			// Until the unit is charged, the headers show it as still free.
+			free := reservation.Usage
+			if free.DailyLimit > 0 {
+				free.DailyRemaining++
+			}
+			if free.MonthlyLimit > 0 {
+				free.MonthlyRemaining++
+			}
+			setQuotaHeaders(w, free)
+
+			m := &meter{reservation: reservation, w: w}
+			defer func() {
+				if m.charged {
+					return
+				}
+				if err := q.Release(context.WithoutCancel(r.Context()), reservation); err != nil {
+					log.Printf("Failed to release %s quota for key %s: %v", metric, key.ID, err)
+				}
+			}()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), meterKey{}, m)))
+		})
+	}
+}
+
+// Charge keeps the quota unit the request reserved, and updates the quota
+// headers to match. It does nothing for requests outside Quotas.Middleware,
+// and is meant to be called before the response is written.
+func Charge(ctx context.Context) {
+	m, ok := ctx.Value(meterKey{}).(*meter)
+	if !ok {
+		return
+	}
+	m.charged = true
+	setQuotaHeaders(m.w, m.reservation.Usage)
+}
+
+func setQuotaHeaders(w http.ResponseWriter, usage Usage) {
+	if usage.DailyLimit > 0 {
+		w.Header().Set("X-Quota-Daily-Limit", strconv.Itoa(usage.DailyLimit))
+		w.Header().Set("X-Quota-Daily-Remaining", strconv.Itoa(usage.DailyRemaining))
+	}
+	if usage.MonthlyLimit > 0 {
+		w.Header().Set("X-Quota-Monthly-Limit", strconv.Itoa(usage.MonthlyLimit))
+		w.Header().Set("X-Quota-Monthly-Remaining", strconv.Itoa(usage.MonthlyRemaining))
+	}
+}
+
+func extractKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && strings.HasPrefix(strings.TrimSpace(token), keyPrefix) {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/internal/apperr"
+)
+
+func writeTestError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) {
+		appErr = apperr.Wrap(apperr.CodeInternal, "", err)
+	}
+	w.WriteHeader(apperr.Status(appErr.Code))
+	w.Write([]byte(appErr.Code))
+}
+
+func testKeys(t *testing.T, keys ...*Key) *MemoryKeyStore {
+	t.Helper()
+	store := NewMemoryKeyStore()
+	for _, key := range keys {
+		if err := store.Put(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func serve(handler http.Handler, rawKey string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if rawKey != "" {
+		r.Header.Set("X-API-Key", rawKey)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestScopes(t *testing.T) {
+	store := testKeys(t,
+		&Key{ID: "solver", Hash: HashKey("sk_solver"), Scopes: []Scope{ScopeSolve}},
+		&Key{ID: "admin", Hash: HashKey("sk_admin"), Scopes: []Scope{ScopeAdmin}},
+		&Key{ID: "revoked", Hash: HashKey("sk_revoked"), Scopes: []Scope{ScopeSolve}, Revoked: true},
+	)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		required bool
+		scope    Scope
+		key      string
+		want     int
+	}{
+		{"scoped key", true, ScopeSolve, "sk_solver", http.StatusOK},
+		{"admin implies every scope", true, ScopeObject, "sk_admin", http.StatusOK},
+		{"missing scope", true, ScopeObject, "sk_solver", http.StatusForbidden},
+		{"admin scope needs admin", false, ScopeAdmin, "sk_solver", http.StatusForbidden},
+		{"unknown key", false, ScopeSolve, "sk_unknown", http.StatusUnauthorized},
+		{"revoked key", false, ScopeSolve, "sk_revoked", http.StatusUnauthorized},
+		{"anonymous when optional", false, ScopeSolve, "", http.StatusOK},
+		{"anonymous when required", true, ScopeSolve, "", http.StatusUnauthorized},
+		{"anonymous admin", false, ScopeAdmin, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthenticator(store, tt.required, writeTestError)
+			w := serve(a.Middleware(a.RequireScope(tt.scope)(ok)), tt.key)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestQuotaChargesOnlySuccessfulWork(t *testing.T) {
+	store := testKeys(t, &Key{ID: "limited", Hash: HashKey("sk_limited"), Scopes: []Scope{ScopeSolve}, Quota: Quota{DailySolves: 2}})
+	a := NewAuthenticator(store, true, writeTestError)
+	quotas := NewQuotas(NewMemoryCounter())
+	quotas.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+
+	succeed := true
+	handler := a.Middleware(quotas.Middleware(MetricSolve, writeTestError)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !succeed {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		Charge(r.Context())
+	})))
+
+	succeed = false
+	for range 3 {
+		if w := serve(handler, "sk_limited"); w.Code != http.StatusBadGateway {
+			t.Fatalf("failed request status = %d", w.Code)
+		}
+	}
+
+	succeed = true
+	for i, remaining := range []string{"1", "0"} {
+		w := serve(handler, "sk_limited")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d (%s)", i, w.Code, w.Body)
+		}
+		if got := w.Header().Get("X-Quota-Daily-Remaining"); got != remaining {
+			t.Errorf("request %d remaining = %s, want %s", i, got, remaining)
+		}
+	}
+
+	w := serve(handler, "sk_limited")
+	if w.Code != http.StatusTooManyRequests || w.Body.String() != string(apperr.CodeQuotaExceeded) {
+		t.Errorf("over quota: status = %d (%s), want 429 quota_exceeded", w.Code, w.Body)
+	}
+}
+
+func TestChargeOutsideMiddlewareIsANoop(t *testing.T) {
+	Charge(context.Background())
+}
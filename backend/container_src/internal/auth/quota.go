@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"server/internal/apperr"
+)
+
+type Metric string
+
+const (
+	MetricSolve   Metric = "solve"
+	MetricFunFact Metric = "funfact"
+)
+
+// Counter keeps named counts that expire ttl after they were created.
+type Counter interface {
+	// Add changes a count by delta and returns the new value.
+	Add(ctx context.Context, key string, delta int, ttl time.Duration) (int, error)
+}
+
+type Usage struct {
+	DailyLimit       int
+	DailyRemaining   int
+	MonthlyLimit     int
+	MonthlyRemaining int
+}
+
+type Quotas struct {
+	counter Counter
+	now     func() time.Time
+}
+
+func NewQuotas(counter Counter) *Quotas {
+	return &Quotas{counter: counter, now: time.Now}
+}
+
+// Reservation is one unit of a metric taken up front for a request; it is
+// kept if the metered work succeeds and released otherwise.
+type Reservation struct {
+	Usage                Usage
+	dailyKey, monthlyKey string
+}
+
+// Reserve takes one unit of a metric and fails, taking nothing, once
+// either limit is reached. Taking before the work rather than after means
+// concurrent requests cannot all pass a check and overshoot the quota.
+func (q *Quotas) Reserve(ctx context.Context, key *Key, metric Metric) (*Reservation, error) {
+	dailyLimit, monthlyLimit := limits(key.Quota, metric)
+	dailyKey, monthlyKey := q.counterKeys(key, metric)
+
+	daily, err := q.counter.Add(ctx, dailyKey, 1, 48*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := q.counter.Add(ctx, monthlyKey, 1, 32*24*time.Hour)
+	if err != nil {
+		q.counter.Add(ctx, dailyKey, -1, 48*time.Hour)
+		return nil, err
+	}
+
+	reservation := &Reservation{
+		Usage: Usage{
+			DailyLimit:       dailyLimit,
+			DailyRemaining:   remaining(dailyLimit, daily),
+			MonthlyLimit:     monthlyLimit,
+			MonthlyRemaining: remaining(monthlyLimit, monthly),
+		},
+		dailyKey:   dailyKey,
+		monthlyKey: monthlyKey,
+	}
+	if (dailyLimit > 0 && daily > dailyLimit) || (monthlyLimit > 0 && monthly > monthlyLimit) {
+		reservation.Usage.DailyRemaining = remaining(dailyLimit, daily-1)
+		reservation.Usage.MonthlyRemaining = remaining(monthlyLimit, monthly-1)
+		if err := q.Release(ctx, reservation); err != nil {
+			return nil, err
+		}
+		return reservation, apperr.ErrQuotaExceeded.WithDetail("metric", string(metric))
+	}
+	return reservation, nil
+}
+
+// Release gives a reserved unit back.
+func (q *Quotas) Release(ctx context.Context, r *Reservation) error {
+	if _, err := q.counter.Add(ctx, r.dailyKey, -1, 48*time.Hour); err != nil {
+		return err
+	}
+	_, err := q.counter.Add(ctx, r.monthlyKey, -1, 32*24*time.Hour)
+	return err
+}
+
+func (q *Quotas) counterKeys(key *Key, metric Metric) (daily, monthly string) {
+	now := q.now().UTC()
+	daily = fmt.Sprintf("quota:%s:%s:%s", key.ID, metric, now.Format(time.DateOnly))
+	monthly = fmt.Sprintf("quota:%s:%s:%s", key.ID, metric, now.Format("2006-01"))
+	return daily, monthly
+}
+
+func limits(quota Quota, metric Metric) (daily, monthly int) {
+	switch metric {
+	case MetricSolve:
+		return quota.DailySolves, quota.MonthlySolves
+	case MetricFunFact:
+		return quota.DailyFunFacts, quota.MonthlyFunFacts
+	default:
+		return 0, 0
+	}
+}
+
+func remaining(limit, used int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-used, 0)
+}
+
+type counterEntry struct {
+	value   int
+	expires time.Time
+}
+
+type MemoryCounter struct {
+	mu      sync.Mutex
+	entries map[string]counterEntry
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{entries: make(map[string]counterEntry)}
+}
+
+func (c *MemoryCounter) Add(ctx context.Context, key string, delta int, ttl time.Duration) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = counterEntry{expires: now.Add(ttl)}
+		c.prune(now)
+	}
+	entry.value += delta
+	c.entries[key] = entry
+	return entry.value, nil
+}
+
+func (c *MemoryCounter) prune(now time.Time) {
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// CounterKV is the part of the KV client KVCounter uses.
+type CounterKV interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	PutWithTTL(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// CounterSyncInterval is how often KVCounter writes counts back to KV.
+const CounterSyncInterval = 5 * time.Second
+
+// KVCounter keeps counts in Cloudflare KV so quotas survive restarts and
+// are shared between container instances. KV has no atomic increment, so
+// each instance counts locally, reading a count from KV the first time it
+// is used, and Sync folds the local changes into KV. Instances therefore
+// see each other's usage a sync interval late, and two instances syncing
+// the same key at once can lose one's changes.
+type KVCounter struct {
+	kv CounterKV
+
+	mu      sync.Mutex
+	entries map[string]*kvCount
+}
+
+type kvCount struct {
+	value   int
+	pending int
+	ttl     time.Duration
+	expires time.Time
+}
+
+func NewKVCounter(kv CounterKV) *KVCounter {
+	return &KVCounter{kv: kv, entries: make(map[string]*kvCount)}
+}
+
+func (c *KVCounter) Add(ctx context.Context, key string, delta int, ttl time.Duration) (int, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || now.After(entry.expires) {
+		shared, err := c.load(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		c.mu.Lock()
+		// Another request may have loaded the key meanwhile.
+		if entry, ok = c.entries[key]; !ok || now.After(entry.expires) {
+			entry = &kvCount{value: shared}
+			c.entries[key] = entry
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.value += delta
+	entry.pending += delta
+	entry.ttl = ttl
+	entry.expires = now.Add(ttl)
+	return entry.value, nil
+}
+
+func (c *KVCounter) load(ctx context.Context, key string) (int, error) {
+	value, found, err := c.kv.Get(ctx, key)
+	if err != nil || !found {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid count for %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// Run syncs every CounterSyncInterval until ctx is done.
+func (c *KVCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(CounterSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Sync(ctx)
+		}
+	}
+}
+
+// Sync adds each key's local changes since the last call to its count in
+// KV, picking up other instances' changes on the way. A key that fails to
+// sync keeps its changes for the next attempt. Expired keys are dropped.
+func (c *KVCounter) Sync(ctx context.Context) {
+	now := time.Now()
+	type change struct {
+		key   string
+		delta int
+		ttl   time.Duration
+	}
+	var changes []change
+	c.mu.Lock()
+	for key, entry := range c.entries {
+		switch {
+		case now.After(entry.expires):
+			delete(c.entries, key)
+		case entry.pending != 0:
+			changes = append(changes, change{key, entry.pending, entry.ttl})
+		}
+	}
+	c.mu.Unlock()
+
+	for _, ch := range changes {
+		shared, err := c.load(ctx, ch.key)
+		if err == nil {
+			err = c.kv.PutWithTTL(ctx, ch.key, strconv.Itoa(shared+ch.delta), ch.ttl)
+		}
+		if err != nil {
+			log.Printf("Quota sync failed for %s: %v", ch.key, err)
+			continue
+		}
+
+		c.mu.Lock()
+		if entry, ok := c.entries[ch.key]; ok {
+			entry.pending -= ch.delta
+			entry.value = shared + ch.delta + entry.pending
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"server/internal/apperr"
+)
+
+func TestConcurrentReservationsStayWithinQuota(t *testing.T) {
+	quotas := NewQuotas(NewMemoryCounter())
+	key := &Key{ID: "limited", Quota: Quota{MonthlySolves: 5}}
+
+	var mu sync.Mutex
+	var granted []*Reservation
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, err := quotas.Reserve(context.Background(), key, MetricSolve)
+			if err != nil && !errors.Is(err, apperr.ErrQuotaExceeded) {
+				t.Error(err)
+			}
+			if err == nil {
+				mu.Lock()
+				granted = append(granted, reservation)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(granted) != 5 {
+		t.Fatalf("%d reservations granted, want 5", len(granted))
+	}
+
+	// Turned-away requests took nothing, so a released unit can be
+	// reserved again.
+	if err := quotas.Release(context.Background(), granted[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quotas.Reserve(context.Background(), key, MetricSolve); err != nil {
+		t.Errorf("Reserve after release: %v", err)
+	}
+}
+
+func TestKVCounterSharesCountsBetweenInstances(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	first, second := NewKVCounter(kv), NewKVCounter(kv)
+
+	for range 3 {
+		if _, err := first.Add(ctx, "quota:k:solve:2026-10", 1, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first.Sync(ctx)
+
+	if n, _ := second.Add(ctx, "quota:k:solve:2026-10", 1, time.Hour); n != 4 {
+		t.Errorf("second instance count = %d, want 4", n)
+	}
+	second.Sync(ctx)
+	first.Add(ctx, "quota:k:solve:2026-10", -1, time.Hour)
+	first.Sync(ctx)
+
+	// A restarted instance starts from what was synced.
+	if n, _ := NewKVCounter(kv).Add(ctx, "quota:k:solve:2026-10", 0, time.Hour); n != 3 {
+		t.Errorf("count after restart = %d, want 3", n)
+	}
+	if n, _ := first.Add(ctx, "quota:k:solve:2026-10", 0, time.Hour); n != 3 {
+		t.Errorf("first instance count after sync = %d, want 3 including the second's", n)
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"server/internal/apperr"
+)
+
+type Service struct {
+	store KeyStore
+}
+
+func NewService(store KeyStore) *Service {
+	return &Service{store: store}
+}
+
+func (s *Service) CreateKey(ctx context.Context, name string, scopes []Scope, quota Quota) (*Key, string, error) {
+	for _, scope := range scopes {
+		if scope != ScopeSolve && scope != ScopeObject && scope != ScopeAdmin {
+			return nil, "", apperr.New(apperr.CodeInvalidRequest, "Unknown scope: "+string(scope))
+		}
+	}
+
+	id, raw, err := GenerateKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &Key{
+		ID:        id,
+		Name:      name,
+		Hash:      HashKey(raw),
+		Scopes:    scopes,
+		Quota:     quota,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.store.Put(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+func (s *Service) RevokeKey(ctx context.Context, id string) (*Key, error) {
+	key, err := s.store.LookupByID(ctx, id)
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	revoked := *key
+	revoked.Revoked = true
+	if err := s.store.Put(ctx, &revoked); err != nil {
+		return nil, err
+	}
+	return &revoked, nil
+}
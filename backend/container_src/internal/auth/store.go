@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	kvKeyPrefix   = "apikey:"
+	kvIndexPrefix = "apikey-id:"
+	cacheTTL      = time.Minute
+)
+
+type KVClient interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Put(ctx context.Context, key, value string) error
+}
+
+type KeyStore interface {
+	Lookup(ctx context.Context, hash string) (*Key, error)
+	LookupByID(ctx context.Context, id string) (*Key, error)
+	Put(ctx context.Context, key *Key) error
+}
+
+type MemoryKeyStore struct {
+	mu     sync.RWMutex
+	byHash map[string]*Key
+	byID   map[string]*Key
+}
+
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{byHash: make(map[string]*Key), byID: make(map[string]*Key)}
+}
+
+func (s *MemoryKeyStore) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read keys file: %w", err)
+	}
+
+	var keys []*Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to decode keys file: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := s.Put(context.Background(), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryKeyStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byHash[hash], nil
+}
+
+func (s *MemoryKeyStore) LookupByID(ctx context.Context, id string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byID[id], nil
+}
+
+func (s *MemoryKeyStore) Put(ctx context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byHash[key.Hash] = key
+	s.byID[key.ID] = key
+	return nil
+}
+
+type cachedKey struct {
+	key     *Key
+	expires time.Time
+}
+
+type KVKeyStore struct {
+	kv       KVClient
+	fallback *MemoryKeyStore
+	mu       sync.Mutex
+	cache    map[string]cachedKey
+}
+
+func NewKVKeyStore(kv KVClient, fallback *MemoryKeyStore) *KVKeyStore {
+	return &KVKeyStore{kv: kv, fallback: fallback, cache: make(map[string]cachedKey)}
+}
+
+// Lookup reads KV before the fallback, so a revoked copy written by
+// RevokeKey overrides a key loaded from a file or the environment.
+func (s *KVKeyStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	key, err := s.lookupKV(ctx, hash)
+	if err != nil || key != nil {
+		return key, err
+	}
+	return s.fallback.Lookup(ctx, hash)
+}
+
+func (s *KVKeyStore) lookupKV(ctx context.Context, hash string) (*Key, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := s.get(ctx, kvKeyPrefix+hash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = cachedKey{key: key, expires: time.Now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return key, nil
+}
+
+func (s *KVKeyStore) LookupByID(ctx context.Context, id string) (*Key, error) {
+	hash, found, err := s.kv.Get(ctx, kvIndexPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if key, err := s.get(ctx, kvKeyPrefix+hash); err != nil || key != nil {
+			return key, err
+		}
+	}
+	return s.fallback.LookupByID(ctx, id)
+}
+
+func (s *KVKeyStore) Put(ctx context.Context, key *Key) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := s.kv.Put(ctx, kvKeyPrefix+key.Hash, string(data)); err != nil {
+		return err
+	}
+	if err := s.kv.Put(ctx, kvIndexPrefix+key.ID, key.Hash); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cache[key.Hash] = cachedKey{key: key, expires: time.Now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KVKeyStore) get(ctx context.Context, kvKey string) (*Key, error) {
+	value, found, err := s.kv.Get(ctx, kvKey)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var key Key
+	if err := json.Unmarshal([]byte(value), &key); err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+	return &key, nil
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeKV struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{values: make(map[string]string)}
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string) (string, bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	value, ok := kv.values[key]
+	return value, ok, nil
+}
+
+func (kv *fakeKV) Put(ctx context.Context, key, value string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.values[key] = value
+	return nil
+}
+
+func (kv *fakeKV) PutWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	return kv.Put(ctx, key, value)
+}
+
+func TestRevokedFallbackKeyStopsAuthenticating(t *testing.T) {
+	ctx := context.Background()
+	fallback := NewMemoryKeyStore()
+	bootstrap := &Key{ID: "bootstrap-admin", Hash: HashKey("sk_admin"), Scopes: []Scope{ScopeAdmin}}
+	if err := fallback.Put(ctx, bootstrap); err != nil {
+		t.Fatal(err)
+	}
+	store := NewKVKeyStore(newFakeKV(), fallback)
+
+	key, err := store.Lookup(ctx, bootstrap.Hash)
+	if err != nil || key == nil || key.Revoked {
+		t.Fatalf("Lookup before revocation = %+v, %v", key, err)
+	}
+
+	revoked, err := NewService(store).RevokeKey(ctx, bootstrap.ID)
+	if err != nil || revoked == nil || !revoked.Revoked {
+		t.Fatalf("RevokeKey = %+v, %v", revoked, err)
+	}
+
+	key, err = store.Lookup(ctx, bootstrap.Hash)
+	if err != nil || key == nil || !key.Revoked {
+		t.Errorf("Lookup after revocation = %+v, %v; want the revoked copy", key, err)
+	}
+	key, err = store.LookupByID(ctx, bootstrap.ID)
+	if err != nil || key == nil || !key.Revoked {
+		t.Errorf("LookupByID after revocation = %+v, %v; want the revoked copy", key, err)
+	}
+
+	// A fresh instance sharing the namespace must see the revocation too.
+	other := NewKVKeyStore(store.kv, fallback)
+	if key, _ := other.Lookup(ctx, bootstrap.Hash); key == nil || !key.Revoked {
+		t.Errorf("another instance still accepts the revoked key: %+v", key)
+	}
+}
+
+func TestKVKeyStoreCreatesAndRevokes(t *testing.T) {
+	ctx := context.Background()
+	store := NewKVKeyStore(newFakeKV(), NewMemoryKeyStore())
+	service := NewService(store)
+
+	created, raw, err := service.CreateKey(ctx, "client", []Scope{ScopeSolve}, Quota{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := store.Lookup(ctx, HashKey(raw)); err != nil || key == nil || key.ID != created.ID {
+		t.Fatalf("Lookup of new key = %+v, %v", key, err)
+	}
+
+	if _, err := service.RevokeKey(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := store.Lookup(ctx, HashKey(raw)); key == nil || !key.Revoked {
+		t.Errorf("Lookup after revocation = %+v, want revoked", key)
+	}
+
+	if key, err := store.LookupByID(ctx, "missing"); err != nil || key != nil {
+		t.Errorf("LookupByID(missing) = %+v, %v", key, err)
+	}
+}
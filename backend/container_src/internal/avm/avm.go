@@ -0,0 +1,125 @@
+// Package avm writes Astronomy Visualization Metadata (AVM 1.2) as an XMP
+// packet, so image sites and tools such as WorldWide Telescope can place a
+// picture on the sky.
+package avm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+
+	"server/internal/wcs"
+)
+
+const namespace = "http://www.communicatingastronomy.org/avm/1.0/"
+
+// Spatial is the AVM description of a TAN plate solution. AVM measures
+// pixels FITS-style from the bottom-left corner, so it is the solution
+// flipped vertically from the top-down rows of a JPEG.
+type Spatial struct {
+	ReferenceValue     [2]float64 // J2000 RA and Dec, degrees
+	ReferenceDimension [2]int     // image width and height
+	ReferencePixel     [2]float64 // FITS pixel of ReferenceValue
+	Scale              [2]float64 // degrees per pixel; negative X means east left
+	Rotation           float64    // degrees, as CROTA2
+}
+
+// FromWCS describes a plate solution for an image width × height pixels,
+// rescaling it when the solution was made at a different size. SIP
+// distortion has no AVM equivalent and is left out.
+func FromWCS(field *wcs.WCS, width, height int) Spatial {
+	k := 1.0
+	if field.ImageWidth > 0 {
+		k = float64(width) / float64(field.ImageWidth)
+	}
+
+	// FITS pixel (x, y) of a top-down image sits at (x, height+1-y) in
+	// AVM's bottom-up frame, which negates the CD matrix's second column.
+	crpix1 := (field.CRPIX[0]-0.5)*k + 0.5
+	crpix2 := float64(height) + 1 - ((field.CRPIX[1]-0.5)*k + 0.5)
+	c11, c12 := field.CD[0][0]/k, -field.CD[0][1]/k
+	c21, c22 := field.CD[1][0]/k, -field.CD[1][1]/k
+
+	// Decompose as CD = [[s1 cos r, -s2 sin r], [s1 sin r, s2 cos r]]
+	// with s2 positive; a mirrored image shows as a positive s1.
+	rotation := math.Atan2(-c12, c22)
+	s2 := math.Hypot(c12, c22)
+	s1 := math.Hypot(c11, c21)
+	if c11*c22-c12*c21 < 0 {
+		s1 = -s1
+	}
+
+	return Spatial{
+		ReferenceValue:     field.CRVAL,
+		ReferenceDimension: [2]int{width, height},
+		ReferencePixel:     [2]float64{crpix1, crpix2},
+		Scale:              [2]float64{s1, s2},
+		Rotation:           rotation * 180 / math.Pi,
+	}
+}
+
+// CD rebuilds the AVM frame's CD matrix from Scale and Rotation.
+func (s Spatial) CD() [2][2]float64 {
+	sin, cos := math.Sincos(s.Rotation * math.Pi / 180)
+	return [2][2]float64{
+		{s.Scale[0] * cos, -s.Scale[1] * sin},
+		{s.Scale[0] * sin, s.Scale[1] * cos},
+	}
+}
+
+// XMP returns a complete XMP packet with the spatial tags and the names of
+// the objects shown.
+func XMP(spatial Spatial, subjects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	fmt.Fprintf(&b, `<rdf:Description rdf:about="" xmlns:avm="%s">`+"\n", namespace)
+
+	property(&b, "MetadataVersion", "1.2")
+	if len(subjects) > 0 {
+		list(&b, "Subject.Name", "Bag", subjects)
+	}
+	property(&b, "Spatial.CoordinateFrame", "ICRS")
+	list(&b, "Spatial.ReferenceValue", "Seq", floats(spatial.ReferenceValue[:]))
+	list(&b, "Spatial.ReferenceDimension", "Seq", []string{
+		strconv.Itoa(spatial.ReferenceDimension[0]),
+		strconv.Itoa(spatial.ReferenceDimension[1]),
+	})
+	list(&b, "Spatial.ReferencePixel", "Seq", floats(spatial.ReferencePixel[:]))
+	list(&b, "Spatial.Scale", "Seq", floats(spatial.Scale[:]))
+	property(&b, "Spatial.Rotation", strconv.FormatFloat(spatial.Rotation, 'g', -1, 64))
+	property(&b, "Spatial.CoordsystemProjection", "TAN")
+	property(&b, "Spatial.Quality", "Full")
+
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString(`<?xpacket end="w"?>`)
+	return b.Bytes()
+}
+
+func property(b *bytes.Buffer, name, value string) {
+	fmt.Fprintf(b, "<avm:%s>", name)
+	xml.EscapeText(b, []byte(value))
+	fmt.Fprintf(b, "</avm:%s>\n", name)
+}
+
+// list writes an RDF container: Seq for ordered values, Bag for sets.
+func list(b *bytes.Buffer, name, container string, values []string) {
+	fmt.Fprintf(b, "<avm:%s><rdf:%s>", name, container)
+	for _, v := range values {
+		b.WriteString("<rdf:li>")
+		xml.EscapeText(b, []byte(v))
+		b.WriteString("</rdf:li>")
+	}
+	fmt.Fprintf(b, "</rdf:%s></avm:%s>\n", container, name)
+}
+
+func floats(values []float64) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return s
+}
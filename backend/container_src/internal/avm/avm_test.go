@@ -0,0 +1,93 @@
+package avm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"server/internal/wcs"
+)
+
+func TestFromWCS(t *testing.T) {
+	cases := map[string]*wcs.WCS{
+		"rotated": {
+			CRVAL:       [2]float64{83.8221, -5.3911},
+			CRPIX:       [2]float64{300.5, 200.5},
+			CD:          [2][2]float64{{-4e-4, 3e-4}, {-3e-4, -4e-4}},
+			ImageWidth:  600,
+			ImageHeight: 400,
+		},
+		"mirrored": {
+			CRVAL:       [2]float64{10.6847, 41.2688},
+			CRPIX:       [2]float64{250.25, 180.75},
+			CD:          [2][2]float64{{4e-4, 1e-4}, {1e-4, -4e-4}},
+			ImageWidth:  600,
+			ImageHeight: 400,
+		},
+	}
+
+	for name, field := range cases {
+		t.Run(name, func(t *testing.T) {
+			// The JPEG is twice the size the solution was made at.
+			const width, height = 1200, 800
+			spatial := FromWCS(field, width, height)
+			avmFrame := &wcs.WCS{CRVAL: spatial.ReferenceValue, CRPIX: spatial.ReferencePixel, CD: spatial.CD()}
+
+			for _, p := range [][2]float64{{0, 0}, {1199, 0}, {600, 400}, {17, 780}} {
+				// Image pixel (x, y) from the top-left is FITS pixel
+				// (x+1, height-y) in AVM's bottom-up frame.
+				wantRA, wantDec := field.PixelToSky((p[0]+0.5)/2-0.5, (p[1]+0.5)/2-0.5)
+				ra, dec := avmFrame.PixelToSky(p[0], height-1-p[1])
+				if off := math.Hypot((ra-wantRA)*math.Cos(dec*math.Pi/180), dec-wantDec) * 3600; off > 1e-6 {
+					t.Errorf("pixel %v: AVM puts it at %.6f %.6f, %.2g\" from %.6f %.6f", p, ra, dec, off, wantRA, wantDec)
+				}
+			}
+		})
+	}
+}
+
+func TestXMP(t *testing.T) {
+	field := &wcs.WCS{CRVAL: [2]float64{83.8, -5.4}, CRPIX: [2]float64{300.5, 200.5}, CD: [2][2]float64{{-4e-4, 0}, {0, -4e-4}}, ImageWidth: 600, ImageHeight: 400}
+	packet := XMP(FromWCS(field, 600, 400), []string{"M42", "Sun & Moon"})
+
+	values := make(map[string][]string)
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	var current string
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("packet is not well-formed XML: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Space == namespace {
+				current = tok.Name.Local
+			}
+		case xml.CharData:
+			if s := strings.TrimSpace(string(tok)); s != "" && current != "" {
+				values[current] = append(values[current], s)
+			}
+		}
+	}
+
+	want := map[string][]string{
+		"Subject.Name":               {"M42", "Sun & Moon"},
+		"Spatial.ReferenceValue":     {"83.8", "-5.4"},
+		"Spatial.ReferenceDimension": {"600", "400"},
+		"Spatial.ReferencePixel":     {"300.5", "200.5"},
+		"Spatial.Scale":              {"-0.0004", "0.0004"},
+		"Spatial.Rotation":           {"0"},
+		"Spatial.CoordinateFrame":    {"ICRS"},
+	}
+	for tag, w := range want {
+		if got := values[tag]; strings.Join(got, "|") != strings.Join(w, "|") {
+			t.Errorf("%s = %q, want %q", tag, got, w)
+		}
+	}
+}
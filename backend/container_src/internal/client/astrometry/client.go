@@ -10,20 +10,38 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"server/internal/apperr"
+)
+
+const (
+	siteURL = "https://nova.astrometry.net"
+	baseURL = siteURL + "/api"
 )
 
-const baseURL = "https://nova.astrometry.net/api"
+const sessionTTL = 30 * time.Minute
 
-var ErrNotFound = errors.New("resource not found")
+var (
+	ErrNotFound       = errors.New("resource not found")
+	ErrSessionExpired = errors.New("session expired")
+)
 
 type Client struct {
-	httpClient *http.Client
-	apiKey     string
-	session    string
-	sessionExp time.Time
-	mu         sync.Mutex
+	httpClient    *http.Client
+	apiKey        string
+	session       string
+	sessionExp    time.Time
+	lastLogin     time.Time
+	logins        int
+	invalidations int
+	lastError     string
+	mu            sync.Mutex
+	loginGroup    singleflight.Group
 }
 
 func NewClient(apiKey string) *Client {
@@ -40,9 +58,19 @@ type LoginResponse struct {
 }
 
 type UploadResponse struct {
-	Status string `json:"status"`
-	SubID  int    `json:"subid"`
-	Hash   string `json:"hash"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"errormessage"`
+	SubID        int    `json:"subid"`
+	Hash         string `json:"hash"`
+}
+
+type SessionState struct {
+	Active        bool
+	ExpiresAt     time.Time
+	LastLoginAt   time.Time
+	Logins        int
+	Invalidations int
+	LastError     string
 }
 
 type SubmissionResponse struct {
@@ -65,6 +93,15 @@ type Annotation struct {
 	Radius float64  `json:"radius"`
 }
 
+type CalibrationResponse struct {
+	RA          float64 `json:"ra"`
+	Dec         float64 `json:"dec"`
+	Radius      float64 `json:"radius"`
+	PixScale    float64 `json:"pixscale"`
+	Orientation float64 `json:"orientation"`
+	Parity      float64 `json:"parity"`
+}
+
 type AnnotationsResponse struct {
 	Annotations []Annotation `json:"annotations"`
 }
@@ -80,37 +117,110 @@ func (c *Client) Login(ctx context.Context) (string, error) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("login request failed: %w", err)
+		return "", apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("login request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
 
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+
 	var result LoginResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode login response: %w", err)
+		return "", apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode login response: %w", err))
 	}
 
 	if result.Status != "success" {
-		return "", fmt.Errorf("login failed: %s", result.Message)
+		return "", apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("login failed: %s", result.Message))
 	}
 	return result.Session, nil
 }
 
 func (c *Client) GetSession(ctx context.Context) (string, error) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.session != "" && time.Now().Before(c.sessionExp) {
-		return c.session, nil
+		session := c.session
+		c.mu.Unlock()
+		return session, nil
+	}
+	c.mu.Unlock()
+
+	ch := c.loginGroup.DoChan("login", func() (any, error) {
+		session, err := c.Login(context.WithoutCancel(ctx))
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err != nil {
+			c.lastError = err.Error()
+			return "", err
+		}
+
+		c.session = session
+		c.sessionExp = time.Now().Add(sessionTTL)
+		c.lastLogin = time.Now()
+		c.logins++
+		c.lastError = ""
+		return session, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	}
+}
+
+func (c *Client) InvalidateSession(session string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == "" || c.session != session {
+		return
+	}
+
+	c.session = ""
+	c.sessionExp = time.Time{}
+	c.invalidations++
+}
+
+func (c *Client) SessionState() SessionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return SessionState{
+		Active:        c.session != "" && time.Now().Before(c.sessionExp),
+		ExpiresAt:     c.sessionExp,
+		LastLoginAt:   c.lastLogin,
+		Logins:        c.logins,
+		Invalidations: c.invalidations,
+		LastError:     c.lastError,
 	}
+}
 
-	session, err := c.Login(ctx)
+func (c *Client) UploadImage(ctx context.Context, imageData []byte, filename string) (int, error) {
+	session, err := c.GetSession(ctx)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
-	c.session = session
-	c.sessionExp = time.Now().Add(30 * time.Minute)
-	return session, nil
+	subID, err := c.Upload(ctx, session, imageData, filename)
+	if !errors.Is(err, ErrSessionExpired) {
+		return subID, err
+	}
+
+	c.InvalidateSession(session)
+	if session, err = c.GetSession(ctx); err != nil {
+		return 0, err
+	}
+
+	subID, err = c.Upload(ctx, session, imageData, filename)
+	if errors.Is(err, ErrSessionExpired) {
+		return 0, apperr.Wrap(apperr.CodeUpstreamError, "", err)
+	}
+	return subID, err
 }
 
 func (c *Client) Upload(ctx context.Context, session string, imageData []byte, filename string) (int, error) {
@@ -142,22 +252,47 @@ func (c *Client) Upload(ctx context.Context, session string, imageData []byte, f
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("upload request failed: %w", err)
+		return 0, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("upload request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
 
+	if err := checkStatus(resp); err != nil {
+		return 0, err
+	}
+
 	var result UploadResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode upload response: %w", err)
+		return 0, apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode upload response: %w", err))
 	}
 
 	if result.Status != "success" {
-		return 0, fmt.Errorf("upload failed with status: %s", result.Status)
+		if isSessionError(result.ErrorMessage) {
+			return 0, fmt.Errorf("%w: %s", ErrSessionExpired, result.ErrorMessage)
+		}
+		if isFileError(result.ErrorMessage) {
+			return 0, apperr.Wrap(apperr.CodeInvalidImage, "", fmt.Errorf("upload rejected: %s", result.ErrorMessage)).
+				WithDetail("upstream", "astrometry")
+		}
+		return 0, apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("upload failed with status: %s: %s", result.Status, result.ErrorMessage))
 	}
 	return result.SubID, nil
 }
 
+func isSessionError(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "no session") || strings.Contains(message, "session expired") ||
+		strings.Contains(message, "invalid session")
+}
+
+// isFileError reports whether Nova rejected the upload because of the file
+// itself rather than the request around it.
+func isFileError(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "file") || strings.Contains(message, "image") ||
+		strings.Contains(message, "format")
+}
+
 func (c *Client) GetSubmission(ctx context.Context, subID int) (*SubmissionResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/submissions/%d", baseURL, subID), nil)
 	if err != nil {
@@ -166,7 +301,7 @@ func (c *Client) GetSubmission(ctx context.Context, subID int) (*SubmissionRespo
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("get submission request failed: %w", err)
+		return nil, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("get submission request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
@@ -175,9 +310,13 @@ func (c *Client) GetSubmission(ctx context.Context, subID int) (*SubmissionRespo
 		return nil, ErrNotFound
 	}
 
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
 	var result SubmissionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode submission response: %w", err)
+		return nil, apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode submission response: %w", err))
 	}
 	return &result, nil
 }
@@ -190,7 +329,7 @@ func (c *Client) GetJob(ctx context.Context, jobID int) (*JobResponse, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("get job request failed: %w", err)
+		return nil, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("get job request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
@@ -198,9 +337,40 @@ func (c *Client) GetJob(ctx context.Context, jobID int) (*JobResponse, error) {
 		return nil, ErrNotFound
 	}
 
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
 	var result JobResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode job response: %w", err)
+		return nil, apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode job response: %w", err))
+	}
+	return &result, nil
+}
+
+func (c *Client) GetCalibration(ctx context.Context, jobID int) (*CalibrationResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/jobs/%d/calibration/", baseURL, jobID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get calibration request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("get calibration request failed: %w", err))
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var result CalibrationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode calibration response: %w", err))
 	}
 	return &result, nil
 }
@@ -213,10 +383,14 @@ func (c *Client) GetAnnotations(ctx context.Context, jobID int) ([]Annotation, e
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("get annotations request failed: %w", err)
+		return nil, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("get annotations request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read annotations response: %w", err)
@@ -226,9 +400,45 @@ func (c *Client) GetAnnotations(ctx context.Context, jobID int) ([]Annotation, e
 	if err := json.Unmarshal(body, &result); err != nil {
 		var annotations []Annotation
 		if err := json.Unmarshal(body, &annotations); err != nil {
-			return nil, fmt.Errorf("failed to decode annotations response: %w", err)
+			return nil, apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode annotations response: %w", err))
 		}
 		return annotations, nil
 	}
 	return result.Annotations, nil
 }
+
+// GetWCSFile downloads the FITS header holding a solved job's TAN-SIP
+// world coordinate system.
+func (c *Client) GetWCSFile(ctx context.Context, jobID int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/wcs_file/%d", siteURL, jobID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get WCS file request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("get WCS file request failed: %w", err))
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WCS file response: %w", err)
+	}
+	return body, nil
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	return apperr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode)).WithDetail("upstream", "astrometry")
+}
@@ -0,0 +1,125 @@
+package astrometry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeNova serves login and upload. Uploads made with a session in
+// expired are refused the way Nova refuses them.
+type fakeNova struct {
+	mu      sync.Mutex
+	logins  int
+	uploads int
+	expired map[string]bool
+}
+
+func (f *fakeNova) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/login":
+		f.logins++
+		fmt.Fprintf(w, `{"status":"success","session":"s%d"}`, f.logins)
+	case "/api/upload":
+		f.uploads++
+		requestJSON := r.FormValue("request-json")
+		for session := range f.expired {
+			if strings.Contains(requestJSON, `"session":"`+session+`"`) {
+				fmt.Fprint(w, `{"status":"error","errormessage":"no session with key"}`)
+				return
+			}
+		}
+		fmt.Fprintf(w, `{"status":"success","subid":%d}`, 100+f.uploads)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// redirect sends every request to the test server, whatever its host.
+type redirect struct {
+	target *url.URL
+}
+
+func (t redirect) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newTestClient(t *testing.T, nova *fakeNova) *Client {
+	t.Helper()
+	server := httptest.NewServer(nova)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+
+	c := NewClient("key")
+	c.httpClient = &http.Client{Transport: redirect{target: target}}
+	return c
+}
+
+func TestUploadLogsInAgainAfterSessionError(t *testing.T) {
+	nova := &fakeNova{expired: map[string]bool{"s1": true}}
+	c := newTestClient(t, nova)
+
+	subID, err := c.UploadImage(context.Background(), []byte("image"), "m31.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subID != 102 {
+		t.Errorf("subID = %d, want 102 from the retried upload", subID)
+	}
+	if nova.logins != 2 || nova.uploads != 2 {
+		t.Errorf("Nova saw %d logins and %d uploads, want 2 and 2", nova.logins, nova.uploads)
+	}
+	state := c.SessionState()
+	if !state.Active || state.Logins != 2 || state.Invalidations != 1 {
+		t.Errorf("SessionState = %+v, want active after 2 logins and 1 invalidation", state)
+	}
+
+	// The new session is reused.
+	if _, err := c.UploadImage(context.Background(), []byte("image"), "m31.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if nova.logins != 2 {
+		t.Errorf("Nova saw %d logins, want the session reused", nova.logins)
+	}
+}
+
+func TestUploadRetriesOnlyOnce(t *testing.T) {
+	nova := &fakeNova{expired: map[string]bool{"s1": true, "s2": true, "s3": true}}
+	c := newTestClient(t, nova)
+
+	if _, err := c.UploadImage(context.Background(), []byte("image"), "m31.jpg"); err == nil {
+		t.Fatal("UploadImage succeeded with every session refused")
+	}
+	if nova.logins != 2 || nova.uploads != 2 {
+		t.Errorf("Nova saw %d logins and %d uploads, want 2 and 2", nova.logins, nova.uploads)
+	}
+}
+
+func TestInvalidateSessionIgnoresStaleSession(t *testing.T) {
+	c := newTestClient(t, &fakeNova{})
+	session, err := c.GetSession(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A request that failed with an older session must not drop the
+	// current one.
+	c.InvalidateSession("s0")
+	if state := c.SessionState(); !state.Active || state.Invalidations != 0 {
+		t.Errorf("SessionState = %+v after invalidating a stale session", state)
+	}
+	c.InvalidateSession(session)
+	if state := c.SessionState(); state.Active || state.Invalidations != 1 {
+		t.Errorf("SessionState = %+v after invalidating the current session", state)
+	}
+}
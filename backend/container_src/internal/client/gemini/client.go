@@ -5,8 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"server/internal/apperr"
 )
 
 const baseURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent"
@@ -36,11 +40,26 @@ type part struct {
 }
 
 type generateResponse struct {
-	Candidates []candidate `json:"candidates"`
+	Candidates     []candidate     `json:"candidates"`
+	PromptFeedback *promptFeedback `json:"promptFeedback"`
 }
 
 type candidate struct {
-	Content content `json:"content"`
+	Content      content `json:"content"`
+	FinishReason string  `json:"finishReason"`
+}
+
+type promptFeedback struct {
+	BlockReason string `json:"blockReason"`
+}
+
+var refusalReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+	"OTHER":              true,
 }
 
 func (c *Client) GenerateFunFact(ctx context.Context, objectName, objectType string) (string, error) {
@@ -68,22 +87,40 @@ func (c *Client) GenerateFunFact(ctx context.Context, objectName, objectType str
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return "", apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+		return "", statusError(resp)
 	}
 
 	var result generateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return "", apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode response: %w", err))
+	}
+
+	if result.PromptFeedback != nil && result.PromptFeedback.BlockReason != "" {
+		return "", apperr.New(apperr.CodeLLMRefused, "").WithDetail("reason", result.PromptFeedback.BlockReason)
 	}
 
 	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no content in response")
+		if len(result.Candidates) > 0 && refusalReasons[result.Candidates[0].FinishReason] {
+			return "", apperr.New(apperr.CodeLLMRefused, "").WithDetail("reason", result.Candidates[0].FinishReason)
+		}
+		return "", apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("no content in response"))
 	}
 	return result.Candidates[0].Content.Parts[0].Text, nil
 }
+
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	err := fmt.Errorf("API returned status %d", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusTooManyRequests && strings.Contains(strings.ToLower(string(body)), "quota") {
+		return apperr.Wrap(apperr.CodeQuotaExceeded, "", err).WithDetail("upstream", "gemini")
+	}
+
+	return apperr.FromHTTPStatus(resp.StatusCode, err).WithDetail("upstream", "gemini")
+}
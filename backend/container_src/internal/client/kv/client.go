@@ -2,14 +2,23 @@ package kv
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"server/internal/apperr"
 )
 
-const baseURL = "https://api.cloudflare.com/client/v4/accounts"
+const (
+	baseURL       = "https://api.cloudflare.com/client/v4/accounts"
+	minTTLSeconds = 60
+)
 
 type Client struct {
 	httpClient  *http.Client
@@ -27,9 +36,14 @@ func NewClient(accountID, namespaceID, apiToken string) *Client {
 	}
 }
 
+// valueURL addresses a key in the namespace. Keys are escaped so that one
+// containing "/", "?" or "#" cannot reach a different API path.
+func (c *Client) valueURL(key string) string {
+	return fmt.Sprintf("%s/%s/storage/kv/namespaces/%s/values/%s", baseURL, c.accountID, c.namespaceID, url.PathEscape(key))
+}
+
 func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
-	url := fmt.Sprintf("%s/%s/storage/kv/namespaces/%s/values/%s", baseURL, c.accountID, c.namespaceID, key)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.valueURL(key), nil)
 	if err != nil {
 		return "", false, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -38,7 +52,7 @@ func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", false, fmt.Errorf("request failed: %w", err)
+		return "", false, apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
@@ -48,7 +62,7 @@ func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", false, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return "", false, apperr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode))
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -59,8 +73,18 @@ func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
 }
 
 func (c *Client) Put(ctx context.Context, key, value string) error {
-	url := fmt.Sprintf("%s/%s/storage/kv/namespaces/%s/values/%s", baseURL, c.accountID, c.namespaceID, key)
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, strings.NewReader(value))
+	return c.put(ctx, c.valueURL(key), value)
+}
+
+// PutWithTTL stores a value that KV deletes after ttl. KV rounds the TTL
+// up to its 60 second minimum.
+func (c *Client) PutWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	seconds := max(int(ttl.Seconds()), minTTLSeconds)
+	return c.put(ctx, c.valueURL(key)+"?expiration_ttl="+strconv.Itoa(seconds), value)
+}
+
+func (c *Client) put(ctx context.Context, target, value string) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", target, strings.NewReader(value))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -70,13 +94,84 @@ func (c *Client) Put(ctx context.Context, key, value string) error {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("request failed: %w", err))
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+		return apperr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.valueURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("request failed: %w", err))
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return apperr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode))
 	}
 	return nil
 }
+
+type ListedKey struct {
+	Name string `json:"name"`
+}
+
+type listResponse struct {
+	Success    bool        `json:"success"`
+	Result     []ListedKey `json:"result"`
+	ResultInfo struct {
+		Cursor string `json:"cursor"`
+	} `json:"result_info"`
+}
+
+// List returns up to limit keys starting with prefix, in lexicographic
+// order, and the cursor for the next page, which is empty after the last.
+// Listings are eventually consistent: a key written in the last minute may
+// be missing.
+func (c *Client) List(ctx context.Context, prefix, cursor string, limit int) ([]ListedKey, string, error) {
+	query := url.Values{"prefix": {prefix}, "limit": {strconv.Itoa(limit)}}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	target := fmt.Sprintf("%s/%s/storage/kv/namespaces/%s/keys?%s", baseURL, c.accountID, c.namespaceID, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", apperr.Wrap(apperr.CodeUpstreamUnavailable, "", fmt.Errorf("request failed: %w", err))
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", apperr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode))
+	}
+
+	var result listResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", apperr.Wrap(apperr.CodeUpstreamError, "", fmt.Errorf("failed to decode key list: %w", err))
+	}
+	if !result.Success {
+		return nil, "", apperr.Wrap(apperr.CodeUpstreamError, "", errors.New("key list failed"))
+	}
+	return result.Result, result.ResultInfo.Cursor, nil
+}
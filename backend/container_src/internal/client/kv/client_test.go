@@ -0,0 +1,27 @@
+package kv
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestValueURLEscapesKeys(t *testing.T) {
+	c := NewClient("account", "namespace", "token")
+	key := "ratelimit:device:../../../namespaces/other?x=1#y"
+
+	u, err := url.Parse(c.valueURL(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		t.Errorf("key leaked into the query or fragment: %s", u)
+	}
+	prefix := "/client/v4/accounts/account/storage/kv/namespaces/namespace/values/"
+	if !strings.HasPrefix(u.Path, prefix) || u.Path[len(prefix):] != key {
+		t.Errorf("path = %q, want the key as one segment after %q", u.Path, prefix)
+	}
+	if strings.Count(u.EscapedPath(), "/") != strings.Count(prefix, "/") {
+		t.Errorf("escaped path %q has extra segments", u.EscapedPath())
+	}
+}
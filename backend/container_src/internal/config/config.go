@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
 	Port                  string
@@ -9,20 +14,110 @@ type Config struct {
 	CloudflareAccountID   string
 	CloudflareNamespaceID string
 	CloudflareAPIToken    string
+	V1DeprecatedAt        time.Time
+	V1Sunset              time.Time
+	RequireAPIKey         bool
+	APIKeysFile           string
+	AdminAPIKey           string
+	RateLimitSubmit       string
+	RateLimitPoll         string
+	RateLimitObject       string
+	RateLimitRegister     string
+	RateLimitStore        string
+	DeviceTokenKeys       string
+	DeviceTokenTTL        time.Duration
+	DeviceTokenGrace      time.Duration
+	MinorBodiesFile       string
+	MinorBodyLimitMag     float64
+	TLEFile               string
 }
 
 func Load() *Config {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
 	return &Config{
-		Port:                  port,
+		Port:                  getEnv("PORT", "8080"),
 		AstrometryAPIKey:      os.Getenv("ASTROMETRY_API_KEY"),
 		GeminiAPIKey:          os.Getenv("GEMINI_API_KEY"),
 		CloudflareAccountID:   os.Getenv("CLOUDFLARE_ACCOUNT_ID"),
 		CloudflareNamespaceID: os.Getenv("CLOUDFLARE_NAMESPACE_ID"),
 		CloudflareAPIToken:    os.Getenv("CLOUDFLARE_API_TOKEN"),
+		V1DeprecatedAt:        getDate("API_V1_DEPRECATED_AT", "2026-11-01"),
+		V1Sunset:              getDate("API_V1_SUNSET", "2027-11-01"),
+		RequireAPIKey:         getBool("REQUIRE_API_KEY", false),
+		APIKeysFile:           os.Getenv("API_KEYS_FILE"),
+		AdminAPIKey:           os.Getenv("ADMIN_API_KEY"),
+		RateLimitSubmit:       getEnv("RATE_LIMIT_SUBMIT", "6/m:10"),
+		RateLimitPoll:         getEnv("RATE_LIMIT_POLL", "60/m:30"),
+		RateLimitObject:       getEnv("RATE_LIMIT_OBJECT", "30/m:20"),
+		RateLimitRegister:     getEnv("RATE_LIMIT_REGISTER", "10/h:5"),
+		RateLimitStore:        getEnv("RATE_LIMIT_STORE", "memory"),
+		DeviceTokenKeys:       os.Getenv("DEVICE_TOKEN_KEYS"),
+		DeviceTokenTTL:        getDuration("DEVICE_TOKEN_TTL", 365*24*time.Hour),
+		DeviceTokenGrace:      getDuration("DEVICE_TOKEN_REFRESH_GRACE", 90*24*time.Hour),
+		MinorBodiesFile:       os.Getenv("MINOR_BODIES_FILE"),
+		MinorBodyLimitMag:     getFloat("MINOR_BODY_LIMIT_MAG", 14),
+		TLEFile:               os.Getenv("TLE_FILE"),
+	}
+}
+
+func (c *Config) KVEnabled() bool {
+	return c.CloudflareAccountID != "" && c.CloudflareNamespaceID != "" && c.CloudflareAPIToken != ""
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDate(key, fallback string) time.Time {
+	value := getEnv(key, fallback)
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s %q: %v", key, value, err)
+		return time.Time{}
+	}
+	return t
+}
+
+func getBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s %q: %v", key, value, err)
+		return fallback
+	}
+	return b
+}
+
+func getFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Ignoring invalid %s %q: %v", key, value, err)
+		return fallback
+	}
+	return f
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s %q: %v", key, value, err)
+		return fallback
 	}
+	return d
 }
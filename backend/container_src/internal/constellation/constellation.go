@@ -0,0 +1,84 @@
+// Package constellation finds the IAU constellation containing a position,
+// using the official boundaries (Delporte 1930) as tabulated by Roman
+// (1987, PASP 99, 695).
+package constellation
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+
+	"server/internal/astro"
+)
+
+//go:embed boundaries.txt
+var boundariesData string
+
+// b1875 is the Julian date of the Besselian epoch 1875.0, the equinox the
+// boundaries were drawn in.
+const b1875 = 2405889.258550475
+
+type Constellation struct {
+	Abbreviation string
+	Name         string
+}
+
+// boundary is one row of Roman's table: the band north of Dec, between the
+// two right ascensions, in degrees.
+type boundary struct {
+	raLow, raHigh, decLow float64
+	abbreviation          string
+}
+
+var boundaries = parseBoundaries(boundariesData)
+
+// At returns the constellation containing a J2000 position in degrees.
+func At(eq astro.Equatorial) Constellation {
+	eq = astro.Precess(eq, astro.J2000, b1875)
+	for _, b := range boundaries {
+		if eq.Dec >= b.decLow && eq.RA >= b.raLow && eq.RA < b.raHigh {
+			return Constellation{Abbreviation: b.abbreviation, Name: names[b.abbreviation]}
+		}
+	}
+	// The last row covers the whole sky down to the south pole.
+	return Constellation{Abbreviation: "OCT", Name: names["OCT"]}
+}
+
+// ByName looks up a constellation by its IAU name or three-letter
+// abbreviation, ignoring case.
+func ByName(name string) (Constellation, bool) {
+	for abbreviation, full := range names {
+		if strings.EqualFold(name, abbreviation) || strings.EqualFold(name, full) {
+			return Constellation{Abbreviation: abbreviation, Name: full}, true
+		}
+	}
+	return Constellation{}, false
+}
+
+func parseBoundaries(text string) []boundary {
+	var list []boundary
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		raLow, err1 := strconv.ParseFloat(fields[0], 64)
+		raHigh, err2 := strconv.ParseFloat(fields[1], 64)
+		decLow, err3 := strconv.ParseFloat(fields[2], 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		list = append(list, boundary{
+			raLow:        raLow * 15,
+			raHigh:       raHigh * 15,
+			decLow:       decLow,
+			abbreviation: fields[3],
+		})
+	}
+	return list
+}
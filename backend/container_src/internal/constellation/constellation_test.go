@@ -0,0 +1,101 @@
+package constellation
+
+import (
+	"strings"
+	"testing"
+
+	"server/internal/astro"
+	"server/internal/model/data"
+)
+
+func TestTable(t *testing.T) {
+	if len(boundaries) != 357 {
+		t.Errorf("parsed %d boundary rows, want 357", len(boundaries))
+	}
+	seen := make(map[string]bool)
+	for _, b := range boundaries {
+		if _, ok := names[b.abbreviation]; !ok {
+			t.Errorf("row %v names unknown constellation %q", b, b.abbreviation)
+		}
+		seen[b.abbreviation] = true
+	}
+	if len(seen) != 88 {
+		t.Errorf("table covers %d constellations, want 88", len(seen))
+	}
+}
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		name string
+		eq   astro.Equatorial
+		want string
+	}{
+		{"Polaris", astro.Equatorial{RA: 37.95, Dec: 89.264}, "UMI"},
+		{"Vega", astro.Equatorial{RA: 279.235, Dec: 38.784}, "LYR"},
+		{"Betelgeuse", astro.Equatorial{RA: 88.793, Dec: 7.407}, "ORI"},
+		{"Sirius", astro.Equatorial{RA: 101.287, Dec: -16.716}, "CMA"},
+		{"Acrux", astro.Equatorial{RA: 186.650, Dec: -63.099}, "CRU"},
+		{"Alpheratz", astro.Equatorial{RA: 2.097, Dec: 29.091}, "AND"},
+		{"Sigma Octantis", astro.Equatorial{RA: 317.2, Dec: -88.956}, "OCT"},
+		// Roman's own worked example, precessed from 1950.0.
+		{"Roman test point", astro.Precess(astro.Equatorial{RA: 9.0 * 15, Dec: 65}, 2433282.4235, astro.J2000), "UMA"},
+	}
+	for _, tt := range tests {
+		if got := At(tt.eq); got.Abbreviation != tt.want {
+			t.Errorf("%s: At(%v) = %s, want %s", tt.name, tt.eq, got.Abbreviation, tt.want)
+		}
+	}
+}
+
+// TestCatalog checks every catalog entry with a position lies in the
+// constellation it is listed under.
+func TestCatalog(t *testing.T) {
+	for _, info := range data.All() {
+		if info.Position == nil || info.Constellation == "" {
+			continue
+		}
+		want, ok := ByName(info.Constellation)
+		if !ok {
+			t.Errorf("%s: unknown constellation %q", info.Name, info.Constellation)
+			continue
+		}
+		got := At(astro.Equatorial{RA: info.Position.RA, Dec: info.Position.Dec})
+		if got != want {
+			t.Errorf("%s at (%.3f, %.3f) lies in %s, catalog says %s", info.Name, info.Position.RA, info.Position.Dec, got.Name, want.Name)
+		}
+	}
+}
+
+func TestFigures(t *testing.T) {
+	// Stars a figure borrows from a neighbouring constellation.
+	borrowed := map[int]bool{677: true, 25428: true}
+
+	listed := 0
+	for _, line := range strings.Split(figuresData, "\n") {
+		if _, pairs, ok := strings.Cut(line, "|"); ok && !strings.HasPrefix(line, "*") && !strings.HasPrefix(line, "#") {
+			listed += len(strings.Fields(pairs))
+		}
+	}
+
+	segments := 0
+	for _, figure := range Figures() {
+		for _, seg := range figure.Segments {
+			segments++
+			for _, star := range []Star{seg.From, seg.To} {
+				if got := At(star.Position); got != figure.Constellation && !borrowed[star.HIP] {
+					t.Errorf("%s: HIP %d (%s) lies in %s", figure.Name, star.HIP, star.Name, got.Name)
+				}
+				// Stars also in the object catalog must agree with it.
+				if info, ok := data.GetObjectInfo(star.Name); ok && info.Position != nil {
+					catalog := astro.Equatorial{RA: info.Position.RA, Dec: info.Position.Dec}
+					if d := astro.Separation(star.Position, catalog); d > 1.0/60 {
+						t.Errorf("%s: HIP %d is %.1f' from the catalog position", star.Name, star.HIP, d*60)
+					}
+				}
+			}
+		}
+	}
+	if segments != listed {
+		t.Errorf("resolved %d of %d segments", segments, listed)
+	}
+}
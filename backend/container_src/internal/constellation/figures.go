@@ -0,0 +1,116 @@
+package constellation
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+
+	"server/internal/astro"
+)
+
+//go:embed figures.txt
+var figuresData string
+
+// Star is a Hipparcos star used in a stick figure.
+type Star struct {
+	HIP      int
+	Name     string
+	Position astro.Equatorial // J2000
+}
+
+// Segment is one line of a stick figure.
+type Segment struct {
+	From, To Star
+}
+
+// Figure is a constellation's stick figure.
+type Figure struct {
+	Constellation
+	Segments []Segment
+}
+
+var figures = parseFigures(figuresData)
+
+// Figures returns the bundled stick figures. Only the prominent
+// constellations have one.
+func Figures() []Figure {
+	return figures
+}
+
+func parseFigures(text string) []Figure {
+	stars := make(map[int]Star)
+	var list []Figure
+	var pending [][]string
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if strings.HasPrefix(line, "*") {
+			if len(parts) < 4 {
+				continue
+			}
+			hip, err := strconv.Atoi(parts[0][1:])
+			ra, okRA := sexagesimal(parts[2])
+			dec, okDec := sexagesimal(parts[3])
+			if err != nil || !okRA || !okDec {
+				continue
+			}
+			stars[hip] = Star{HIP: hip, Name: parts[1], Position: astro.Equatorial{RA: ra * 15, Dec: dec}}
+			continue
+		}
+		if len(parts) == 2 {
+			pending = append(pending, parts)
+		}
+	}
+
+	// Figures are resolved once every star is known, since one may borrow
+	// a star listed further down.
+	for _, parts := range pending {
+		name, ok := names[parts[0]]
+		if !ok {
+			continue
+		}
+		figure := Figure{Constellation: Constellation{Abbreviation: parts[0], Name: name}}
+		for _, pair := range strings.Fields(parts[1]) {
+			from, to, ok := strings.Cut(pair, "-")
+			if !ok {
+				continue
+			}
+			a, errA := strconv.Atoi(from)
+			b, errB := strconv.Atoi(to)
+			starA, okA := stars[a]
+			starB, okB := stars[b]
+			if errA != nil || errB != nil || !okA || !okB {
+				continue
+			}
+			figure.Segments = append(figure.Segments, Segment{From: starA, To: starB})
+		}
+		list = append(list, figure)
+	}
+	return list
+}
+
+// sexagesimal parses "hh:mm:ss.s" or "±dd:mm:ss" into decimal units.
+func sexagesimal(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+
+	var value float64
+	scale := 1.0
+	for _, field := range strings.Split(strings.TrimLeft(s, "+-"), ":") {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v < 0 {
+			return 0, false
+		}
+		value += v / scale
+		scale *= 60
+	}
+	return sign * value, true
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"server/internal/apperr"
+	"server/internal/auth"
+	"server/internal/service/minorbody"
+	"server/internal/view"
+)
+
+type KeyService interface {
+	CreateKey(ctx context.Context, name string, scopes []auth.Scope, quota auth.Quota) (*auth.Key, string, error)
+	RevokeKey(ctx context.Context, id string) (*auth.Key, error)
+}
+
+type MinorBodyService interface {
+	Refresh(ctx context.Context, data []byte) (*minorbody.Summary, error)
+}
+
+// maxElementsBytes bounds an uploaded elements file; trim MPCORB.DAT to the
+// numbered asteroids of interest rather than uploading all of it.
+const maxElementsBytes = 64 << 20
+
+type AdminController struct {
+	keys        KeyService
+	devices     DeviceService
+	minorBodies MinorBodyService
+}
+
+func NewAdminController(keys KeyService, devices DeviceService, minorBodies MinorBodyService) *AdminController {
+	return &AdminController{keys: keys, devices: devices, minorBodies: minorBodies}
+}
+
+func (c *AdminController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req view.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Invalid JSON body"))
+		return
+	}
+
+	if req.Name == "" || len(req.Scopes) == 0 {
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Name and at least one scope are required"))
+		return
+	}
+
+	scopes := make([]auth.Scope, len(req.Scopes))
+	for i, s := range req.Scopes {
+		scopes[i] = auth.Scope(s)
+	}
+
+	key, raw, err := c.keys.CreateKey(r.Context(), req.Name, scopes, auth.Quota(req.Quota))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := toAPIKeyResponse(key)
+	resp.Key = raw
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (c *AdminController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, err := c.keys.RevokeKey(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if key == nil {
+		writeError(w, r, apperr.New(apperr.CodeNotFound, "API key not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIKeyResponse(key))
+}
+
+func (c *AdminController) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	deviceID := chi.URLParam(r, "id")
+	if err := c.devices.Revoke(r.Context(), deviceID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, view.DeviceRevocationResponse{DeviceID: deviceID, Revoked: true})
+}
+
+func (c *AdminController) RotateDeviceKeys(w http.ResponseWriter, r *http.Request) {
+	kid, err := c.devices.RotateKeys(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, view.DeviceKeyRotationResponse{ActiveKeyID: kid})
+}
+
+func (c *AdminController) RetireDeviceKey(w http.ResponseWriter, r *http.Request) {
+	kid := chi.URLParam(r, "kid")
+	if err := c.devices.RetireKey(r.Context(), kid); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, view.DeviceKeyRetirementResponse{KeyID: kid, Retired: true})
+}
+
+func (c *AdminController) RefreshMinorBodies(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxElementsBytes)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Elements file is too large").WithDetail("maxBytes", maxElementsBytes))
+			return
+		}
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Failed to parse multipart form"))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "No elements file provided"))
+		return
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, r, apperr.Wrap(apperr.CodeInternal, "Failed to read elements file", err))
+		return
+	}
+
+	summary, err := c.minorBodies.Refresh(r.Context(), data)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, view.MinorBodyRefreshResponse{
+		Comets:    summary.Comets,
+		Asteroids: summary.Asteroids,
+		UpdatedAt: summary.UpdatedAt,
+		Persisted: summary.Persisted,
+	})
+}
+
+func toAPIKeyResponse(key *auth.Key) view.APIKeyResponse {
+	scopes := make([]string, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+
+	return view.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scopes:    scopes,
+		Quota:     view.APIKeyQuota(key.Quota),
+		CreatedAt: key.CreatedAt,
+		Revoked:   key.Revoked,
+	}
+}
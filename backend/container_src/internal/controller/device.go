@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"server/internal/device"
+	"server/internal/view"
+)
+
+type DeviceService interface {
+	Register(ctx context.Context, deviceID string) (*device.Registration, error)
+	Revoke(ctx context.Context, deviceID string) error
+	RotateKeys(ctx context.Context) (string, error)
+	RetireKey(ctx context.Context, kid string) error
+}
+
+type DeviceController struct {
+	service DeviceService
+}
+
+func NewDeviceController(service DeviceService) *DeviceController {
+	return &DeviceController{service: service}
+}
+
+func (c *DeviceController) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	deviceID, _ := device.FromContext(r.Context())
+	registration, err := c.service.Register(r.Context(), deviceID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if deviceID != "" {
+		status = http.StatusOK
+	}
+
+	writeJSON(w, status, view.DeviceRegistrationResponse{
+		DeviceID:  registration.DeviceID,
+		Token:     registration.Token,
+		ExpiresAt: registration.ExpiresAt,
+	})
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+
+	"server/internal/client/astrometry"
+	"server/internal/view"
+)
+
+type SessionReporter interface {
+	SessionState() astrometry.SessionState
+}
+
+type HealthController struct {
+	astrometry SessionReporter
+}
+
+func NewHealthController(astrometry SessionReporter) *HealthController {
+	return &HealthController{astrometry: astrometry}
+}
+
+func (c *HealthController) GetAstrometrySession(w http.ResponseWriter, r *http.Request) {
+	state := c.astrometry.SessionState()
+	writeJSON(w, http.StatusOK, view.SessionState{
+		Active:        state.Active,
+		ExpiresAt:     view.OptionalTime(state.ExpiresAt),
+		LastLoginAt:   view.OptionalTime(state.LastLoginAt),
+		Logins:        state.Logins,
+		Invalidations: state.Invalidations,
+		LastError:     state.LastError,
+	})
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"server/internal/apperr"
+	"server/internal/device"
+	"server/internal/model"
+	"server/internal/service/history"
+	"server/internal/view"
+)
+
+type HistoryService interface {
+	List(ctx context.Context, deviceID string, q history.Query) (*history.Page, error)
+	Get(ctx context.Context, deviceID string, id int) (*model.Job, error)
+	Delete(ctx context.Context, deviceID string, id int) error
+	Thumbnail(ctx context.Context, deviceID string, id int) ([]byte, error)
+}
+
+type HistoryController struct {
+	service HistoryService
+}
+
+func NewHistoryController(service HistoryService) *HistoryController {
+	return &HistoryController{service: service}
+}
+
+func (c *HistoryController) ListHistory(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	deviceID, _ := device.FromContext(r.Context())
+	page, err := c.service.List(r.Context(), deviceID, q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	base := strings.TrimSuffix(r.URL.Path, "/")
+	items := make([]view.HistoryItem, len(page.Items))
+	for i, summary := range page.Items {
+		items[i] = view.FromJobSummary(summary, thumbnailURL(base, summary.ID))
+	}
+
+	writeJSON(w, http.StatusOK, view.HistoryPage{Items: items, NextCursor: page.NextCursor})
+}
+
+func (c *HistoryController) GetHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := historyID(w, r)
+	if !ok {
+		return
+	}
+
+	deviceID, _ := device.FromContext(r.Context())
+	job, err := c.service.Get(r.Context(), deviceID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	base := strings.TrimSuffix(r.URL.Path, "/"+chi.URLParam(r, "id"))
+	writeJSON(w, http.StatusOK, view.FromJobDetail(job, thumbnailURL(base, job.ID)))
+}
+
+func (c *HistoryController) DeleteHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := historyID(w, r)
+	if !ok {
+		return
+	}
+
+	deviceID, _ := device.FromContext(r.Context())
+	if err := c.service.Delete(r.Context(), deviceID, id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *HistoryController) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	id, ok := historyID(w, r)
+	if !ok {
+		return
+	}
+
+	deviceID, _ := device.FromContext(r.Context())
+	data, err := c.service.Thumbnail(r.Context(), deviceID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(data)
+}
+
+func parseHistoryQuery(r *http.Request) (history.Query, error) {
+	values := r.URL.Query()
+	q := history.Query{
+		Cursor:        values.Get("cursor"),
+		Object:        values.Get("object"),
+		Constellation: values.Get("constellation"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return q, apperr.New(apperr.CodeInvalidRequest, "Invalid limit").WithDetail("maxLimit", history.MaxLimit)
+		}
+		q.Limit = n
+	}
+
+	var err error
+	if q.From, err = parseHistoryDate(values.Get("from"), false); err != nil {
+		return q, apperr.New(apperr.CodeInvalidRequest, "Invalid from date")
+	}
+	if q.To, err = parseHistoryDate(values.Get("to"), true); err != nil {
+		return q, apperr.New(apperr.CodeInvalidRequest, "Invalid to date")
+	}
+	return q, nil
+}
+
+// parseHistoryDate accepts RFC 3339 timestamps or plain dates. A plain "to"
+// date includes the whole day.
+func parseHistoryDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func historyID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Invalid history ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+func thumbnailURL(base string, id int) string {
+	return base + "/" + strconv.Itoa(id) + "/thumbnail.jpg"
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"server/internal/export"
+	"server/internal/service/tonight"
+)
+
+type ObservingListService interface {
+	FromSolve(ctx context.Context, subID int) (*export.List, error)
+	FromTonight(q tonight.Query) *export.List
+}
+
+type ObservingListController struct {
+	service ObservingListService
+}
+
+func NewObservingListController(service ObservingListService) *ObservingListController {
+	return &ObservingListController{service: service}
+}
+
+func (c *ObservingListController) GetSolveList(w http.ResponseWriter, r *http.Request) {
+	subID, err := jobIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	format, columns, err := parseListFormat(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	list, err := c.service.FromSolve(r.Context(), subID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeList(w, r, list, format, columns, fmt.Sprintf("starseek-%d", subID))
+}
+
+func (c *ObservingListController) GetTonightList(w http.ResponseWriter, r *http.Request) {
+	q, err := parseTonightQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	format, columns, err := parseListFormat(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	list := c.service.FromTonight(q)
+	writeList(w, r, list, format, columns, "starseek-tonight-"+q.Date.Format("2006-01-02"))
+}
+
+func parseListFormat(r *http.Request) (export.ListFormat, []export.Column, error) {
+	format, ok := export.ParseListFormat(r.FormValue("format"))
+	if !ok {
+		return "", nil, invalidField("format", "must be skylist, stellarium, astroplanner, csv or jsonl")
+	}
+
+	columns, unknown, ok := export.ParseColumns(r.FormValue("columns"))
+	if !ok {
+		names := make([]string, len(export.Columns))
+		for i, c := range export.Columns {
+			names[i] = string(c)
+		}
+		return "", nil, invalidField("columns", fmt.Sprintf("unknown column %q; use %s", unknown, strings.Join(names, ", ")))
+	}
+	return format, columns, nil
+}
+
+// writeList sends the list as a download named after the job or night.
+func writeList(w http.ResponseWriter, r *http.Request, list *export.List, format export.ListFormat, columns []export.Column, filename string) {
+	data, err := export.EncodeList(*list, format, columns)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format.Extension()))
+	w.Write(data)
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"server/internal/apperr"
+	"server/internal/view"
+)
+
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeProblemJSON = "application/problem+json"
+	// problemType says the problem has no meaning beyond its status; the
+	// code member tells problems apart (RFC 7807 section 4.2).
+	problemType = "about:blank"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	writeJSONAs(w, status, contentTypeJSON, v)
+}
+
+func writeJSONAs(w http.ResponseWriter, status int, contentType string, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := apperr.As(err)
+	status := apperr.Status(e.Code)
+	requestID := middleware.GetReqID(r.Context())
+	if status >= http.StatusInternalServerError {
+		log.Printf("Request %s failed (%s): %v", requestID, e.Code, err)
+	}
+
+	if requestID != "" {
+		w.Header().Set("X-Request-Id", requestID)
+	}
+
+	if !prefersProblemJSON(r.Header.Get("Accept")) {
+		writeJSON(w, status, view.ErrorResponse{
+			Error:   e.PublicMessage(),
+			Code:    string(e.Code),
+			Details: e.Details,
+		})
+		return
+	}
+
+	writeJSONAs(w, status, contentTypeProblemJSON, view.Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.PublicMessage(),
+		Instance:  r.URL.RequestURI(),
+		Code:      string(e.Code),
+		RequestID: requestID,
+		Details:   e.Details,
+		Error:     e.PublicMessage(),
+	})
+}
+
+func prefersProblemJSON(accept string) bool {
+	problemQ, jsonQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		switch mediaType {
+		case contentTypeProblemJSON:
+			problemQ = max(problemQ, q)
+		case contentTypeJSON:
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return problemQ > 0 && problemQ >= jsonQ
+}
+
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, err)
+}
@@ -2,40 +2,68 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"server/internal/apperr"
+	"server/internal/auth"
+	"server/internal/device"
+	"server/internal/export"
+	"server/internal/fits"
+	"server/internal/model"
+	"server/internal/overlay"
+	"server/internal/render"
 	"server/internal/service/object"
 	"server/internal/service/solve"
 	"server/internal/view"
 )
 
 type SolveService interface {
-	SubmitImage(ctx context.Context, imageData []byte, filename string) (int, error)
+	SubmitImage(ctx context.Context, submission solve.Submission) (int, error)
 	GetJobStatus(ctx context.Context, subID int) (*solve.JobStatus, error)
+	Grid(ctx context.Context, subID int, density overlay.Density) (*overlay.Grid, error)
+	Chart(ctx context.Context, subID int) ([]byte, error)
+	WCSHeader(ctx context.Context, subID int, caller solve.Caller) (*fits.Header, error)
+	ImageWithAVM(ctx context.Context, subID int, caller solve.Caller) ([]byte, error)
+	KML(ctx context.Context, subID int) ([]byte, error)
+	KMZ(ctx context.Context, subID int, caller solve.Caller) ([]byte, error)
+	Annotated(ctx context.Context, subID int, format render.Format, style render.Style) ([]byte, error)
 }
 
+const maxUploadBytes = 32 << 20
+
 type SolveController struct {
 	service SolveService
+	views   Views
 }
 
-func NewSolveController(service SolveService) *SolveController {
-	return &SolveController{service: service}
+func NewSolveController(service SolveService, views Views) *SolveController {
+	return &SolveController{service: service, views: views}
 }
 
 func (c *SolveController) SubmitImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		writeError(w, http.StatusBadRequest, "Failed to parse multipart form")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, r, apperr.ErrImageTooLarge.WithDetail("maxBytes", maxUploadBytes))
+			return
+		}
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Failed to parse multipart form"))
 		return
 	}
 
 	file, header, err := r.FormFile("image")
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "No image provided")
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "No image provided"))
 		return
 	}
 
@@ -43,16 +71,30 @@ func (c *SolveController) SubmitImage(w http.ResponseWriter, r *http.Request) {
 
 	imageData, err := io.ReadAll(file)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to read image")
+		writeError(w, r, apperr.Wrap(apperr.CodeInternal, "Failed to read image", err))
 		return
 	}
 
-	subID, err := c.service.SubmitImage(r.Context(), imageData, header.Filename)
+	observation, err := parseObservation(r)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to process image")
+		writeError(w, r, err)
 		return
 	}
 
+	caller := callerOf(r)
+	subID, err := c.service.SubmitImage(r.Context(), solve.Submission{
+		ImageData:   imageData,
+		Filename:    header.Filename,
+		DeviceID:    caller.DeviceID,
+		KeyID:       caller.KeyID,
+		Observation: observation,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	auth.Charge(r.Context())
+
 	writeJSON(w, http.StatusOK, view.SolveResponse{
 		JobID:  strconv.Itoa(subID),
 		Status: solve.StatusProcessing,
@@ -60,80 +102,286 @@ func (c *SolveController) SubmitImage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c *SolveController) GetSolveStatus(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
-	if jobID == "" {
-		writeError(w, http.StatusBadRequest, "Job ID required")
+	subID, err := jobIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	subID, err := strconv.Atoi(jobID)
+	status, err := c.service.GetJobStatus(r.Context(), subID)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid job ID")
+		writeError(w, r, err)
 		return
 	}
 
-	status, err := c.service.GetJobStatus(r.Context(), subID)
+	if status == nil {
+		writeError(w, r, apperr.New(apperr.CodeNotFound, "Job not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, c.views.JobStatus(status.For(callerOf(r))))
+}
+
+func (c *SolveController) GetGrid(w http.ResponseWriter, r *http.Request) {
+	subID, err := jobIDParam(r)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to check job status")
+		writeError(w, r, err)
 		return
 	}
 
-	if status == nil {
-		writeError(w, http.StatusNotFound, "Job not found")
+	density, ok := overlay.ParseDensity(r.FormValue("density"))
+	if !ok {
+		writeError(w, r, invalidField("density", "must be sparse, normal or dense"))
+		return
+	}
+
+	grid, err := c.service.Grid(r.Context(), subID, density)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, view.JobStatusResponse{
-		Status: status.Status,
-		Result: view.FromSolveResult(status.Result),
-		Error:  status.Error,
+	writeJSON(w, http.StatusOK, view.FromGrid(grid))
+}
+
+func (c *SolveController) GetChart(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, "image/svg+xml", c.service.Chart)
+}
+
+func (c *SolveController) GetWCSFITS(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, "application/fits", func(ctx context.Context, subID int) ([]byte, error) {
+		header, err := c.service.WCSHeader(ctx, subID, callerOf(r))
+		if err != nil {
+			return nil, err
+		}
+		return header.Bytes(), nil
 	})
 }
 
+func (c *SolveController) GetWCSText(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, "text/plain; charset=utf-8", func(ctx context.Context, subID int) ([]byte, error) {
+		header, err := c.service.WCSHeader(ctx, subID, callerOf(r))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(header.Text()), nil
+	})
+}
+
+func (c *SolveController) GetImageWithAVM(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, "image/jpeg", func(ctx context.Context, subID int) ([]byte, error) {
+		return c.service.ImageWithAVM(ctx, subID, callerOf(r))
+	})
+}
+
+func (c *SolveController) GetKML(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, export.KMLContentType, c.service.KML)
+}
+
+func (c *SolveController) GetKMZ(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, export.KMZContentType, func(ctx context.Context, subID int) ([]byte, error) {
+		return c.service.KMZ(ctx, subID, callerOf(r))
+	})
+}
+
+// callerOf identifies the device and API key behind a request.
+func callerOf(r *http.Request) solve.Caller {
+	var caller solve.Caller
+	caller.DeviceID, _ = device.FromContext(r.Context())
+	if key, ok := auth.FromContext(r.Context()); ok {
+		caller.KeyID = key.ID
+	}
+	return caller
+}
+
+// serveFile writes a file generated for the job in the URL.
+func (c *SolveController) serveFile(w http.ResponseWriter, r *http.Request, contentType string, generate func(ctx context.Context, subID int) ([]byte, error)) {
+	subID, err := jobIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	data, err := generate(r.Context(), subID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(data)
+}
+
+func (c *SolveController) GetAnnotatedPNG(w http.ResponseWriter, r *http.Request) {
+	c.getAnnotated(w, r, render.FormatPNG)
+}
+
+func (c *SolveController) GetAnnotatedJPEG(w http.ResponseWriter, r *http.Request) {
+	c.getAnnotated(w, r, render.FormatJPEG)
+}
+
+func (c *SolveController) getAnnotated(w http.ResponseWriter, r *http.Request, format render.Format) {
+	subID, err := jobIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	style, err := parseRenderStyle(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	data, err := c.service.Annotated(r.Context(), subID, format, style)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(data)
+}
+
+func parseRenderStyle(r *http.Request) (render.Style, error) {
+	var style render.Style
+	types, unknown, ok := render.ParseTypes(r.FormValue("types"))
+	if !ok {
+		return style, invalidField("types", fmt.Sprintf("unknown object type %q; use %s", unknown, strings.Join(render.ObjectTypes, ", ")))
+	}
+	style.Types = types
+
+	if style.LabelSize, ok = render.ParseLabelSize(r.FormValue("labelSize")); !ok {
+		return style, invalidField("labelSize", "must be small, medium or large")
+	}
+	if style.Theme, ok = render.ParseTheme(r.FormValue("theme")); !ok {
+		return style, invalidField("theme", "must be default, red or mono")
+	}
+	return style, nil
+}
+
+func jobIDParam(r *http.Request) (int, error) {
+	jobID := chi.URLParam(r, "jobId")
+	if jobID == "" {
+		return 0, apperr.New(apperr.CodeInvalidRequest, "Job ID required")
+	}
+
+	subID, err := strconv.Atoi(jobID)
+	if err != nil {
+		return 0, apperr.New(apperr.CodeInvalidRequest, "Invalid job ID")
+	}
+	return subID, nil
+}
+
 type ObjectService interface {
 	GetObjectDetail(ctx context.Context, name string) (*object.ObjectDetail, error)
 }
 
 type ObjectController struct {
 	service ObjectService
+	views   Views
 }
 
-func NewObjectController(service ObjectService) *ObjectController {
-	return &ObjectController{service: service}
+func NewObjectController(service ObjectService, views Views) *ObjectController {
+	return &ObjectController{service: service, views: views}
 }
 
 func (c *ObjectController) GetObjectDetail(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	if name == "" {
-		writeError(w, http.StatusBadRequest, "Object name required")
+		writeError(w, r, apperr.New(apperr.CodeInvalidRequest, "Object name required"))
 		return
 	}
 
 	obj, err := c.service.GetObjectDetail(r.Context(), name)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to generate object details")
+		writeError(w, r, err)
 		return
 	}
 
 	if obj == nil {
-		writeError(w, http.StatusNotFound, "Object not found")
+		writeError(w, r, apperr.New(apperr.CodeNotFound, "Object not found"))
 		return
 	}
+	if obj.Generated {
+		auth.Charge(r.Context())
+	}
 
-	writeJSON(w, http.StatusOK, view.ObjectDetailResponse{
-		Name:          obj.Name,
-		Type:          obj.Type,
-		Constellation: obj.Constellation,
-		FunFact:       obj.FunFact,
-	})
+	writeJSON(w, http.StatusOK, c.views.ObjectDetail(obj))
 }
 
-func writeJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(v)
+// parseObservation reads the optional capture metadata fields. Latitude and
+// longitude must be given together.
+func parseObservation(r *http.Request) (*model.Observation, error) {
+	var observation model.Observation
+
+	if value := r.FormValue("capturedAt"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, invalidField("capturedAt", "must be an RFC 3339 timestamp")
+		}
+		t = t.UTC()
+		observation.CapturedAt = &t
+	}
+
+	lat, hasLat, err := formFloat(r, "latitude", -90, 90)
+	if err != nil {
+		return nil, err
+	}
+	lon, hasLon, err := formFloat(r, "longitude", -180, 180)
+	if err != nil {
+		return nil, err
+	}
+	elevation, hasElevation, err := formFloat(r, "elevation", -500, 10000)
+	if err != nil {
+		return nil, err
+	}
+	if hasLat != hasLon {
+		return nil, invalidField("latitude", "latitude and longitude must be given together")
+	}
+	if hasLat {
+		observation.Location = &model.Location{Latitude: lat, Longitude: lon}
+		if hasElevation {
+			observation.Location.Elevation = &elevation
+		}
+	}
+
+	focalLength, _, err := formFloat(r, "focalLength", 0, 100000)
+	if err != nil {
+		return nil, err
+	}
+	equipment := model.Equipment{
+		Telescope:   strings.TrimSpace(r.FormValue("telescope")),
+		FocalLength: focalLength,
+		Camera:      strings.TrimSpace(r.FormValue("camera")),
+	}
+	if equipment != (model.Equipment{}) {
+		observation.Equipment = &equipment
+	}
+
+	if observation.IsEmpty() {
+		return nil, nil
+	}
+	return &observation, nil
+}
+
+func formFloat(r *http.Request, field string, lo, hi float64) (float64, bool, error) {
+	value := r.FormValue(field)
+	if value == "" {
+		return 0, false, nil
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(v) || v < lo || v > hi {
+		return 0, false, invalidField(field, fmt.Sprintf("must be a number between %g and %g", lo, hi))
+	}
+	return v, true, nil
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, view.ErrorResponse{Error: message})
+func invalidField(field, reason string) error {
+	return apperr.New(apperr.CodeInvalidRequest, "Invalid "+field).
+		WithDetail("field", field).
+		WithDetail("reason", reason)
 }
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/internal/service/tonight"
+	"server/internal/view"
+)
+
+type TonightService interface {
+	Recommend(q tonight.Query) *tonight.Night
+}
+
+type TonightController struct {
+	service TonightService
+}
+
+func NewTonightController(service TonightService) *TonightController {
+	return &TonightController{service: service}
+}
+
+func (c *TonightController) GetTonight(w http.ResponseWriter, r *http.Request) {
+	q, err := parseTonightQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, view.FromNight(c.service.Recommend(q)))
+}
+
+func parseTonightQuery(r *http.Request) (tonight.Query, error) {
+	q := tonight.Query{MinAltitude: tonight.DefaultMinAltitude, Limit: tonight.DefaultLimit}
+
+	lat, hasLat, err := formFloat(r, "lat", -90, 90)
+	if err != nil {
+		return q, err
+	}
+	lon, hasLon, err := formFloat(r, "lon", -180, 180)
+	if err != nil {
+		return q, err
+	}
+	if !hasLat || !hasLon {
+		return q, invalidField("lat", "lat and lon are required")
+	}
+	q.Latitude, q.Longitude = lat, lon
+
+	limitMag, hasLimitMag, err := formFloat(r, "limitMag", -2, 20)
+	if err != nil {
+		return q, err
+	}
+	if hasLimitMag {
+		q.LimitMagnitude = &limitMag
+	}
+
+	minAlt, hasMinAlt, err := formFloat(r, "minAlt", 0, 90)
+	if err != nil {
+		return q, err
+	}
+	if hasMinAlt {
+		q.MinAltitude = minAlt
+	}
+
+	if value := r.FormValue("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > tonight.MaxLimit {
+			return q, invalidField("limit", "must be between 1 and "+strconv.Itoa(tonight.MaxLimit))
+		}
+		q.Limit = n
+	}
+
+	q.Date = tonight.DefaultDate(time.Now(), q.Longitude)
+	if value := r.FormValue("date"); value != "" {
+		if q.Date, err = time.Parse(time.DateOnly, value); err != nil {
+			return q, invalidField("date", "must be a date in YYYY-MM-DD form")
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"server/internal/service/object"
+	"server/internal/service/solve"
+	"server/internal/view"
+)
+
+type Views interface {
+	JobStatus(status *solve.JobStatus) any
+	ObjectDetail(obj *object.ObjectDetail) any
+}
+
+type V1Views struct{}
+
+func (V1Views) JobStatus(status *solve.JobStatus) any {
+	return view.JobStatusResponse{
+		Status: status.Status,
+		Result: view.FromSolveResult(status.Result),
+		Error:  status.Error,
+	}
+}
+
+func (V1Views) ObjectDetail(obj *object.ObjectDetail) any {
+	return view.ObjectDetailResponse{
+		Name:          obj.Name,
+		Type:          obj.Type,
+		Constellation: obj.Constellation,
+		FunFact:       obj.FunFact,
+	}
+}
+
+type V2Views struct{}
+
+func (V2Views) JobStatus(status *solve.JobStatus) any {
+	return view.JobStatusResponseV2{
+		Status:      status.Status,
+		Result:      view.FromSolveResultV2(status.Result),
+		Error:       status.Error,
+		Observation: view.FromObservation(status.Observation),
+	}
+}
+
+func (V2Views) ObjectDetail(obj *object.ObjectDetail) any {
+	return view.ObjectDetailResponseV2{
+		Name:          obj.Name,
+		DisplayName:   obj.DisplayName,
+		Type:          obj.Type,
+		Constellation: obj.Constellation,
+		FunFact:       obj.FunFact,
+	}
+}
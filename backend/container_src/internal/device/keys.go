@@ -0,0 +1,220 @@
+package device
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	kvKeySetKey    = "device-token-keys"
+	reloadInterval = 30 * time.Second
+)
+
+var ErrActiveKey = errors.New("cannot retire the active device token key")
+
+type KVClient interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Put(ctx context.Context, key, value string) error
+}
+
+type keySetData struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+type KeySet struct {
+	mu         sync.RWMutex
+	active     string
+	keys       map[string][]byte
+	kv         KVClient
+	lastReload time.Time
+}
+
+// ParseKeySet reads "kid:base64secret,kid2:base64secret"; the first entry signs new tokens.
+func ParseKeySet(spec string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string][]byte)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, encoded, ok := strings.Cut(entry, ":")
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("invalid device token key %q", entry)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(secret) < 32 {
+			return nil, fmt.Errorf("device token key %q must be at least 32 base64 encoded bytes", kid)
+		}
+
+		ks.keys[kid] = secret
+		if ks.active == "" {
+			ks.active = kid
+		}
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) WithKV(ctx context.Context, kv KVClient) error {
+	ks.kv = kv
+	if err := ks.reload(ctx); err != nil {
+		return err
+	}
+
+	ks.mu.RLock()
+	empty := ks.active == ""
+	ks.mu.RUnlock()
+	if empty {
+		_, err := ks.Rotate(ctx)
+		return err
+	}
+	return ks.save(ctx)
+}
+
+func (ks *KeySet) Rotate(ctx context.Context) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate device token key: %w", err)
+	}
+
+	suffix, err := randomHex(3)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate device token key id: %w", err)
+	}
+
+	kid := time.Now().UTC().Format("20060102") + "-" + suffix
+	ks.mu.Lock()
+	ks.keys[kid] = secret
+	ks.active = kid
+	ks.mu.Unlock()
+
+	if err := ks.save(ctx); err != nil {
+		return "", err
+	}
+	return kid, nil
+}
+
+// Retire drops a rotated-out key; tokens it signed stop verifying.
+func (ks *KeySet) Retire(ctx context.Context, kid string) error {
+	ks.mu.Lock()
+	if kid == ks.active {
+		ks.mu.Unlock()
+		return ErrActiveKey
+	}
+	if _, ok := ks.keys[kid]; !ok {
+		ks.mu.Unlock()
+		return ErrUnknownKey
+	}
+	delete(ks.keys, kid)
+	ks.mu.Unlock()
+	return ks.save(ctx)
+}
+
+func (ks *KeySet) Active() string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.active
+}
+
+func (ks *KeySet) signingKey(ctx context.Context) (string, []byte, error) {
+	ks.mu.RLock()
+	kid, secret := ks.active, ks.keys[ks.active]
+	ks.mu.RUnlock()
+	if kid != "" {
+		return kid, secret, nil
+	}
+
+	kid, err := ks.Rotate(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return kid, ks.keys[kid], nil
+}
+
+func (ks *KeySet) lookup(kid string) ([]byte, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	secret, ok := ks.keys[kid]
+	return secret, ok
+}
+
+func (ks *KeySet) reloadIfStale(ctx context.Context) bool {
+	ks.mu.RLock()
+	stale := ks.kv != nil && time.Since(ks.lastReload) > reloadInterval
+	ks.mu.RUnlock()
+	return stale && ks.reload(ctx) == nil
+}
+
+func (ks *KeySet) reload(ctx context.Context) error {
+	if ks.kv == nil {
+		return nil
+	}
+
+	value, found, err := ks.kv.Get(ctx, kvKeySetKey)
+
+	ks.mu.Lock()
+	initial := ks.lastReload.IsZero()
+	ks.lastReload = time.Now()
+	ks.mu.Unlock()
+	if err != nil || !found {
+		return err
+	}
+
+	var data keySetData
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return fmt.Errorf("failed to decode device token keys: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(data.Keys))
+	for kid, encoded := range data.Keys {
+		if secret, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			keys[kid] = secret
+		}
+	}
+	if _, ok := keys[data.Active]; !ok {
+		return fmt.Errorf("device token keys in KV have no usable active key %q", data.Active)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if initial {
+		for kid, secret := range ks.keys {
+			if _, ok := keys[kid]; !ok {
+				keys[kid] = secret
+			}
+		}
+	}
+	ks.keys = keys
+	ks.active = data.Active
+	return nil
+}
+
+func (ks *KeySet) save(ctx context.Context) error {
+	if ks.kv == nil {
+		return nil
+	}
+
+	ks.mu.RLock()
+	data := keySetData{Active: ks.active, Keys: make(map[string]string, len(ks.keys))}
+	for kid, secret := range ks.keys {
+		data.Keys[kid] = base64.StdEncoding.EncodeToString(secret)
+	}
+	ks.mu.RUnlock()
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode device token keys: %w", err)
+	}
+	return ks.kv.Put(ctx, kvKeySetKey, string(encoded))
+}
@@ -0,0 +1,79 @@
+package device
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"server/internal/apperr"
+)
+
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, err error)
+
+type contextKey struct{}
+
+func WithID(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, deviceID)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	deviceID, ok := ctx.Value(contextKey{}).(string)
+	return deviceID, ok && deviceID != ""
+}
+
+// Middleware attaches the device ID from a valid token to the request.
+// Requests without a token pass through anonymously.
+func (s *Service) Middleware(onError ErrorWriter) func(http.Handler) http.Handler {
+	return s.middleware(s.Verify, onError)
+}
+
+// RefreshMiddleware is Middleware for the registration endpoint: it also
+// accepts a token that expired within the grace period, so the device can
+// renew it.
+func (s *Service) RefreshMiddleware(onError ErrorWriter) func(http.Handler) http.Handler {
+	return s.middleware(s.VerifyForRefresh, onError)
+}
+
+func (s *Service) middleware(verify func(ctx context.Context, token string) (string, error), onError ErrorWriter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := extractToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			deviceID, err := verify(r.Context(), token)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithID(r.Context(), deviceID)))
+		})
+	}
+}
+
+func RequireDevice(onError ErrorWriter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := FromContext(r.Context()); !ok {
+				onError(w, r, apperr.New(apperr.CodeUnauthorized, "Device token required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func extractToken(r *http.Request) string {
+	if token := r.Header.Get("X-Device-Token"); token != "" {
+		return strings.TrimSpace(token)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if ok && strings.EqualFold(scheme, "Bearer") && strings.Count(token, ".") == 2 {
+		return token
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package device
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const kvRevokedPrefix = "device-revoked:"
+
+type RevocationList interface {
+	IsRevoked(ctx context.Context, deviceID string) (bool, error)
+	Revoke(ctx context.Context, deviceID string) error
+}
+
+type MemoryRevocationList struct {
+	mu      sync.RWMutex
+	revoked map[string]bool
+}
+
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{revoked: make(map[string]bool)}
+}
+
+func (l *MemoryRevocationList) IsRevoked(ctx context.Context, deviceID string) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.revoked[deviceID], nil
+}
+
+func (l *MemoryRevocationList) Revoke(ctx context.Context, deviceID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revoked[deviceID] = true
+	return nil
+}
+
+// revocationCacheTTL bounds how long another instance's revocation can go
+// unnoticed.
+const revocationCacheTTL = time.Minute
+
+type cachedRevocation struct {
+	revoked bool
+	expires time.Time
+}
+
+type KVRevocationList struct {
+	kv        KVClient
+	mu        sync.Mutex
+	cache     map[string]cachedRevocation
+	lastPrune time.Time
+}
+
+func NewKVRevocationList(kv KVClient) *KVRevocationList {
+	return &KVRevocationList{kv: kv, cache: make(map[string]cachedRevocation)}
+}
+
+func (l *KVRevocationList) IsRevoked(ctx context.Context, deviceID string) (bool, error) {
+	l.mu.Lock()
+	cached, ok := l.cache[deviceID]
+	l.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.revoked, nil
+	}
+
+	_, found, err := l.kv.Get(ctx, kvRevokedPrefix+deviceID)
+	if err != nil {
+		return false, err
+	}
+
+	l.remember(deviceID, found)
+	return found, nil
+}
+
+func (l *KVRevocationList) Revoke(ctx context.Context, deviceID string) error {
+	if err := l.kv.Put(ctx, kvRevokedPrefix+deviceID, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	l.remember(deviceID, true)
+	return nil
+}
+
+// remember caches a lookup, first dropping expired entries if it has been a
+// while, so the cache only holds devices seen in the last couple of minutes.
+func (l *KVRevocationList) remember(deviceID string, revoked bool) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > revocationCacheTTL {
+		l.lastPrune = now
+		for id, cached := range l.cache {
+			if !now.Before(cached.expires) {
+				delete(l.cache, id)
+			}
+		}
+	}
+	l.cache[deviceID] = cachedRevocation{revoked: revoked, expires: now.Add(revocationCacheTTL)}
+}
@@ -0,0 +1,57 @@
+package device
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeKV struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string) (string, bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	value, ok := kv.values[key]
+	return value, ok, nil
+}
+
+func (kv *fakeKV) Put(ctx context.Context, key, value string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.values[key] = value
+	return nil
+}
+
+func TestKVRevocationCacheDropsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	l := NewKVRevocationList(&fakeKV{values: make(map[string]string)})
+	if err := l.Revoke(ctx, "dev_revoked"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend a burst of devices was checked long enough ago to have expired.
+	l.mu.Lock()
+	for _, id := range []string{"dev_a", "dev_b", "dev_c"} {
+		l.cache[id] = cachedRevocation{expires: time.Now().Add(-time.Second)}
+	}
+	l.lastPrune = time.Time{}
+	l.mu.Unlock()
+
+	if revoked, err := l.IsRevoked(ctx, "dev_new"); err != nil || revoked {
+		t.Fatalf("IsRevoked(dev_new) = %v, %v", revoked, err)
+	}
+	l.mu.Lock()
+	size := len(l.cache)
+	l.mu.Unlock()
+	if size != 2 {
+		t.Errorf("cache holds %d entries after pruning, want 2", size)
+	}
+
+	if revoked, err := l.IsRevoked(ctx, "dev_revoked"); err != nil || !revoked {
+		t.Errorf("IsRevoked(dev_revoked) = %v, %v; want revoked", revoked, err)
+	}
+}
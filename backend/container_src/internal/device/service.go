@@ -0,0 +1,117 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"server/internal/apperr"
+)
+
+const idPrefix = "dev_"
+
+type Registration struct {
+	DeviceID  string
+	Token     string
+	ExpiresAt time.Time
+}
+
+type Service struct {
+	keys        *KeySet
+	revocations RevocationList
+	ttl         time.Duration
+	// grace is how long after expiry a token can still be refreshed.
+	grace time.Duration
+	now   func() time.Time
+}
+
+func NewService(keys *KeySet, revocations RevocationList, ttl, grace time.Duration) *Service {
+	return &Service{keys: keys, revocations: revocations, ttl: ttl, grace: grace, now: time.Now}
+}
+
+func (s *Service) Register(ctx context.Context, deviceID string) (*Registration, error) {
+	if deviceID == "" {
+		id, err := randomHex(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate device id: %w", err)
+		}
+		deviceID = idPrefix + id
+	}
+
+	jti, err := randomHex(8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	kid, secret, err := s.keys.signingKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	expiresAt := now.Add(s.ttl)
+	token, err := sign(kid, secret, Claims{
+		Subject:   deviceID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		ID:        jti,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Registration{DeviceID: deviceID, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+func (s *Service) Verify(ctx context.Context, token string) (string, error) {
+	return s.verify(ctx, token, false)
+}
+
+// VerifyForRefresh is Verify, except that a token which expired less than
+// the grace period ago is still accepted, so the device can get a new one
+// without losing its ID.
+func (s *Service) VerifyForRefresh(ctx context.Context, token string) (string, error) {
+	return s.verify(ctx, token, true)
+}
+
+func (s *Service) verify(ctx context.Context, token string, refresh bool) (string, error) {
+	now := s.now()
+	claims, err := parse(token, s.keys.lookup, now)
+	if errors.Is(err, ErrUnknownKey) && s.keys.reloadIfStale(ctx) {
+		claims, err = parse(token, s.keys.lookup, now)
+	}
+	if errors.Is(err, ErrExpiredToken) && refresh && now.Before(time.Unix(claims.ExpiresAt, 0).Add(s.grace)) {
+		err = nil
+	}
+	if err != nil {
+		return "", apperr.Wrap(apperr.CodeUnauthorized, "Invalid device token", err)
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, claims.Subject)
+	if err != nil {
+		return "", err
+	}
+	if revoked {
+		return "", apperr.New(apperr.CodeUnauthorized, "Device has been revoked")
+	}
+	return claims.Subject, nil
+}
+
+func (s *Service) Revoke(ctx context.Context, deviceID string) error {
+	return s.revocations.Revoke(ctx, deviceID)
+}
+
+func (s *Service) RotateKeys(ctx context.Context) (string, error) {
+	return s.keys.Rotate(ctx)
+}
+
+func (s *Service) RetireKey(ctx context.Context, kid string) error {
+	err := s.keys.Retire(ctx, kid)
+	switch {
+	case errors.Is(err, ErrUnknownKey):
+		return apperr.New(apperr.CodeNotFound, "Signing key not found")
+	case errors.Is(err, ErrActiveKey):
+		return apperr.New(apperr.CodeInvalidRequest, "Cannot retire the active signing key; rotate first")
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package device
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"server/internal/apperr"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	keys, err := ParseKeySet("k1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(keys, NewMemoryRevocationList(), 24*time.Hour, 7*24*time.Hour)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func advance(s *Service, d time.Duration) {
+	now := s.now().Add(d)
+	s.now = func() time.Time { return now }
+}
+
+func isUnauthorized(err error) bool {
+	return hasCode(err, apperr.CodeUnauthorized)
+}
+
+func hasCode(err error, code apperr.Code) bool {
+	var appErr *apperr.Error
+	return errors.As(err, &appErr) && appErr.Code == code
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	reg, err := s.Register(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(reg.DeviceID, idPrefix) {
+		t.Errorf("device ID %q lacks the %q prefix", reg.DeviceID, idPrefix)
+	}
+
+	if id, err := s.Verify(ctx, reg.Token); err != nil || id != reg.DeviceID {
+		t.Fatalf("Verify = %q, %v", id, err)
+	}
+
+	parts := strings.Split(reg.Token, ".")
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(claims), reg.DeviceID, "dev_other", 1))) + "." + parts[2]
+	for name, token := range map[string]string{"forged": forged, "garbage": "a.b.c", "truncated": parts[0] + "." + parts[1]} {
+		if _, err := s.Verify(ctx, token); !isUnauthorized(err) {
+			t.Errorf("Verify(%s) = %v, want unauthorized", name, err)
+		}
+	}
+}
+
+func TestExpiredTokenCanBeRefreshedWithinGrace(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	reg, err := s.Register(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	advance(s, 25*time.Hour)
+	if _, err := s.Verify(ctx, reg.Token); !isUnauthorized(err) {
+		t.Errorf("Verify of an expired token = %v, want unauthorized", err)
+	}
+	id, err := s.VerifyForRefresh(ctx, reg.Token)
+	if err != nil || id != reg.DeviceID {
+		t.Fatalf("VerifyForRefresh within grace = %q, %v", id, err)
+	}
+	renewed, err := s.Register(ctx, id)
+	if err != nil || renewed.DeviceID != reg.DeviceID {
+		t.Fatalf("Register(%s) = %+v, %v", id, renewed, err)
+	}
+	if got, err := s.Verify(ctx, renewed.Token); err != nil || got != reg.DeviceID {
+		t.Errorf("Verify of the renewed token = %q, %v", got, err)
+	}
+
+	advance(s, 7*24*time.Hour)
+	if _, err := s.VerifyForRefresh(ctx, reg.Token); !isUnauthorized(err) {
+		t.Errorf("VerifyForRefresh past grace = %v, want unauthorized", err)
+	}
+}
+
+func TestRevokedDeviceIsRejected(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	reg, err := s.Register(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Revoke(ctx, reg.DeviceID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Verify(ctx, reg.Token); !isUnauthorized(err) {
+		t.Errorf("Verify after revocation = %v, want unauthorized", err)
+	}
+	advance(s, 25*time.Hour)
+	if _, err := s.VerifyForRefresh(ctx, reg.Token); !isUnauthorized(err) {
+		t.Errorf("VerifyForRefresh after revocation = %v, want unauthorized", err)
+	}
+}
+
+func TestRotationKeepsOldTokensValid(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	before, err := s.Register(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kid, err := s.RotateKeys(ctx)
+	if err != nil || kid == "k1" {
+		t.Fatalf("RotateKeys = %q, %v", kid, err)
+	}
+	after, err := s.Register(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"before": before.Token, "after": after.Token} {
+		if _, err := s.Verify(ctx, token); err != nil {
+			t.Errorf("Verify(%s rotation) = %v", name, err)
+		}
+	}
+
+	if err := s.RetireKey(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(ctx, before.Token); !isUnauthorized(err) {
+		t.Errorf("Verify with a retired key = %v, want unauthorized", err)
+	}
+	if err := s.RetireKey(ctx, kid); !hasCode(err, apperr.CodeInvalidRequest) {
+		t.Errorf("RetireKey(active) = %v, want invalid_request", err)
+	}
+	if err := s.RetireKey(ctx, "k1"); !hasCode(err, apperr.CodeNotFound) {
+		t.Errorf("RetireKey(retired) = %v, want not_found", err)
+	}
+}
@@ -0,0 +1,105 @@
+package device
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid device token")
+	ErrExpiredToken = errors.New("device token expired")
+	ErrUnknownKey   = errors.New("device token signed with unknown key")
+)
+
+type Claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+var encoding = base64.RawURLEncoding
+
+func sign(kid string, secret []byte, claims Claims) (string, error) {
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token header: %w", err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	signingInput := encoding.EncodeToString(h) + "." + encoding.EncodeToString(c)
+	return signingInput + "." + encoding.EncodeToString(mac(secret, signingInput)), nil
+}
+
+func parse(token string, lookup func(kid string) ([]byte, bool), now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	secret, ok := lookup(h.Kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac(secret, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// Expired claims are still returned, with the error, so a refresh can
+	// tell how long ago the token lapsed.
+	if claims.ExpiresAt != 0 && now.Unix() >= claims.ExpiresAt {
+		return &claims, ErrExpiredToken
+	}
+	return &claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := encoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func mac(secret []byte, input string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(input))
+	return h.Sum(nil)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,170 @@
+// Package export encodes solve results in formats that other astronomy and
+// mapping software reads.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"server/internal/model"
+	"server/internal/wcs"
+)
+
+const (
+	KMLContentType = "application/vnd.google-earth.kml+xml"
+	KMZContentType = "application/vnd.google-earth.kmz"
+
+	// KMLImageHref is where a KML document expects the image: next to a
+	// plain .kml file, or inside a KMZ archive.
+	KMLImageHref = "image.jpg"
+)
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	XmlnsGX  string      `xml:"xmlns:gx,attr"`
+	Hint     string      `xml:"hint,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name    string     `xml:"name"`
+	Overlay kmlOverlay `xml:"GroundOverlay"`
+	Folder  *kmlFolder `xml:"Folder,omitempty"`
+}
+
+type kmlOverlay struct {
+	Name string  `xml:"name"`
+	Icon kmlIcon `xml:"Icon"`
+	Quad kmlQuad `xml:"gx:LatLonQuad"`
+}
+
+type kmlIcon struct {
+	Href string `xml:"href"`
+}
+
+type kmlQuad struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string   `xml:"name"`
+	Description string   `xml:"description,omitempty"`
+	Point       kmlPoint `xml:"Point"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// KML returns a document for Google Earth's Sky mode that lays the image
+// over its solved footprint, with a placemark for each identified object.
+// The image is referenced as KMLImageHref.
+func KML(name string, field *wcs.WCS, objects []model.CelestialObject) ([]byte, error) {
+	w, h := float64(field.ImageWidth)-0.5, float64(field.ImageHeight)-0.5
+	// gx:LatLonQuad lists the image corners counter-clockwise from the
+	// bottom left, which handles rotated and mirrored images exactly.
+	var quad []string
+	for _, c := range [][2]float64{{-0.5, h}, {w, h}, {w, -0.5}, {-0.5, -0.5}} {
+		quad = append(quad, skyCoordinates(field.PixelToSky(c[0], c[1])))
+	}
+
+	doc := kmlDocument{
+		Name: name,
+		Overlay: kmlOverlay{
+			Name: name,
+			Icon: kmlIcon{Href: KMLImageHref},
+			Quad: kmlQuad{Coordinates: strings.Join(quad, " ")},
+		},
+	}
+
+	var placemarks []kmlPlacemark
+	for _, o := range objects {
+		if o.Coordinates == nil {
+			continue
+		}
+		placemarks = append(placemarks, kmlPlacemark{
+			Name:        o.GetDisplayName(),
+			Description: describe(o),
+			Point:       kmlPoint{Coordinates: skyCoordinates(o.Coordinates.RA, o.Coordinates.Dec)},
+		})
+	}
+	if len(placemarks) > 0 {
+		doc.Folder = &kmlFolder{Name: "Identified objects", Placemarks: placemarks}
+	}
+
+	root := kmlRoot{
+		Xmlns:    "http://www.opengis.net/kml/2.2",
+		XmlnsGX:  "http://www.google.com/kml/ext/2.2",
+		Hint:     "target=sky",
+		Document: doc,
+	}
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode kml: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// KMZ packs the KML document and the image into one archive. The image goes
+// in as given, so callers strip metadata the recipient should not see.
+func KMZ(name string, field *wcs.WCS, objects []model.CelestialObject, image []byte) ([]byte, error) {
+	doc, err := KML(name, field, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// Google Earth opens the first .kml entry, so it goes first.
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{"doc.kml", doc}, {KMLImageHref, image}} {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write kmz: %w", err)
+		}
+		if _, err := f.Write(file.data); err != nil {
+			return nil, fmt.Errorf("failed to write kmz: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write kmz: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// skyCoordinates formats a J2000 position as Sky-mode "longitude,latitude"
+// KML coordinates: longitude is RA - 180° and latitude is Dec.
+func skyCoordinates(ra, dec float64) string {
+	lon := math.Mod(ra, 360) - 180
+	if lon < -180 {
+		lon += 360
+	}
+	return strconv.FormatFloat(lon, 'f', 6, 64) + "," + strconv.FormatFloat(dec, 'f', 6, 64) + ",0"
+}
+
+func describe(o model.CelestialObject) string {
+	var parts []string
+	if o.Name != o.GetDisplayName() {
+		parts = append(parts, o.Name)
+	}
+	if o.Type != "" {
+		parts = append(parts, o.Type)
+	}
+	if o.Constellation != "" {
+		parts = append(parts, "in "+o.Constellation)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"server/internal/model"
+	"server/internal/wcs"
+)
+
+func orion() *wcs.WCS {
+	return &wcs.WCS{
+		CRVAL:       [2]float64{83.8221, -5.3911},
+		CRPIX:       [2]float64{600.5, 400.5},
+		CD:          [2][2]float64{{-2.7e-4, 0}, {0, -2.7e-4}},
+		ImageWidth:  1200,
+		ImageHeight: 800,
+	}
+}
+
+var objects = []model.CelestialObject{
+	{Name: "M42", DisplayName: "Orion Nebula", Type: "nebula", Constellation: "Orion", Coordinates: &model.Coordinates{RA: 83.8221, Dec: -5.3911}},
+	{Name: "M43", Type: "nebula", Coordinates: &model.Coordinates{RA: 83.8792, Dec: -5.2700}},
+	{Name: "Uncatalogued"},
+}
+
+type parsedKML struct {
+	Hint     string `xml:"hint,attr"`
+	Document struct {
+		Overlay struct {
+			Href        string `xml:"Icon>href"`
+			Coordinates string `xml:"LatLonQuad>coordinates"`
+		} `xml:"GroundOverlay"`
+		Placemarks []struct {
+			Name        string `xml:"name"`
+			Description string `xml:"description"`
+			Coordinates string `xml:"Point>coordinates"`
+		} `xml:"Folder>Placemark"`
+	} `xml:"Document"`
+}
+
+func TestKML(t *testing.T) {
+	data, err := KML("Orion", orion(), objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc parsedKML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid KML: %v", err)
+	}
+	if doc.Hint != "target=sky" {
+		t.Errorf("hint = %q, want Sky mode", doc.Hint)
+	}
+	if doc.Document.Overlay.Href != KMLImageHref {
+		t.Errorf("href = %q", doc.Document.Overlay.Href)
+	}
+
+	corners := strings.Fields(doc.Document.Overlay.Coordinates)
+	if len(corners) != 4 {
+		t.Fatalf("LatLonQuad has %d corners, want 4", len(corners))
+	}
+	// North up and east left: the bottom-left corner is south and east of
+	// the centre, so it has a lower latitude and a higher longitude.
+	lon, lat := lonLat(t, corners[0])
+	if lat > -5.3911 || lon < 83.8221-180 {
+		t.Errorf("bottom-left corner at %v,%v is not south-east of the centre", lon, lat)
+	}
+	lon, lat = lonLat(t, corners[2])
+	if lat < -5.3911 || lon > 83.8221-180 {
+		t.Errorf("top-right corner at %v,%v is not north-west of the centre", lon, lat)
+	}
+
+	placemarks := doc.Document.Placemarks
+	if len(placemarks) != 2 {
+		t.Fatalf("%d placemarks, want the 2 objects with coordinates", len(placemarks))
+	}
+	if p := placemarks[0]; p.Name != "Orion Nebula" || p.Description != "M42, nebula, in Orion" || p.Coordinates != "-96.177900,-5.391100,0" {
+		t.Errorf("placemark = %+v", p)
+	}
+}
+
+func TestSkyCoordinates(t *testing.T) {
+	for ra, want := range map[float64]string{
+		0:     "-180.000000,10.000000,0",
+		180:   "0.000000,10.000000,0",
+		359.5: "179.500000,10.000000,0",
+		360:   "-180.000000,10.000000,0",
+	} {
+		if got := skyCoordinates(ra, 10); got != want {
+			t.Errorf("skyCoordinates(%v) = %q, want %q", ra, got, want)
+		}
+	}
+}
+
+func TestKMZ(t *testing.T) {
+	image := []byte("\xff\xd8 not really a jpeg")
+	data, err := KMZ("Orion", orion(), objects, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != "doc.kml" || zr.File[1].Name != KMLImageHref {
+		t.Fatalf("archive holds %v", zr.File)
+	}
+	f, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got, _ := io.ReadAll(f); !bytes.Equal(got, image) {
+		t.Error("image changed in the archive")
+	}
+}
+
+func lonLat(t *testing.T, s string) (float64, float64) {
+	t.Helper()
+	parts := strings.Split(s, ",")
+	lon, err1 := strconv.ParseFloat(parts[0], 64)
+	lat, err2 := strconv.ParseFloat(parts[1], 64)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("bad coordinates %q", s)
+	}
+	return lon, lat
+}
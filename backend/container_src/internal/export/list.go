@@ -0,0 +1,406 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"server/internal/model/data"
+)
+
+// ListItem is one object on an observing list, with the catalog metadata
+// the formats draw their columns from.
+type ListItem struct {
+	Name          string
+	DisplayName   string
+	Type          string
+	Constellation string
+	RA            float64 // J2000, degrees
+	Dec           float64
+	Magnitude     *float64
+	Size          float64 // major axis in arcminutes; 0 when unknown
+}
+
+// List is a named observing list.
+type List struct {
+	Name    string
+	Created time.Time
+	Items   []ListItem
+}
+
+// ListFormat is a file format for observing lists.
+type ListFormat string
+
+const (
+	ListSkySafari    ListFormat = "skylist"
+	ListStellarium   ListFormat = "stellarium"
+	ListAstroPlanner ListFormat = "astroplanner"
+	ListCSV          ListFormat = "csv"
+	ListJSONLines    ListFormat = "jsonl"
+)
+
+var listFormats = map[ListFormat]struct {
+	contentType string
+	extension   string
+}{
+	ListSkySafari:    {"text/plain; charset=utf-8", "skylist"},
+	ListStellarium:   {"application/json", "json"},
+	ListAstroPlanner: {"text/csv; charset=utf-8", "csv"},
+	ListCSV:          {"text/csv; charset=utf-8", "csv"},
+	ListJSONLines:    {"application/jsonl", "jsonl"},
+}
+
+// ParseListFormat reads a format name; empty means ListCSV.
+func ParseListFormat(s string) (ListFormat, bool) {
+	if s == "" {
+		return ListCSV, true
+	}
+	_, ok := listFormats[ListFormat(s)]
+	return ListFormat(s), ok
+}
+
+func (f ListFormat) ContentType() string { return listFormats[f].contentType }
+
+// Extension is the file extension the importing software expects.
+func (f ListFormat) Extension() string { return listFormats[f].extension }
+
+// Column is a catalog metadata field that plain CSV and JSON Lines exports
+// can include.
+type Column string
+
+const (
+	ColumnName          Column = "name"
+	ColumnDisplayName   Column = "displayName"
+	ColumnType          Column = "type"
+	ColumnConstellation Column = "constellation"
+	ColumnRA            Column = "ra"
+	ColumnDec           Column = "dec"
+	ColumnMagnitude     Column = "magnitude"
+	ColumnSize          Column = "size"
+)
+
+// Columns lists every column in its default order.
+var Columns = []Column{
+	ColumnName, ColumnDisplayName, ColumnType, ColumnConstellation,
+	ColumnRA, ColumnDec, ColumnMagnitude, ColumnSize,
+}
+
+// ParseColumns reads a comma-separated column list, reporting the first
+// unknown name. Empty means every column.
+func ParseColumns(s string) ([]Column, string, bool) {
+	if s == "" {
+		return Columns, "", true
+	}
+	var columns []Column
+	for _, name := range strings.Split(s, ",") {
+		c := Column(strings.TrimSpace(name))
+		if !slices.Contains(Columns, c) {
+			return nil, string(c), false
+		}
+		if !slices.Contains(columns, c) {
+			columns = append(columns, c)
+		}
+	}
+	return columns, "", true
+}
+
+// value returns a column's value, or nil when the catalog does not know it.
+func (it ListItem) value(c Column) any {
+	switch c {
+	case ColumnName:
+		return it.Name
+	case ColumnDisplayName:
+		if it.DisplayName != "" {
+			return it.DisplayName
+		}
+		return it.Name
+	case ColumnType:
+		return it.Type
+	case ColumnConstellation:
+		return it.Constellation
+	case ColumnRA:
+		return roundDegrees(it.RA)
+	case ColumnDec:
+		return roundDegrees(it.Dec)
+	case ColumnMagnitude:
+		if it.Magnitude != nil {
+			return *it.Magnitude
+		}
+	case ColumnSize:
+		if it.Size > 0 {
+			return it.Size
+		}
+	}
+	return nil
+}
+
+// roundDegrees drops digits below a microdegree, which the catalog
+// positions do not carry.
+func roundDegrees(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// EncodeList writes a list in the given format. Columns only apply to
+// ListCSV and ListJSONLines; the other formats have fixed layouts.
+func EncodeList(list List, format ListFormat, columns []Column) ([]byte, error) {
+	switch format {
+	case ListSkySafari:
+		return skySafari(list), nil
+	case ListStellarium:
+		return stellarium(list)
+	case ListAstroPlanner:
+		return astroPlanner(list)
+	case ListJSONLines:
+		return jsonLines(list, columns)
+	default:
+		return plainCSV(list, columns)
+	}
+}
+
+// skySafari writes a SkySafari observing list. SkySafari matches entries
+// by catalog number and common name; solar-system bodies move, so it is
+// left to find those itself and they are not listed.
+func skySafari(list List) []byte {
+	var b bytes.Buffer
+	b.WriteString("SkySafariObservingListVersion=3.0\r\n")
+	b.WriteString("SortedBy=Default Order\r\n")
+	for _, it := range list.Items {
+		objectType, ok := skySafariTypes[it.Type]
+		if !ok {
+			continue
+		}
+		b.WriteString("SkyObject=BeginObject\r\n")
+		fmt.Fprintf(&b, "\tObjectID=%d,-1,-1\r\n", objectType)
+		if data.IsDesignation(it.Name) {
+			fmt.Fprintf(&b, "\tCatalogNumber=%s\r\n", spacedDesignation(it.Name))
+			if it.DisplayName != "" {
+				fmt.Fprintf(&b, "\tCommonName=%s\r\n", it.DisplayName)
+			}
+		} else {
+			fmt.Fprintf(&b, "\tCommonName=%s\r\n", it.Name)
+		}
+		b.WriteString("EndObject=SkyObject\r\n")
+	}
+	return b.Bytes()
+}
+
+// skySafariTypes are SkySafari's object classes for catalog types.
+var skySafariTypes = map[string]int{
+	"star":    2,
+	"nebula":  4,
+	"galaxy":  4,
+	"cluster": 4,
+}
+
+type stellariumFile struct {
+	DefaultListOLUD string                    `json:"defaultListOlud"`
+	ObservingLists  map[string]stellariumList `json:"observingLists"`
+	ShortName       string                    `json:"shortName"`
+	Version         string                    `json:"version"`
+}
+
+type stellariumList struct {
+	CreationDate string             `json:"creation date"`
+	Description  string             `json:"description"`
+	Name         string             `json:"name"`
+	Objects      []stellariumObject `json:"objects"`
+	Sorting      string             `json:"sorting"`
+}
+
+type stellariumObject struct {
+	Constellation   string  `json:"constellation"`
+	Dec             string  `json:"dec"`
+	Designation     string  `json:"designation"`
+	FOV             float64 `json:"fov"`
+	IsVisibleMarker bool    `json:"isVisibleMarker"`
+	JD              float64 `json:"jd"`
+	LandscapeID     string  `json:"landscapeID"`
+	Location        string  `json:"location"`
+	Magnitude       string  `json:"magnitude"`
+	Name            string  `json:"name"`
+	NameI18n        string  `json:"nameI18n"`
+	ObjType         string  `json:"objtype"`
+	RA              string  `json:"ra"`
+	Type            string  `json:"type"`
+}
+
+// stellariumClasses are the Stellarium object classes for catalog types.
+var stellariumClasses = map[string]string{
+	"star":     "Star",
+	"nebula":   "Nebula",
+	"galaxy":   "Nebula",
+	"cluster":  "Nebula",
+	"sun":      "Planet",
+	"planet":   "Planet",
+	"moon":     "Planet",
+	"comet":    "Comet",
+	"asteroid": "MinorPlanet",
+}
+
+// stellarium writes a Stellarium observing list file, as its Observing
+// Lists dialog imports.
+func stellarium(list List) ([]byte, error) {
+	objects := make([]stellariumObject, 0, len(list.Items))
+	for _, it := range list.Items {
+		class, ok := stellariumClasses[it.Type]
+		if !ok {
+			continue
+		}
+		name := it.DisplayName
+		if name == "" {
+			name = it.Name
+		}
+		o := stellariumObject{
+			Constellation: it.Constellation,
+			RA:            formatRA(it.RA, "%02dh%02dm%04.1fs"),
+			Dec:           formatDec(it.Dec, "%s%d°%02d'%02d\""),
+			Designation:   it.Name,
+			Name:          name,
+			NameI18n:      name,
+			ObjType:       it.Type,
+			Type:          class,
+		}
+		if it.Magnitude != nil {
+			o.Magnitude = strconv.FormatFloat(*it.Magnitude, 'f', 2, 64)
+		}
+		objects = append(objects, o)
+	}
+
+	id := listUUID(list.Name)
+	file := stellariumFile{
+		DefaultListOLUD: id,
+		ObservingLists: map[string]stellariumList{id: {
+			CreationDate: list.Created.UTC().Format("2006-01-02 15:04:05"),
+			Name:         list.Name,
+			Objects:      objects,
+		}},
+		ShortName: "Observing list for Stellarium",
+		Version:   "2.0",
+	}
+	data, err := json.MarshalIndent(file, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode stellarium list: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// listUUID derives a stable, UUID-shaped list identifier from the name.
+func listUUID(name string) string {
+	h := sha1.Sum([]byte(name))
+	return fmt.Sprintf("{%x-%x-%x-%x-%x}", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// astroPlanner writes the CSV layout AstroPlanner's text import reads,
+// with sexagesimal coordinates.
+func astroPlanner(list List) ([]byte, error) {
+	rows := [][]string{{"ID", "Name", "Type", "RA", "Dec", "Magnitude", "Size", "Constellation"}}
+	for _, it := range list.Items {
+		var mag, size string
+		if it.Magnitude != nil {
+			mag = strconv.FormatFloat(*it.Magnitude, 'f', 1, 64)
+		}
+		if it.Size > 0 {
+			size = strconv.FormatFloat(it.Size, 'f', -1, 64) + "'"
+		}
+		rows = append(rows, []string{
+			it.Name, it.DisplayName, it.Type,
+			formatRA(it.RA, "%02d %02d %04.1f"), formatDec(it.Dec, "%s%02d %02d %02d"),
+			mag, size, it.Constellation,
+		})
+	}
+	return writeCSV(rows)
+}
+
+func plainCSV(list List, columns []Column) ([]byte, error) {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = string(c)
+	}
+	rows := [][]string{header}
+	for _, it := range list.Items {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			switch v := it.value(c).(type) {
+			case string:
+				row[i] = v
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return writeCSV(rows)
+}
+
+func writeCSV(rows [][]string) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to encode csv: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// jsonLines writes one JSON object per item. Unknown values are left out
+// rather than written as null.
+func jsonLines(list List, columns []Column) ([]byte, error) {
+	var b bytes.Buffer
+	for _, it := range list.Items {
+		// Build the object by hand so keys keep the column order.
+		b.WriteByte('{')
+		first := true
+		for _, c := range columns {
+			v := it.value(c)
+			if v == nil {
+				continue
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode json lines: %w", err)
+			}
+			if !first {
+				b.WriteByte(',')
+			}
+			first = false
+			fmt.Fprintf(&b, "%q:%s", c, value)
+		}
+		b.WriteString("}\n")
+	}
+	return b.Bytes(), nil
+}
+
+// spacedDesignation writes Messier numbers as "M 42", the form SkySafari
+// looks up.
+func spacedDesignation(name string) string {
+	if rest, ok := strings.CutPrefix(name, "M"); ok && rest != "" && unicode.IsDigit(rune(rest[0])) {
+		return "M " + rest
+	}
+	return name
+}
+
+// formatRA formats degrees of right ascension as hours, minutes and
+// tenths of seconds using layout.
+func formatRA(ra float64, layout string) string {
+	tenths := int64(math.Round(math.Mod(ra+360, 360) / 15 * 36000))
+	tenths %= 24 * 36000
+	return fmt.Sprintf(layout, tenths/36000, tenths/600%60, float64(tenths%600)/10)
+}
+
+// formatDec formats a declination as sign, degrees, minutes and whole
+// seconds using layout.
+func formatDec(dec float64, layout string) string {
+	sign := "+"
+	if dec < 0 {
+		sign = "-"
+	}
+	seconds := int64(math.Round(math.Abs(dec) * 3600))
+	return fmt.Sprintf(layout, sign, seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mag(v float64) *float64 { return &v }
+
+var list = List{
+	Name:    "Orion tonight",
+	Created: time.Date(2026, 1, 15, 20, 0, 0, 0, time.UTC),
+	Items: []ListItem{
+		{Name: "M42", DisplayName: "Orion Nebula", Type: "nebula", Constellation: "Orion", RA: 83.8221, Dec: -5.3911, Magnitude: mag(4), Size: 85},
+		{Name: "Betelgeuse", Type: "star", Constellation: "Orion", RA: 88.7929, Dec: 7.4071, Magnitude: mag(0.42)},
+		{Name: "Mars", Type: "planet", RA: 100, Dec: 25},
+	},
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, _, ok := ParseColumns("name, ra,dec,ra")
+	if !ok || len(columns) != 3 || columns[1] != ColumnRA {
+		t.Errorf("ParseColumns = %v %v", columns, ok)
+	}
+	if _, bad, ok := ParseColumns("name,surfaceBrightness"); ok || bad != "surfaceBrightness" {
+		t.Errorf("unknown column accepted: %q %v", bad, ok)
+	}
+	if columns, _, _ := ParseColumns(""); len(columns) != len(Columns) {
+		t.Errorf("empty list gave %v, want every column", columns)
+	}
+}
+
+func TestSkySafari(t *testing.T) {
+	data, err := EncodeList(list, ListSkySafari, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	for _, want := range []string{
+		"SkySafariObservingListVersion=3.0\r\n",
+		"\tObjectID=4,-1,-1\r\n\tCatalogNumber=M 42\r\n\tCommonName=Orion Nebula\r\n",
+		"\tObjectID=2,-1,-1\r\n\tCommonName=Betelgeuse\r\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("skylist is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Count(text, "BeginObject") != 2 || strings.Contains(text, "Mars") {
+		t.Errorf("skylist should hold the two catalog objects only:\n%s", text)
+	}
+}
+
+func TestStellarium(t *testing.T) {
+	data, err := EncodeList(list, ListStellarium, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file stellariumFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	l, ok := file.ObservingLists[file.DefaultListOLUD]
+	if !ok || l.Name != "Orion tonight" || len(l.Objects) != 3 {
+		t.Fatalf("lists = %+v", file.ObservingLists)
+	}
+	m42 := l.Objects[0]
+	if m42.RA != "05h35m17.3s" || m42.Dec != "-5°23'28\"" || m42.Type != "Nebula" || m42.Magnitude != "4.00" || m42.Name != "Orion Nebula" {
+		t.Errorf("M42 = %+v", m42)
+	}
+	if l.Objects[2].Type != "Planet" {
+		t.Errorf("Mars type = %q", l.Objects[2].Type)
+	}
+}
+
+func TestAstroPlanner(t *testing.T) {
+	data, err := EncodeList(list, ListAstroPlanner, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"M42", "Orion Nebula", "nebula", "05 35 17.3", "-05 23 28", "4.0", "85'", "Orion"}
+	if len(rows) != 4 || strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Errorf("rows = %q", rows)
+	}
+}
+
+func TestPlainCSV(t *testing.T) {
+	data, err := EncodeList(list, ListCSV, []Column{ColumnName, ColumnDec, ColumnMagnitude, ColumnSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name,dec,magnitude,size\nM42,-5.3911,4,85\nBetelgeuse,7.4071,0.42,\nMars,25,,\n"
+	if string(data) != want {
+		t.Errorf("csv = %q, want %q", data, want)
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	data, err := EncodeList(list, ListJSONLines, []Column{ColumnDisplayName, ColumnRA, ColumnMagnitude})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{
+		`{"displayName":"Orion Nebula","ra":83.8221,"magnitude":4}`,
+		`{"displayName":"Betelgeuse","ra":88.7929,"magnitude":0.42}`,
+		`{"displayName":"Mars","ra":100}`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("jsonl =\n%s\nwant\n%s", data, strings.Join(want, "\n"))
+	}
+}
+
+func TestSexagesimal(t *testing.T) {
+	cases := []struct {
+		ra, dec       float64
+		wantRA, wantD string
+	}{
+		{359.99999, -0.0001, "00 00 00.0", "-00 00 00"},
+		{15, 89.99999, "01 00 00.0", "+90 00 00"},
+		{-15, 10.5, "23 00 00.0", "+10 30 00"},
+	}
+	for _, c := range cases {
+		if got := formatRA(c.ra, "%02d %02d %04.1f"); got != c.wantRA {
+			t.Errorf("formatRA(%v) = %q, want %q", c.ra, got, c.wantRA)
+		}
+		if got := formatDec(c.dec, "%s%02d %02d %02d"); got != c.wantD {
+			t.Errorf("formatDec(%v) = %q, want %q", c.dec, got, c.wantD)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package fits writes FITS headers: 80-character keyword cards padded to
+// 2880-byte blocks.
+package fits
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	cardSize  = 80
+	blockSize = 2880
+)
+
+// Header is a list of FITS cards, without the END card.
+type Header struct {
+	cards []string
+}
+
+// Bool adds a logical keyword.
+func (h *Header) Bool(key string, value bool, comment string) {
+	v := "F"
+	if value {
+		v = "T"
+	}
+	h.add(key, fmt.Sprintf("%20s", v), comment)
+}
+
+// Int adds an integer keyword.
+func (h *Header) Int(key string, value int, comment string) {
+	h.add(key, fmt.Sprintf("%20d", value), comment)
+}
+
+// Float adds a real keyword in the shortest form that reads back exactly.
+func (h *Header) Float(key string, value float64, comment string) {
+	h.add(key, fmt.Sprintf("%20s", strconv.FormatFloat(value, 'E', -1, 64)), comment)
+}
+
+// String adds a character-string keyword. Quotes are doubled and the
+// value is padded to the eight characters the standard asks for.
+func (h *Header) String(key, value, comment string) {
+	h.add(key, fmt.Sprintf("'%-8s'", strings.ReplaceAll(value, "'", "''")), comment)
+}
+
+// Comment adds a COMMENT card.
+func (h *Header) Comment(text string) {
+	h.cards = append(h.cards, card(fmt.Sprintf("%-8s%s", "COMMENT", text)))
+}
+
+func (h *Header) add(key, value, comment string) {
+	c := fmt.Sprintf("%-8s= %s", key, value)
+	if comment != "" {
+		c += " / " + comment
+	}
+	h.cards = append(h.cards, card(c))
+}
+
+// card pads or cuts a card to exactly 80 characters. Only comments are
+// long enough to be cut.
+func card(s string) string {
+	if len(s) > cardSize {
+		return s[:cardSize]
+	}
+	return fmt.Sprintf("%-80s", s)
+}
+
+// Bytes returns the header as it appears in a FITS file: the cards and END
+// padded with spaces to a whole number of blocks.
+func (h *Header) Bytes() []byte {
+	var b bytes.Buffer
+	for _, c := range h.cards {
+		b.WriteString(c)
+	}
+	b.WriteString(card("END"))
+	if rem := b.Len() % blockSize; rem != 0 {
+		b.Write(bytes.Repeat([]byte{' '}, blockSize-rem))
+	}
+	return b.Bytes()
+}
+
+// Text returns the header as plain text, one card per line, as DS9 and
+// SWarp read it from .head files.
+func (h *Header) Text() string {
+	var b strings.Builder
+	for _, c := range h.cards {
+		b.WriteString(strings.TrimRight(c, " "))
+		b.WriteByte('\n')
+	}
+	b.WriteString("END\n")
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package fits
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	var h Header
+	h.Bool("SIMPLE", true, "conforms to FITS")
+	h.Int("NAXIS", 0, "")
+	h.Float("CRVAL1", 83.8221, "RA of reference point")
+	h.String("OBJECT", "Barnard's Loop", "")
+	h.Comment("made by a test")
+
+	data := h.Bytes()
+	if len(data) != blockSize {
+		t.Fatalf("len = %d, want one block", len(data))
+	}
+
+	want := []string{
+		"SIMPLE  =                    T / conforms to FITS",
+		"NAXIS   =                    0",
+		"CRVAL1  =          8.38221E+01 / RA of reference point",
+		"OBJECT  = 'Barnard''s Loop'",
+		"COMMENT made by a test",
+		"END",
+	}
+	for i, w := range want {
+		got := string(data[i*cardSize : (i+1)*cardSize])
+		if got != card(w) {
+			t.Errorf("card %d = %q, want %q", i, got, w)
+		}
+	}
+	if rest := data[len(want)*cardSize:]; strings.TrimLeft(string(rest), " ") != "" {
+		t.Error("header is not padded with spaces")
+	}
+
+	if text := h.Text(); text != strings.Join(want, "\n")+"\n" {
+		t.Errorf("Text() = %q", text)
+	}
+}
+
+func TestShortStringPadding(t *testing.T) {
+	var h Header
+	h.String("CTYPE1", "RA", "")
+	if got := strings.TrimRight(h.cards[0], " "); got != "CTYPE1  = 'RA      '" {
+		t.Errorf("card = %q", got)
+	}
+}
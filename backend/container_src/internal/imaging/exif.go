@@ -0,0 +1,294 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// EXIF holds the capture metadata StarSeek cares about. Fields that are not
+// present in the image are left at their zero value.
+type EXIF struct {
+	DateTimeOriginal *time.Time
+	Latitude         *float64
+	Longitude        *float64
+	Altitude         *float64
+	Make             string
+	Model            string
+	LensModel        string
+	FocalLength      float64
+}
+
+var ErrNoEXIF = errors.New("no EXIF data")
+
+const (
+	tagMake               = 0x010f
+	tagModel              = 0x0110
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagFocalLength        = 0x920a
+	tagLensModel          = 0xa434
+
+	tagGPSLatitudeRef  = 0x01
+	tagGPSLatitude     = 0x02
+	tagGPSLongitudeRef = 0x03
+	tagGPSLongitude    = 0x04
+	tagGPSAltitudeRef  = 0x05
+	tagGPSAltitude     = 0x06
+	tagGPSTimeStamp    = 0x07
+	tagGPSDateStamp    = 0x1d
+)
+
+const (
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeSRational = 10
+)
+
+const exifDateLayout = "2006:01:02 15:04:05"
+
+// ReadEXIF extracts capture metadata from a JPEG's APP1 segment.
+func ReadEXIF(data []byte) (*EXIF, error) {
+	tiff, err := FindEXIF(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseTIFF(tiff)
+}
+
+// FindEXIF returns the TIFF payload of a JPEG's EXIF APP1 segment.
+func FindEXIF(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, ErrNoEXIF
+	}
+
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xff {
+			return nil, ErrNoEXIF
+		}
+		marker := data[pos+1]
+		if marker == 0xda || marker == 0xd9 {
+			return nil, ErrNoEXIF
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment at offset %d", pos)
+		}
+
+		payload := data[pos+4 : end]
+		if marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return payload[6:], nil
+		}
+		pos = end
+	}
+	return nil, ErrNoEXIF
+}
+
+type ifdEntry struct {
+	typ    uint16
+	count  uint32
+	offset uint32
+	value  []byte
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+	seen  map[uint32]bool
+}
+
+func parseTIFF(data []byte) (*EXIF, error) {
+	if len(data) < 8 {
+		return nil, ErrNoEXIF
+	}
+
+	r := &tiffReader{data: data, seen: make(map[uint32]bool)}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid TIFF byte order %q", data[:2])
+	}
+
+	ifd0, err := r.readIFD(r.order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	exif := &EXIF{
+		Make:  r.ascii(ifd0[tagMake]),
+		Model: r.ascii(ifd0[tagModel]),
+	}
+
+	// DateTimeOriginal has no zone unless OffsetTimeOriginal is set; it is
+	// read as UTC and replaced by the GPS timestamp, which is always UTC.
+	zoned := false
+	if entry, ok := ifd0[tagExifIFD]; ok {
+		sub, err := r.readIFD(r.uint32(entry))
+		if err != nil {
+			return nil, err
+		}
+		exif.LensModel = r.ascii(sub[tagLensModel])
+		if v := r.rationals(sub[tagFocalLength]); len(v) > 0 {
+			exif.FocalLength = v[0]
+		}
+
+		original := r.ascii(sub[tagDateTimeOriginal])
+		if t, err := time.Parse(exifDateLayout+"-07:00", original+r.ascii(sub[tagOffsetTimeOriginal])); err == nil {
+			t = t.UTC()
+			exif.DateTimeOriginal, zoned = &t, true
+		} else if t, err := time.Parse(exifDateLayout, original); err == nil {
+			exif.DateTimeOriginal = &t
+		}
+	}
+
+	if entry, ok := ifd0[tagGPSIFD]; ok {
+		gps, err := r.readIFD(r.uint32(entry))
+		if err != nil {
+			return nil, err
+		}
+		r.applyGPS(exif, gps)
+		if t := r.gpsTime(gps); t != nil && !zoned {
+			exif.DateTimeOriginal = t
+		}
+	}
+	return exif, nil
+}
+
+// applyGPS sets the position only when both coordinates are present and in
+// range. Many cameras write 0,0 when they have no fix, so that is dropped too.
+func (r *tiffReader) applyGPS(exif *EXIF, gps map[uint16]ifdEntry) {
+	lat, hasLat := dms(r.rationals(gps[tagGPSLatitude]))
+	lon, hasLon := dms(r.rationals(gps[tagGPSLongitude]))
+	if strings.EqualFold(r.ascii(gps[tagGPSLatitudeRef]), "S") {
+		lat = -lat
+	}
+	if strings.EqualFold(r.ascii(gps[tagGPSLongitudeRef]), "W") {
+		lon = -lon
+	}
+	if hasLat && hasLon && math.Abs(lat) <= 90 && math.Abs(lon) <= 180 && (lat != 0 || lon != 0) {
+		exif.Latitude, exif.Longitude = &lat, &lon
+	}
+	if alt := r.rationals(gps[tagGPSAltitude]); len(alt) > 0 {
+		v := alt[0]
+		if ref := gps[tagGPSAltitudeRef].value; len(ref) > 0 && ref[0] == 1 {
+			v = -v
+		}
+		exif.Altitude = &v
+	}
+}
+
+func (r *tiffReader) gpsTime(gps map[uint16]ifdEntry) *time.Time {
+	stamp := r.rationals(gps[tagGPSTimeStamp])
+	date, err := time.Parse("2006:01:02", r.ascii(gps[tagGPSDateStamp]))
+	if len(stamp) != 3 || err != nil {
+		return nil
+	}
+
+	t := date.Add(time.Duration((stamp[0]*3600 + stamp[1]*60 + stamp[2]) * float64(time.Second)))
+	return &t
+}
+
+func dms(v []float64) (float64, bool) {
+	if len(v) != 3 {
+		return 0, false
+	}
+	return v[0] + v[1]/60 + v[2]/3600, true
+}
+
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	if r.seen[offset] {
+		return nil, fmt.Errorf("IFD at %d is referenced twice", offset)
+	}
+	r.seen[offset] = true
+	if int(offset)+2 > len(r.data) {
+		return nil, fmt.Errorf("IFD offset %d out of range", offset)
+	}
+
+	count := int(r.order.Uint16(r.data[offset:]))
+	start := int(offset) + 2
+	if start+count*12 > len(r.data) {
+		return nil, fmt.Errorf("IFD at %d is truncated", offset)
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		raw := r.data[start+i*12 : start+(i+1)*12]
+		entry := ifdEntry{
+			typ:    r.order.Uint16(raw[2:]),
+			count:  r.order.Uint32(raw[4:]),
+			offset: r.order.Uint32(raw[8:]),
+		}
+
+		size := typeSize(entry.typ) * int(entry.count)
+		if size <= 4 {
+			entry.value = raw[8 : 8+size]
+		} else if int(entry.offset)+size <= len(r.data) {
+			entry.value = r.data[entry.offset : int(entry.offset)+size]
+		}
+		entries[r.order.Uint16(raw)] = entry
+	}
+	return entries, nil
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case typeShort:
+		return 2
+	case typeLong:
+		return 4
+	case typeRational, typeSRational:
+		return 8
+	default:
+		return 1
+	}
+}
+
+func (r *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+func (r *tiffReader) uint32(e ifdEntry) uint32 {
+	if e.typ == typeShort && len(e.value) >= 2 {
+		return uint32(r.order.Uint16(e.value))
+	}
+	if len(e.value) >= 4 {
+		return r.order.Uint32(e.value)
+	}
+	return e.offset
+}
+
+func (r *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != typeRational && e.typ != typeSRational {
+		return nil
+	}
+
+	values := make([]float64, 0, len(e.value)/8)
+	for i := 0; i+8 <= len(e.value); i += 8 {
+		num, den := r.order.Uint32(e.value[i:]), r.order.Uint32(e.value[i+4:])
+		if den == 0 {
+			return nil
+		}
+		if e.typ == typeSRational {
+			values = append(values, float64(int32(num))/float64(int32(den)))
+		} else {
+			values = append(values, float64(num)/float64(den))
+		}
+	}
+	return values
+}
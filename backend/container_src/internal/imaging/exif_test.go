@@ -0,0 +1,234 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+// tiffWriter lays out IFDs one after another from offset 8. Sub-IFDs are
+// written before the IFDs that point at them, and root sets IFD0.
+type tiffWriter struct {
+	order binary.ByteOrder
+	data  []byte
+}
+
+func newTIFF(order binary.ByteOrder) *tiffWriter {
+	w := &tiffWriter{order: order, data: make([]byte, 8)}
+	if order == binary.LittleEndian {
+		copy(w.data, "II")
+	} else {
+		copy(w.data, "MM")
+	}
+	order.PutUint16(w.data[2:], 42)
+	return w
+}
+
+func (w *tiffWriter) root(offset uint32) []byte {
+	w.order.PutUint32(w.data[4:], offset)
+	return w.data
+}
+
+func (w *tiffWriter) ifd(entries ...tiffEntry) uint32 {
+	offset := uint32(len(w.data))
+	extra := offset + uint32(2+12*len(entries)+4)
+
+	ifd := make([]byte, 2+12*len(entries)+4)
+	w.order.PutUint16(ifd, uint16(len(entries)))
+	var out []byte
+	for i, e := range entries {
+		raw := ifd[2+12*i:]
+		w.order.PutUint16(raw, e.tag)
+		w.order.PutUint16(raw[2:], e.typ)
+		w.order.PutUint32(raw[4:], e.count)
+		if len(e.value) <= 4 {
+			copy(raw[8:12], e.value)
+		} else {
+			w.order.PutUint32(raw[8:], extra+uint32(len(out)))
+			out = append(out, e.value...)
+		}
+	}
+	w.data = append(append(w.data, ifd...), out...)
+	return offset
+}
+
+func (w *tiffWriter) ascii(tag uint16, s string) tiffEntry {
+	return tiffEntry{tag: tag, typ: typeASCII, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func (w *tiffWriter) long(tag uint16, v uint32) tiffEntry {
+	value := make([]byte, 4)
+	w.order.PutUint32(value, v)
+	return tiffEntry{tag: tag, typ: typeLong, count: 1, value: value}
+}
+
+func (w *tiffWriter) rationals(tag uint16, pairs ...[2]uint32) tiffEntry {
+	value := make([]byte, 8*len(pairs))
+	for i, p := range pairs {
+		w.order.PutUint32(value[8*i:], p[0])
+		w.order.PutUint32(value[8*i+4:], p[1])
+	}
+	return tiffEntry{tag: tag, typ: typeRational, count: uint32(len(pairs)), value: value}
+}
+
+// gpsTIFF builds IFD0 with a GPS IFD at the given degrees, minutes and
+// seconds.
+func gpsTIFF(order binary.ByteOrder, latRef string, lat [3]uint32, lonRef string, lon [3]uint32) []byte {
+	w := newTIFF(order)
+	gps := w.ifd(
+		w.ascii(tagGPSLatitudeRef, latRef),
+		w.rationals(tagGPSLatitude, [2]uint32{lat[0], 1}, [2]uint32{lat[1], 1}, [2]uint32{lat[2], 1}),
+		w.ascii(tagGPSLongitudeRef, lonRef),
+		w.rationals(tagGPSLongitude, [2]uint32{lon[0], 1}, [2]uint32{lon[1], 1}, [2]uint32{lon[2], 1}),
+	)
+	return w.root(w.ifd(w.long(tagGPSIFD, gps)))
+}
+
+func assertNear(t *testing.T, name string, got *float64, want float64) {
+	t.Helper()
+	if got == nil {
+		t.Errorf("%s = nil, want %g", name, want)
+	} else if math.Abs(*got-want) > 1e-9 {
+		t.Errorf("%s = %g, want %g", name, *got, want)
+	}
+}
+
+func TestParseTIFFByteOrders(t *testing.T) {
+	for name, order := range map[string]binary.ByteOrder{"II": binary.LittleEndian, "MM": binary.BigEndian} {
+		t.Run(name, func(t *testing.T) {
+			w := newTIFF(order)
+			sub := w.ifd(
+				w.ascii(tagDateTimeOriginal, "2026:10:19 21:30:00"),
+				w.ascii(tagOffsetTimeOriginal, "+02:00"),
+				w.rationals(tagFocalLength, [2]uint32{50, 1}),
+				w.ascii(tagLensModel, "EF50mm f/1.8"),
+			)
+			gps := w.ifd(
+				w.ascii(tagGPSLatitudeRef, "N"),
+				w.rationals(tagGPSLatitude, [2]uint32{51, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+				w.ascii(tagGPSLongitudeRef, "W"),
+				w.rationals(tagGPSLongitude, [2]uint32{0, 1}, [2]uint32{7, 1}, [2]uint32{30, 1}),
+				w.rationals(tagGPSAltitude, [2]uint32{355, 10}),
+			)
+			tiff := w.root(w.ifd(
+				w.ascii(tagMake, "Canon"),
+				w.ascii(tagModel, "EOS 6D"),
+				w.long(tagExifIFD, sub),
+				w.long(tagGPSIFD, gps),
+			))
+
+			exif, err := parseTIFF(tiff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exif.Make != "Canon" || exif.Model != "EOS 6D" || exif.LensModel != "EF50mm f/1.8" || exif.FocalLength != 50 {
+				t.Errorf("camera = %q %q %q %g", exif.Make, exif.Model, exif.LensModel, exif.FocalLength)
+			}
+			if want := time.Date(2026, 10, 19, 19, 30, 0, 0, time.UTC); exif.DateTimeOriginal == nil || !exif.DateTimeOriginal.Equal(want) {
+				t.Errorf("DateTimeOriginal = %v, want %v", exif.DateTimeOriginal, want)
+			}
+			assertNear(t, "Latitude", exif.Latitude, 51.5)
+			assertNear(t, "Longitude", exif.Longitude, -0.125)
+			assertNear(t, "Altitude", exif.Altitude, 35.5)
+		})
+	}
+}
+
+func TestParseTIFFGPSValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		latRef, lonRef string
+		lat, lon       [3]uint32
+		valid          bool
+	}{
+		{"southern and western", "S", "W", [3]uint32{33, 51, 0}, [3]uint32{151, 12, 36}, true},
+		{"latitude out of range", "N", "E", [3]uint32{95, 0, 0}, [3]uint32{10, 0, 0}, false},
+		{"longitude out of range", "N", "E", [3]uint32{45, 0, 0}, [3]uint32{190, 0, 0}, false},
+		{"no fix", "N", "E", [3]uint32{0, 0, 0}, [3]uint32{0, 0, 0}, false},
+		{"on the equator", "N", "E", [3]uint32{0, 0, 0}, [3]uint32{32, 0, 0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exif, err := parseTIFF(gpsTIFF(binary.BigEndian, tt.latRef, tt.lat, tt.lonRef, tt.lon))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := exif.Latitude != nil && exif.Longitude != nil; got != tt.valid {
+				t.Errorf("position = %v, %v; want valid = %v", exif.Latitude, exif.Longitude, tt.valid)
+			}
+			if !tt.valid && (exif.Latitude != nil || exif.Longitude != nil) {
+				t.Error("an invalid position should drop both coordinates")
+			}
+		})
+	}
+}
+
+func TestParseTIFFMalformed(t *testing.T) {
+	order := binary.LittleEndian
+	valid := gpsTIFF(order, "N", [3]uint32{51, 30, 0}, "W", [3]uint32{0, 7, 30})
+
+	// Every truncation must fail cleanly or parse what is left, never panic.
+	for n := range len(valid) {
+		parseTIFF(valid[:n])
+	}
+
+	w := newTIFF(order)
+	selfRef := w.root(w.ifd(w.long(tagExifIFD, 8)))
+
+	w = newTIFF(order)
+	shared := w.ifd(w.ascii(tagLensModel, "lens"))
+	sharedSub := w.root(w.ifd(w.long(tagExifIFD, shared), w.long(tagGPSIFD, shared)))
+
+	w = newTIFF(order)
+	badSub := w.root(w.ifd(w.long(tagExifIFD, 1<<31)))
+
+	w = newTIFF(order)
+	hugeCount := w.root(w.ifd(tiffEntry{tag: tagMake, typ: typeASCII, count: math.MaxUint32, value: make([]byte, 8)}))
+
+	tests := []struct {
+		name    string
+		tiff    []byte
+		wantErr bool
+	}{
+		{"too short", valid[:7], true},
+		{"bad byte order", append([]byte("XX"), valid[2:]...), true},
+		{"IFD0 out of range", newTIFF(order).root(1 << 20), true},
+		{"IFD0 count past the end", append(newTIFF(order).root(8), 0xff, 0x00), true},
+		{"sub-IFD offset out of range", badSub, true},
+		{"Exif IFD pointing at IFD0", selfRef, true},
+		{"Exif and GPS IFDs sharing an offset", sharedSub, true},
+		{"value offset out of range", hugeCount, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exif, err := parseTIFF(tt.tiff)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error = %v", err, tt.wantErr)
+			}
+			if err == nil && exif.Make != "" {
+				t.Errorf("Make = %q, want it dropped", exif.Make)
+			}
+		})
+	}
+}
+
+func TestFindEXIFTruncatedSegment(t *testing.T) {
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xe1, 0x10, 0x00, 'E', 'x', 'i', 'f'}
+	if _, err := FindEXIF(jpeg); err == nil || errors.Is(err, ErrNoEXIF) {
+		t.Errorf("FindEXIF(truncated) = %v, want a truncation error", err)
+	}
+	if _, err := FindEXIF([]byte("not a jpeg")); !errors.Is(err, ErrNoEXIF) {
+		t.Errorf("FindEXIF(not JPEG) = %v, want ErrNoEXIF", err)
+	}
+	if _, err := ReadEXIF(withEXIF([]byte{0xff, 0xd8, 0xff, 0xd9}, []byte("MM\x00"))); !errors.Is(err, ErrNoEXIF) {
+		t.Errorf("ReadEXIF(short TIFF) = %v, want ErrNoEXIF", err)
+	}
+}
@@ -0,0 +1,325 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Streak is a straight trail across an image, with endpoints in pixel
+// coordinates of the source image measured from the centre of the top-left
+// pixel.
+type Streak struct {
+	X1, Y1 float64
+	X2, Y2 float64
+}
+
+// Length returns the streak's length in pixels.
+func (s Streak) Length() float64 {
+	return math.Hypot(s.X2-s.X1, s.Y2-s.Y1)
+}
+
+const (
+	// streakWorkSize is the longest side the detector works at; streaks
+	// survive the box filter while noise averages down.
+	streakWorkSize = 1200
+	// streakBlock is the size of the tiles the sky background is estimated
+	// in, which absorbs gradients from light pollution and vignetting.
+	streakBlock = 64
+	// streakSigma is the detection threshold above the local background.
+	streakSigma = 3.0
+	// streakMinAxisRatio rejects round blobs (stars) before the transform.
+	streakMinAxisRatio = 3.0
+	// streakLineWidth is how far from a Hough line a pixel may lie and
+	// still count as part of it.
+	streakLineWidth = 1.5
+	// streakMaxGap is the longest gap, in working pixels, a streak may
+	// have; satellites that flash or tumble leave dashed trails.
+	streakMaxGap = 25
+	maxStreaks   = 8
+)
+
+// DetectStreaks finds straight trails left by satellites, aircraft and
+// meteors. The image is thresholded against a tiled background estimate,
+// compact blobs such as stars are masked out, and the remaining pixels are
+// grouped into lines with a Hough transform.
+func DetectStreaks(img image.Image) []Streak {
+	small := Thumbnail(img, streakWorkSize)
+	b, sb := img.Bounds(), small.Bounds()
+	scaleX := float64(b.Dx()) / float64(sb.Dx())
+	scaleY := float64(b.Dy()) / float64(sb.Dy())
+
+	w, h := sb.Dx(), sb.Dy()
+	mask := threshold(luminance(small), w, h)
+	points := elongatedPixels(mask, w, h)
+	minLength := max(40, 0.05*float64(max(w, h)))
+
+	var streaks []Streak
+	for _, seg := range houghSegments(points, w, h, minLength) {
+		streaks = append(streaks, Streak{
+			X1: (seg.X1+0.5)*scaleX - 0.5,
+			Y1: (seg.Y1+0.5)*scaleY - 0.5,
+			X2: (seg.X2+0.5)*scaleX - 0.5,
+			Y2: (seg.Y2+0.5)*scaleY - 0.5,
+		})
+	}
+	return streaks
+}
+
+func luminance(img image.Image) []float64 {
+	b := img.Bounds()
+	lum := make([]float64, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			lum[(y-b.Min.Y)*b.Dx()+x-b.Min.X] = (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(bl)) / 257
+		}
+	}
+	return lum
+}
+
+// threshold marks pixels brighter than their tile's median by streakSigma
+// robust standard deviations.
+func threshold(lum []float64, w, h int) []bool {
+	mask := make([]bool, len(lum))
+	for by := 0; by < h; by += streakBlock {
+		for bx := 0; bx < w; bx += streakBlock {
+			x1, y1 := min(bx+streakBlock, w), min(by+streakBlock, h)
+			var tile []float64
+			for y := by; y < y1; y++ {
+				tile = append(tile, lum[y*w+bx:y*w+x1]...)
+			}
+
+			median := medianOf(tile)
+			for i, v := range tile {
+				tile[i] = math.Abs(v - median)
+			}
+			sigma := max(1.4826*medianOf(tile), 1)
+
+			limit := median + streakSigma*sigma
+			for y := by; y < y1; y++ {
+				for x := bx; x < x1; x++ {
+					mask[y*w+x] = lum[y*w+x] > limit
+				}
+			}
+		}
+	}
+	return mask
+}
+
+func medianOf(values []float64) float64 {
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+type point struct{ x, y float64 }
+
+// elongatedPixels returns the pixels of connected blobs whose second
+// moments make them clearly longer than wide, dropping stars, noise and
+// broad glows.
+func elongatedPixels(mask []bool, w, h int) []point {
+	seen := make([]bool, len(mask))
+	var points, blob []point
+	var stack []int
+
+	for start := range mask {
+		if !mask[start] || seen[start] {
+			continue
+		}
+
+		blob, stack = blob[:0], append(stack[:0], start)
+		seen[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%w, i/w
+			blob = append(blob, point{float64(x), float64(y)})
+
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					if j := ny*w + nx; mask[j] && !seen[j] {
+						seen[j] = true
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+
+		if elongated(blob) {
+			points = append(points, blob...)
+		}
+	}
+	return points
+}
+
+func elongated(blob []point) bool {
+	if len(blob) < 5 {
+		return false
+	}
+
+	var mx, my float64
+	for _, p := range blob {
+		mx += p.x
+		my += p.y
+	}
+	n := float64(len(blob))
+	mx, my = mx/n, my/n
+
+	var sxx, syy, sxy float64
+	for _, p := range blob {
+		dx, dy := p.x-mx, p.y-my
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+	sxx, syy, sxy = sxx/n, syy/n, sxy/n
+
+	// Eigenvalues of the covariance matrix are the squared semi-axes.
+	mean := (sxx + syy) / 2
+	spread := math.Sqrt((sxx-syy)*(sxx-syy)/4 + sxy*sxy)
+	major, minor := mean+spread, max(mean-spread, 0.25)
+	return major/minor >= streakMinAxisRatio*streakMinAxisRatio && math.Sqrt(12*major) >= 10
+}
+
+// houghSegments repeatedly takes the strongest line through the points,
+// keeps its longest run if it is long enough, and removes the run's pixels
+// before looking again.
+func houghSegments(points []point, w, h int, minLength float64) []Streak {
+	const thetaSteps = 360
+	diag := int(math.Ceil(math.Hypot(float64(w), float64(h))))
+	rhoSize := 2*diag + 1
+
+	cos, sin := make([]float64, thetaSteps), make([]float64, thetaSteps)
+	for t := range thetaSteps {
+		theta := float64(t) * math.Pi / thetaSteps
+		cos[t], sin[t] = math.Cos(theta), math.Sin(theta)
+	}
+
+	votes := make([]int, thetaSteps*rhoSize)
+	vote := func(p point, delta int) {
+		for t := range thetaSteps {
+			rho := int(math.Round(p.x*cos[t]+p.y*sin[t])) + diag
+			votes[t*rhoSize+rho] += delta
+		}
+	}
+	for _, p := range points {
+		vote(p, 1)
+	}
+
+	alive := make([]bool, len(points))
+	for i := range alive {
+		alive[i] = true
+	}
+
+	var streaks []Streak
+	for attempts := 0; len(streaks) < maxStreaks && attempts < 4*maxStreaks; attempts++ {
+		best := 0
+		for i, v := range votes {
+			if v > votes[best] {
+				best = i
+			}
+		}
+		if float64(votes[best]) < 0.5*minLength {
+			break
+		}
+
+		t, rho := best/rhoSize, float64(best%rhoSize-diag)
+		c, s, r := refineLine(points, alive, cos[t], sin[t], rho)
+		run, members := longestRun(points, alive, c, s, r)
+		if run.Length() < minLength {
+			votes[best] = 0
+			continue
+		}
+
+		for _, i := range members {
+			alive[i] = false
+			vote(points[i], -1)
+		}
+		streaks = append(streaks, run)
+	}
+	return streaks
+}
+
+// refineLine fits the line x cos θ + y sin θ = rho to the live points near
+// it by total least squares. The Hough bins are coarse enough that a long
+// streak drifts several pixels off its bin's line from end to end.
+func refineLine(points []point, alive []bool, cos, sin, rho float64) (float64, float64, float64) {
+	for range 3 {
+		var sx, sy, n float64
+		var near []point
+		for i, p := range points {
+			if alive[i] && math.Abs(p.x*cos+p.y*sin-rho) <= 4*streakLineWidth {
+				near = append(near, p)
+				sx, sy, n = sx+p.x, sy+p.y, n+1
+			}
+		}
+		if n < 2 {
+			break
+		}
+
+		mx, my := sx/n, sy/n
+		var sxx, syy, sxy float64
+		for _, p := range near {
+			dx, dy := p.x-mx, p.y-my
+			sxx += dx * dx
+			syy += dy * dy
+			sxy += dx * dy
+		}
+		// The normal is the direction of least spread.
+		angle := 0.5*math.Atan2(2*sxy, sxx-syy) + math.Pi/2
+		cos, sin = math.Cos(angle), math.Sin(angle)
+		rho = mx*cos + my*sin
+	}
+	return cos, sin, rho
+}
+
+// longestRun collects live points within streakLineWidth of the line
+// x cos θ + y sin θ = rho and returns the longest stretch without a gap
+// wider than streakMaxGap, with the indices of its points.
+func longestRun(points []point, alive []bool, cos, sin, rho float64) (Streak, []int) {
+	type onLine struct {
+		along float64
+		index int
+	}
+
+	var line []onLine
+	for i, p := range points {
+		if alive[i] && math.Abs(p.x*cos+p.y*sin-rho) <= streakLineWidth {
+			line = append(line, onLine{along: -p.x*sin + p.y*cos, index: i})
+		}
+	}
+	if len(line) == 0 {
+		return Streak{}, nil
+	}
+	sort.Slice(line, func(i, j int) bool { return line[i].along < line[j].along })
+
+	bestStart, bestEnd, start := 0, 0, 0
+	for i := 1; i <= len(line); i++ {
+		if i == len(line) || line[i].along-line[i-1].along > streakMaxGap {
+			if line[i-1].along-line[start].along > line[bestEnd].along-line[bestStart].along {
+				bestStart, bestEnd = start, i-1
+			}
+			start = i
+		}
+	}
+
+	members := make([]int, 0, bestEnd-bestStart+1)
+	for _, p := range line[bestStart : bestEnd+1] {
+		members = append(members, p.index)
+	}
+
+	// Endpoints are placed on the fitted line rather than at the extreme
+	// pixels, which can sit up to a line width to either side.
+	at := func(along float64) (float64, float64) {
+		return rho*cos - along*sin, rho*sin + along*cos
+	}
+	x1, y1 := at(line[bestStart].along)
+	x2, y2 := at(line[bestEnd].along)
+	return Streak{X1: x1, Y1: y1, X2: x2, Y2: y2}, members
+}
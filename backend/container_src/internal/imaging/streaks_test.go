@@ -0,0 +1,73 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// skyImage returns a noisy dark frame with Gaussian stars at the given
+// positions.
+func skyImage(w, h int, stars []point) *image.Gray {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			v := 30 + 4*rng.NormFloat64()
+			for _, s := range stars {
+				d2 := (float64(x)-s.x)*(float64(x)-s.x) + (float64(y)-s.y)*(float64(y)-s.y)
+				v += 220 * math.Exp(-d2/(2*1.5*1.5))
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(min(max(v, 0), 255))})
+		}
+	}
+	return img
+}
+
+// drawLine brightens a two pixel wide line from (x1, y1) to (x2, y2).
+func drawLine(img *image.Gray, x1, y1, x2, y2 float64) {
+	length := math.Hypot(x2-x1, y2-y1)
+	for step := 0.0; step <= length; step += 0.25 {
+		x := x1 + (x2-x1)*step/length
+		y := y1 + (y2-y1)*step/length
+		for _, d := range []float64{-0.5, 0.5} {
+			px := int(math.Round(x - d*(y2-y1)/length))
+			py := int(math.Round(y + d*(x2-x1)/length))
+			img.SetGray(px, py, color.Gray{Y: 160})
+		}
+	}
+}
+
+var testStars = []point{{60, 60}, {300, 120}, {420, 400}, {700, 90}, {150, 500}, {520, 260}, {740, 540}}
+
+func TestDetectStreaks(t *testing.T) {
+	// One star sits on the trail and one on its extension past the end;
+	// neither may move the endpoints.
+	img := skyImage(800, 600, append([]point{{300, 225.5}, {720, 531}}, testStars...))
+	drawLine(img, 100, 80, 650, 480)
+
+	streaks := DetectStreaks(img)
+	if len(streaks) != 1 {
+		t.Fatalf("DetectStreaks found %d streaks, want 1: %+v", len(streaks), streaks)
+	}
+	s := streaks[0]
+	if s.X1 > s.X2 {
+		s.X1, s.Y1, s.X2, s.Y2 = s.X2, s.Y2, s.X1, s.Y1
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{{"X1", s.X1, 100}, {"Y1", s.Y1, 80}, {"X2", s.X2, 650}, {"Y2", s.Y2, 480}} {
+		if math.Abs(c.got-c.want) > 3 {
+			t.Errorf("%s = %.1f, want %.0f", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestDetectStreaksIgnoresStars(t *testing.T) {
+	if streaks := DetectStreaks(skyImage(800, 600, testStars)); len(streaks) != 0 {
+		t.Errorf("DetectStreaks found %+v in a field of stars", streaks)
+	}
+}
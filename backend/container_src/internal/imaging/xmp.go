@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotJPEG     = errors.New("not a JPEG image")
+	ErrXMPTooLarge = errors.New("XMP packet does not fit in a JPEG segment")
+)
+
+const (
+	markerSOI  = 0xd8
+	markerSOS  = 0xda
+	markerAPP0 = 0xe0
+	markerAPP1 = 0xe1
+
+	maxSegmentPayload = 0xffff - 2
+)
+
+var (
+	exifHeader        = []byte("Exif\x00\x00")
+	xmpHeader         = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	extendedXMPHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+)
+
+// SetXMP returns a copy of a JPEG with its XMP packet replaced by packet.
+// Every other segment, including EXIF, and the compressed image data are
+// copied unchanged. The new packet goes after the JFIF and EXIF segments,
+// where readers expect it.
+func SetXMP(data, packet []byte) ([]byte, error) {
+	return setXMP(data, packet, true)
+}
+
+// SetXMPWithoutEXIF is SetXMP for images shared with someone other than
+// their owner: it also drops the EXIF segment, which may carry GPS
+// coordinates.
+func SetXMPWithoutEXIF(data, packet []byte) ([]byte, error) {
+	return setXMP(data, packet, false)
+}
+
+func setXMP(data, packet []byte, keepEXIF bool) ([]byte, error) {
+	if len(xmpHeader)+len(packet) > maxSegmentPayload {
+		return nil, ErrXMPTooLarge
+	}
+	if len(data) < 4 || data[0] != 0xff || data[1] != markerSOI {
+		return nil, ErrNotJPEG
+	}
+
+	var segments [][]byte
+	insert := 0
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xff {
+			return nil, fmt.Errorf("malformed JPEG segment at offset %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == markerSOS {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment at offset %d", pos)
+		}
+
+		segment, payload := data[pos:end], data[pos+4:end]
+		pos = end
+		switch {
+		case marker == markerAPP1 && (bytes.HasPrefix(payload, xmpHeader) || bytes.HasPrefix(payload, extendedXMPHeader)):
+			continue
+		case marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) && !keepEXIF:
+			continue
+		case marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader),
+			marker == markerAPP0 && len(segments) == 0:
+			segments = append(segments, segment)
+			insert = len(segments)
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	xmp := make([]byte, 4, 4+len(xmpHeader)+len(packet))
+	xmp[0], xmp[1] = 0xff, markerAPP1
+	binary.BigEndian.PutUint16(xmp[2:], uint16(2+len(xmpHeader)+len(packet)))
+	xmp = append(append(xmp, xmpHeader...), packet...)
+
+	var out bytes.Buffer
+	out.Grow(len(data) + len(xmp))
+	out.Write(data[:2])
+	for i, segment := range segments {
+		if i == insert {
+			out.Write(xmp)
+		}
+		out.Write(segment)
+	}
+	if insert == len(segments) {
+		out.Write(xmp)
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+// withEXIF inserts an EXIF APP1 segment holding tiff after the SOI marker.
+func withEXIF(data, tiff []byte) []byte {
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	segment := []byte{0xff, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	segment = append(segment, payload...)
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func countXMP(data []byte) int {
+	return bytes.Count(data, xmpHeader)
+}
+
+func TestSetXMP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 16, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08fake exif")
+	original := withEXIF(buf.Bytes(), tiff)
+
+	first, err := SetXMP(original, []byte("<first/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := SetXMP(first, []byte("<second/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if countXMP(second) != 1 || !bytes.Contains(second, []byte("<second/>")) || bytes.Contains(second, []byte("<first/>")) {
+		t.Error("SetXMP should replace the existing packet")
+	}
+	got, err := FindEXIF(second)
+	if err != nil || !bytes.Equal(got, tiff) {
+		t.Errorf("EXIF = %q, %v; want it preserved", got, err)
+	}
+	if exif, xmp := bytes.Index(second, exifHeader), bytes.Index(second, xmpHeader); exif > xmp {
+		t.Error("XMP should follow the EXIF segment")
+	}
+	if len(second) != len(original)+4+len(xmpHeader)+len("<second/>") {
+		t.Errorf("len = %d, want only the XMP segment added to %d", len(second), len(original))
+	}
+
+	img, err := Decode(second)
+	if err != nil || img.Bounds().Dx() != 16 {
+		t.Errorf("image no longer decodes: %v", err)
+	}
+
+	if _, err := SetXMP([]byte("\x89PNG\r\n"), nil); err != ErrNotJPEG {
+		t.Errorf("SetXMP on a PNG: err = %v, want ErrNotJPEG", err)
+	}
+	if _, err := SetXMP(original, make([]byte, 70000)); err != ErrXMPTooLarge {
+		t.Errorf("SetXMP with a huge packet: err = %v, want ErrXMPTooLarge", err)
+	}
+}
+
+func TestSetXMPWithoutEXIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 16, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	original := withEXIF(buf.Bytes(), []byte("MM\x00\x2a\x00\x00\x00\x08GPS here"))
+
+	got, err := SetXMPWithoutEXIF(original, []byte("<packet/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindEXIF(got); err != ErrNoEXIF {
+		t.Errorf("FindEXIF err = %v, want the EXIF segment dropped", err)
+	}
+	if countXMP(got) != 1 {
+		t.Error("XMP packet missing")
+	}
+	if img, err := Decode(got); err != nil || img.Bounds().Dx() != 16 {
+		t.Errorf("image no longer decodes: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func Deprecation(deprecatedAt, sunset time.Time, successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !deprecatedAt.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+			}
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if successor != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,17 +2,33 @@ package data
 
 import (
 	_ "embed"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 //go:embed catalog.txt
 var catalogData string
 
+//go:embed positions.txt
+var positionsData string
+
 type ObjectInfo struct {
 	Name          string
 	Constellation string
 	Type          string
 	DisplayName   string
+	Position      *Position
+}
+
+// Position is a catalog entry's J2000 position in degrees, with its visual
+// magnitude and major-axis size in arcminutes when known.
+type Position struct {
+	RA        float64
+	Dec       float64
+	Magnitude *float64
+	Size      float64
 }
 
 func (o ObjectInfo) GetDisplayName() string {
@@ -48,9 +64,109 @@ func init() {
 		}
 		catalog[strings.ToLower(parts[0])] = info
 	}
+
+	for name, position := range parsePositions(positionsData) {
+		if info, ok := catalog[name]; ok {
+			info.Position = position
+			catalog[name] = info
+		}
+	}
+}
+
+// IsDesignation reports whether a name is a catalog designation such as
+// "M42" or "NGC 7000" rather than a common name.
+func IsDesignation(name string) bool {
+	for _, prefix := range []string{"M", "NGC ", "IC "} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && rest != "" && unicode.IsDigit(rune(rest[0])) {
+			return true
+		}
+	}
+	return false
 }
 
 func GetObjectInfo(name string) (ObjectInfo, bool) {
 	info, ok := catalog[strings.ToLower(name)]
 	return info, ok
 }
+
+// All returns every catalog entry, sorted by name. Common names such as
+// "Orion Nebula" appear alongside their catalog designations.
+func All() []ObjectInfo {
+	all := make([]ObjectInfo, 0, len(catalog))
+	for _, info := range catalog {
+		all = append(all, info)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+func parsePositions(text string) map[string]*Position {
+	positions := make(map[string]*Position)
+	aliases := make(map[string]string)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		name := strings.ToLower(parts[0])
+		if len(parts) == 2 && strings.HasPrefix(parts[1], "=") {
+			aliases[name] = strings.ToLower(parts[1][1:])
+			continue
+		}
+		if len(parts) < 3 {
+			continue
+		}
+
+		ra, okRA := parseSexagesimal(parts[1])
+		dec, okDec := parseSexagesimal(parts[2])
+		if !okRA || !okDec {
+			continue
+		}
+
+		position := &Position{RA: ra * 15, Dec: dec}
+		if len(parts) >= 4 && parts[3] != "" {
+			if mag, err := strconv.ParseFloat(parts[3], 64); err == nil {
+				position.Magnitude = &mag
+			}
+		}
+		if len(parts) >= 5 && parts[4] != "" {
+			position.Size, _ = strconv.ParseFloat(parts[4], 64)
+		}
+		positions[name] = position
+	}
+
+	for name, target := range aliases {
+		for i := 0; i < len(aliases) && positions[target] == nil; i++ {
+			target = aliases[target]
+		}
+		if position := positions[target]; position != nil {
+			positions[name] = position
+		}
+	}
+	return positions
+}
+
+// parseSexagesimal parses "hh:mm:ss.s" or "±dd:mm:ss" into decimal units.
+func parseSexagesimal(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+
+	var value float64
+	scale := 1.0
+	for _, field := range strings.Split(s, ":") {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v < 0 {
+			return 0, false
+		}
+		value += v / scale
+		scale *= 60
+	}
+	return sign * value, true
+}
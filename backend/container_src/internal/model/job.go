@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+type Job struct {
+	ID          int
+	DeviceID    string
+	KeyID       string
+	Filename    string
+	Observation *Observation
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Status      string
+	Result      *SolveResult
+	Error       string
+}
+
+// JobSummary is the part of a job a history listing shows and filters on.
+// Stores keep it alongside the device index so listing does not load jobs.
+type JobSummary struct {
+	ID        int
+	Status    string
+	Filename  string
+	CreatedAt time.Time
+	Objects   []ObjectRef
+}
+
+// ObjectRef names an identified object without its position.
+type ObjectRef struct {
+	Name          string
+	DisplayName   string
+	Type          string
+	Constellation string
+}
+
+func (j *Job) Summary() *JobSummary {
+	summary := &JobSummary{ID: j.ID, Status: j.Status, Filename: j.Filename, CreatedAt: j.CreatedAt}
+	if j.Result == nil {
+		return summary
+	}
+	for _, o := range j.Result.Objects {
+		summary.Objects = append(summary.Objects, ObjectRef{
+			Name:          o.Name,
+			DisplayName:   o.GetDisplayName(),
+			Type:          o.Type,
+			Constellation: o.Constellation,
+		})
+	}
+	return summary
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Observation describes when, where and with what an image was captured.
+// Values come from the submission form, falling back to the image's EXIF.
+type Observation struct {
+	CapturedAt *time.Time
+	Location   *Location
+	Equipment  *Equipment
+}
+
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	Elevation *float64
+}
+
+type Equipment struct {
+	Telescope   string
+	FocalLength float64
+	Camera      string
+}
+
+func (o *Observation) IsEmpty() bool {
+	return o == nil || (o.CapturedAt == nil && o.Location == nil && o.Equipment == nil)
+}
@@ -1,6 +1,11 @@
 package model
 
-import "server/internal/model/data"
+import (
+	"time"
+
+	"server/internal/model/data"
+	"server/internal/wcs"
+)
 
 type CelestialObject struct {
 	Name          string
@@ -9,6 +14,46 @@ type CelestialObject struct {
 	DisplayName   string
 	PixelX        *float64
 	PixelY        *float64
+	Footprint     *Footprint
+	Coordinates   *Coordinates
+	Sky           *SkyPosition
+}
+
+// Coordinates is a J2000 catalog position in degrees.
+type Coordinates struct {
+	RA  float64
+	Dec float64
+}
+
+// SkyPosition is where an object stood for the observer at capture time,
+// and its rise, transit and set for that night. Altitude includes
+// refraction, HourAngle is in hours (negative east of the meridian) and
+// Airmass is nil below the horizon.
+type SkyPosition struct {
+	Altitude    float64
+	Azimuth     float64
+	HourAngle   float64
+	Airmass     *float64
+	Rise        *time.Time
+	Transit     time.Time
+	Set         *time.Time
+	Circumpolar bool
+	NeverRises  bool
+}
+
+type Footprint struct {
+	PixelX float64
+	PixelY float64
+	Radius float64
+}
+
+type Calibration struct {
+	RA          float64
+	Dec         float64
+	Radius      float64
+	PixelScale  float64
+	Orientation float64
+	Parity      int
 }
 
 func (o CelestialObject) GetDisplayName() string {
@@ -18,8 +63,53 @@ func (o CelestialObject) GetDisplayName() string {
 	return o.Name
 }
 
+// Streak is a straight trail detected in the image. Endpoints are in image
+// pixels; Start and End are their J2000 positions when the plate solution
+// is known. Shower names the meteor shower it may belong to, or "sporadic".
+type Streak struct {
+	X1, Y1     float64
+	X2, Y2     float64
+	Start      *Coordinates
+	End        *Coordinates
+	Satellites []SatelliteMatch
+	Shower     string
+}
+
+// SatelliteMatch is a satellite whose predicted track runs along a streak.
+// Offset is the mean angular distance in degrees between the two.
+type SatelliteMatch struct {
+	Name    string
+	NoradID int
+	Offset  float64
+}
+
+// FieldConstellation is a constellation the field overlaps. Coverage is
+// the fraction of the image inside it.
+type FieldConstellation struct {
+	Abbreviation string
+	Name         string
+	Coverage     float64
+}
+
+// ConstellationLine is a stick-figure segment between two Hipparcos stars,
+// in image pixels and clipped to the image.
+type ConstellationLine struct {
+	Constellation string
+	FromHIP       int
+	ToHIP         int
+	X1, Y1        float64
+	X2, Y2        float64
+}
+
 type SolveResult struct {
-	Objects []CelestialObject
+	Objects            []CelestialObject
+	Streaks            []Streak
+	Constellations     []FieldConstellation
+	ConstellationLines []ConstellationLine
+	Calibration        *Calibration
+	// WCS is the full plate solution; it is nil for results recorded
+	// before it was fetched or when astrometry.net did not provide it.
+	WCS *wcs.WCS
 }
 
 func GetCelestialObject(name string) (*CelestialObject, bool) {
@@ -33,5 +123,13 @@ func GetCelestialObject(name string) (*CelestialObject, bool) {
 		Type:          info.Type,
 		Constellation: info.Constellation,
 		DisplayName:   info.DisplayName,
+		Coordinates:   CoordinatesOf(info),
 	}, true
 }
+
+func CoordinatesOf(info data.ObjectInfo) *Coordinates {
+	if info.Position == nil {
+		return nil
+	}
+	return &Coordinates{RA: info.Position.RA, Dec: info.Position.Dec}
+}
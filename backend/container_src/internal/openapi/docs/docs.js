@@ -0,0 +1,230 @@
+"use strict";
+
+// A small, dependency-free viewer for /openapi.json. It lists operations by
+// tag, shows their parameters and schemas, and can send requests with the
+// credentials entered in the header.
+
+const methods = ["get", "post", "put", "patch", "delete"];
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs || {})) {
+    if (name === "class") {
+      node.className = value;
+    } else {
+      node.setAttribute(name, value);
+    }
+  }
+  for (const child of children.flat()) {
+    if (child !== null && child !== undefined) {
+      node.append(child);
+    }
+  }
+  return node;
+}
+
+function resolve(spec, schema) {
+  while (schema && schema.$ref) {
+    schema = spec.components.schemas[schema.$ref.split("/").pop()];
+  }
+  return schema || {};
+}
+
+function typeName(schema) {
+  if (schema.$ref) {
+    return schema.$ref.split("/").pop();
+  }
+  if (schema.type === "array") {
+    return typeName(schema.items || {}) + "[]";
+  }
+  return [].concat(schema.type || "any").join(" | ");
+}
+
+// schemaTree lists an object's properties, expanding nested objects once so
+// recursive types stay finite.
+function schemaTree(spec, schema, seen) {
+  seen = seen || new Set();
+  let target = schema.type === "array" ? schema.items || {} : schema;
+  const name = target.$ref ? target.$ref.split("/").pop() : null;
+  if (name && seen.has(name)) {
+    return null;
+  }
+  target = resolve(spec, target);
+  if (!target.properties) {
+    return null;
+  }
+  const next = new Set(seen);
+  if (name) {
+    next.add(name);
+  }
+  const required = new Set(target.required || []);
+  return el("ul", { class: "schema" },
+    Object.entries(target.properties).map(([prop, value]) =>
+      el("li", {},
+        el("span", { class: required.has(prop) ? "required" : "" }, prop),
+        ": ",
+        el("span", { class: "type" }, typeName(value)),
+        schemaTree(spec, value, next))));
+}
+
+function credentials() {
+  const headers = {};
+  const key = document.getElementById("api-key").value.trim();
+  const token = document.getElementById("device-token").value.trim();
+  if (key) {
+    headers["X-API-Key"] = key;
+  }
+  if (token) {
+    headers["X-Device-Token"] = token;
+  }
+  return headers;
+}
+
+async function send(method, path, inputs, form, json, output) {
+  let url = path;
+  const query = new URLSearchParams();
+  for (const input of inputs) {
+    const value = input.value.trim();
+    if (input.dataset.in === "path") {
+      url = url.replace("{" + input.name + "}", encodeURIComponent(value));
+    } else if (value) {
+      query.set(input.name, value);
+    }
+  }
+  if ([...query].length) {
+    url += "?" + query;
+  }
+
+  const init = { method: method.toUpperCase(), headers: credentials() };
+  if (form) {
+    const body = new FormData();
+    for (const input of form) {
+      if (input.type === "file" && input.files.length) {
+        body.append(input.name, input.files[0]);
+      } else if (input.type !== "file" && input.value.trim()) {
+        body.append(input.name, input.value.trim());
+      }
+    }
+    init.body = body;
+  } else if (json && json.value.trim()) {
+    init.headers["Content-Type"] = "application/json";
+    init.body = json.value;
+  }
+
+  output.textContent = init.method + " " + url + " …";
+  try {
+    const response = await fetch(url, init);
+    const type = response.headers.get("Content-Type") || "";
+    let text;
+    if (/json|text|xml|csv|svg/.test(type)) {
+      text = await response.text();
+      try {
+        text = JSON.stringify(JSON.parse(text), null, 2);
+      } catch (e) {
+        // Not JSON; show it as is.
+      }
+    } else {
+      text = "(" + (await response.blob()).size + " bytes of " + type + ")";
+    }
+    output.textContent = response.status + " " + response.statusText + "\n" + type + "\n\n" + text;
+  } catch (e) {
+    output.textContent = "Request failed: " + e;
+  }
+}
+
+function operationView(spec, path, method, op) {
+  const inputs = (op.parameters || []).map((p) =>
+    el("input", { name: p.name, "data-in": p.in, placeholder: typeName(p.schema || {}) }));
+  const params = (op.parameters || []).map((p, i) =>
+    el("tr", {},
+      el("td", { class: p.required || p.in === "path" ? "required" : "" }, p.name),
+      el("td", {}, p.in),
+      el("td", {}, p.description || ""),
+      el("td", {}, inputs[i])));
+
+  let form = null;
+  let formRows = [];
+  const multipart = op.requestBody && op.requestBody.content["multipart/form-data"];
+  if (multipart) {
+    const schema = multipart.schema;
+    const required = new Set(schema.required || []);
+    form = Object.entries(schema.properties).map(([name, prop]) =>
+      el("input", prop.format === "binary" ? { name, type: "file" } : { name, placeholder: typeName(prop) }));
+    formRows = Object.entries(schema.properties).map(([name, prop], i) =>
+      el("tr", {},
+        el("td", { class: required.has(name) ? "required" : "" }, name),
+        el("td", {}, "form"),
+        el("td", {}, prop.description || ""),
+        el("td", {}, form[i])));
+  }
+  const json = op.requestBody && op.requestBody.content["application/json"];
+  const jsonInput = json ? el("textarea", { rows: "4", placeholder: "{}" }) : null;
+
+  const responses = Object.entries(op.responses || {}).map(([status, response]) => {
+    const content = response.content || {};
+    const [type, media] = Object.entries(content)[0] || [];
+    const schema = media && media.schema ? media.schema : null;
+    return el("li", {},
+      el("strong", {}, status), " ", response.description,
+      type ? el("span", { class: "type" }, " " + type) : null,
+      schema && schema.$ref ? el("span", { class: "type" }, " " + typeName(schema)) : null,
+      status !== "default" && schema ? schemaTree(spec, schema) : null);
+  });
+
+  const output = el("pre", { class: "result" });
+  output.hidden = true;
+  const button = el("button", { type: "button" }, "Send");
+  button.addEventListener("click", () => {
+    output.hidden = false;
+    send(method, path, inputs, form, jsonInput, output);
+  });
+
+  return el("details", { class: "op" + (op.deprecated ? " deprecated" : ""), id: op.operationId },
+    el("summary", {},
+      el("span", { class: "method " + method }, method),
+      el("span", { class: "path" }, path),
+      el("span", { class: "summary" }, op.summary || "")),
+    el("div", {},
+      params.length || formRows.length
+        ? el("table", {}, el("tr", {}, el("th", {}, "Name"), el("th", {}, "In"), el("th", {}, "Description"), el("th", {}, "Value")), params, formRows)
+        : null,
+      json ? el("div", {}, "Body: ", el("span", { class: "type" }, typeName(json.schema)), schemaTree(spec, json.schema), jsonInput) : null,
+      el("p", {}, button),
+      el("h4", {}, "Responses"),
+      el("ul", {}, responses),
+      output));
+}
+
+function render(spec) {
+  document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+  const byTag = new Map();
+  for (const [path, item] of Object.entries(spec.paths)) {
+    for (const method of methods) {
+      const op = item[method];
+      if (!op) {
+        continue;
+      }
+      const tag = (op.tags && op.tags[0]) || "other";
+      if (!byTag.has(tag)) {
+        byTag.set(tag, []);
+      }
+      byTag.get(tag).push(operationView(spec, path, method, op));
+    }
+  }
+
+  const main = document.getElementById("operations");
+  main.replaceChildren(...[...byTag].flatMap(([tag, views]) => [el("h2", {}, tag), ...views]));
+}
+
+for (const id of ["api-key", "device-token"]) {
+  const input = document.getElementById(id);
+  input.value = sessionStorage.getItem(id) || "";
+  input.addEventListener("change", () => sessionStorage.setItem(id, input.value));
+}
+
+fetch("/openapi.json")
+  .then((response) => response.json())
+  .then(render)
+  .catch((e) => {
+    document.getElementById("operations").textContent = "Failed to load /openapi.json: " + e;
+  });
@@ -0,0 +1,356 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string                       `json:"openapi"`
+	Info       Info                         `json:"info"`
+	Security   []map[string][]string        `json:"security,omitempty"`
+	Paths      map[string]map[string]*opDoc `json:"paths"`
+	Components map[string]map[string]Schema `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Schema map[string]any
+
+type Operation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tag         string
+	Deprecated  bool
+	Params      []Param
+	Form        []FormField
+	Body        any
+	Responses   []Response
+}
+
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Type        string
+}
+
+type FormField struct {
+	Name        string
+	Description string
+	Binary      bool
+	Required    bool
+	Type        string
+}
+
+type Response struct {
+	Status      int
+	Description string
+	ContentType string
+	Body        any
+}
+
+type opDoc struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Tags        []string               `json:"tags,omitempty"`
+	Deprecated  bool                   `json:"deprecated,omitempty"`
+	Parameters  []paramDoc             `json:"parameters,omitempty"`
+	RequestBody *bodyDoc               `json:"requestBody,omitempty"`
+	Responses   map[string]responseDoc `json:"responses"`
+}
+
+type paramDoc struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema"`
+}
+
+type bodyDoc struct {
+	Required bool                    `json:"required"`
+	Content  map[string]mediaTypeDoc `json:"content"`
+}
+
+type responseDoc struct {
+	Description string                  `json:"description"`
+	Content     map[string]mediaTypeDoc `json:"content,omitempty"`
+}
+
+type mediaTypeDoc struct {
+	Schema Schema `json:"schema"`
+}
+
+func Build(title, version string, operations []Operation) *Document {
+	g := &generator{schemas: make(map[string]Schema)}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]map[string]*opDoc),
+	}
+
+	for _, op := range operations {
+		item, ok := doc.Paths[op.Path]
+		if !ok {
+			item = make(map[string]*opDoc)
+			doc.Paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = g.operation(op)
+	}
+
+	doc.Security = []map[string][]string{{}, {"bearerAuth": {}}, {"apiKeyHeader": {}}, {"deviceToken": {}}}
+	doc.Components = map[string]map[string]Schema{
+		"schemas": g.schemas,
+		"securitySchemes": {
+			"bearerAuth":   {"type": "http", "scheme": "bearer"},
+			"apiKeyHeader": {"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			"deviceToken":  {"type": "apiKey", "in": "header", "name": "X-Device-Token"},
+		},
+	}
+	return doc
+}
+
+func (d *Document) MarshalIndent() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func (d *Document) HasRoute(method, path string) bool {
+	item, ok := d.Paths[path]
+	if !ok {
+		return false
+	}
+	_, ok = item[strings.ToLower(method)]
+	return ok
+}
+
+type generator struct {
+	schemas map[string]Schema
+}
+
+func (g *generator) operation(op Operation) *opDoc {
+	doc := &opDoc{
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Deprecated:  op.Deprecated,
+		Responses:   make(map[string]responseDoc),
+	}
+	if op.Tag != "" {
+		doc.Tags = []string{op.Tag}
+	}
+
+	for _, p := range op.Params {
+		doc.Parameters = append(doc.Parameters, paramDoc{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+			Schema:      primitive(p.Type),
+		})
+	}
+
+	if len(op.Form) > 0 {
+		properties := make(map[string]any)
+		var required []string
+		for _, f := range op.Form {
+			schema := primitive(f.Type)
+			if f.Binary {
+				schema = Schema{"type": "string", "format": "binary"}
+			}
+			if f.Description != "" {
+				schema["description"] = f.Description
+			}
+			properties[f.Name] = schema
+			if f.Required {
+				required = append(required, f.Name)
+			}
+		}
+
+		schema := Schema{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		doc.RequestBody = &bodyDoc{
+			Required: true,
+			Content:  map[string]mediaTypeDoc{"multipart/form-data": {Schema: schema}},
+		}
+	}
+
+	if op.Body != nil {
+		doc.RequestBody = &bodyDoc{
+			Required: true,
+			Content:  map[string]mediaTypeDoc{"application/json": {Schema: g.schemaFor(reflect.TypeOf(op.Body))}},
+		}
+	}
+
+	for _, r := range op.Responses {
+		key := strconv.Itoa(r.Status)
+		if r.Status == 0 {
+			key = "default"
+		}
+
+		resp := doc.Responses[key]
+		resp.Description = r.Description
+		if r.Body != nil {
+			if resp.Content == nil {
+				resp.Content = make(map[string]mediaTypeDoc)
+			}
+			contentType := r.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			schema := g.schemaFor(reflect.TypeOf(r.Body))
+			if _, ok := r.Body.([]byte); ok {
+				schema = Schema{"type": "string", "format": "binary"}
+			}
+			resp.Content[contentType] = mediaTypeDoc{Schema: schema}
+		}
+		doc.Responses[key] = resp
+	}
+	return doc
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) schemaFor(t reflect.Type) Schema {
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return primitive("string")
+	case reflect.Bool:
+		return primitive("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return primitive("integer")
+	case reflect.Float32, reflect.Float64:
+		return primitive("number")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return Schema{}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return Schema{}
+	}
+}
+
+func (g *generator) structRef(t reflect.Type) Schema {
+	name := t.Name()
+	ref := Schema{"$ref": "#/components/schemas/" + name}
+	if _, ok := g.schemas[name]; ok {
+		return ref
+	}
+
+	g.schemas[name] = Schema{}
+	properties := make(map[string]any)
+	var required []string
+	for _, f := range Fields(t) {
+		properties[f.Name] = g.schemaFor(f.Type)
+		if !f.Optional {
+			required = append(required, f.Name)
+		}
+	}
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	g.schemas[name] = schema
+	return ref
+}
+
+type Field struct {
+	Name     string
+	Type     reflect.Type
+	Optional bool
+}
+
+func Fields(t reflect.Type) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && tag == "" && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, Fields(sf.Type)...)
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, Field{
+			Name:     name,
+			Type:     sf.Type,
+			Optional: strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero"),
+		})
+	}
+	return fields
+}
+
+func primitive(typ string) Schema {
+	if typ == "" {
+		typ = "string"
+	}
+	return Schema{"type": typ}
+}
+
+func JSONHandler(spec []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
+
+func Undocumented(doc *Document, routes chi.Routes) []string {
+	var missing []string
+	chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if route == "" {
+			route = "/"
+		}
+		if !doc.HasRoute(method, route) {
+			missing = append(missing, method+" "+route)
+		}
+		return nil
+	})
+	return missing
+}
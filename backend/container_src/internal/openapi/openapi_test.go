@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpecIsUpToDate(t *testing.T) {
+	generated, err := Spec().MarshalIndent()
+	if err != nil {
+		t.Fatalf("failed to marshal spec: %v", err)
+	}
+
+	if !bytes.Equal(generated, SpecJSON()) {
+		t.Fatal("openapi.json is out of date with the view types, run: go generate ./internal/openapi")
+	}
+}
+
+func TestViewStructsMatchSpecSchemas(t *testing.T) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+				Required   []string       `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(SpecJSON(), &doc); err != nil {
+		t.Fatalf("failed to parse openapi.json: %v", err)
+	}
+
+	for _, typ := range viewStructs(t) {
+		schema, ok := doc.Components.Schemas[typ.Name()]
+		if !ok {
+			t.Errorf("%s is missing from the spec", typ.Name())
+			continue
+		}
+
+		var fields, required []string
+		for _, f := range Fields(typ) {
+			fields = append(fields, f.Name)
+			if !f.Optional {
+				required = append(required, f.Name)
+			}
+		}
+
+		var properties []string
+		for name := range schema.Properties {
+			properties = append(properties, name)
+		}
+
+		sort.Strings(fields)
+		sort.Strings(required)
+		sort.Strings(properties)
+		sort.Strings(schema.Required)
+		if !reflect.DeepEqual(fields, properties) {
+			t.Errorf("%s fields %v drifted from spec properties %v", typ.Name(), fields, properties)
+		}
+		if len(required) > 0 && !reflect.DeepEqual(required, schema.Required) {
+			t.Errorf("%s required fields %v drifted from spec %v", typ.Name(), required, schema.Required)
+		}
+	}
+}
+
+func viewStructs(t *testing.T) []reflect.Type {
+	t.Helper()
+
+	seen := make(map[reflect.Type]bool)
+	var types []reflect.Type
+	var visit func(reflect.Type)
+	visit = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) || seen[typ] {
+			return
+		}
+
+		seen[typ] = true
+		types = append(types, typ)
+		for _, f := range Fields(typ) {
+			visit(f.Type)
+		}
+	}
+
+	for _, op := range Routes() {
+		for _, r := range op.Responses {
+			if r.Body != nil {
+				visit(reflect.TypeOf(r.Body))
+			}
+		}
+	}
+
+	if len(types) == 0 {
+		t.Fatal("no view structs referenced by routes")
+	}
+	return types
+}
+
+func TestDocsAreServedFromTheBinary(t *testing.T) {
+	rec := httptest.NewRecorder()
+	DocsHandler(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /docs = %d", rec.Code)
+	}
+	page := rec.Body.String()
+	if strings.Contains(page, "https://") {
+		t.Error("docs page loads assets from another origin")
+	}
+
+	for _, asset := range []string{"/docs/docs.js", "/docs/docs.css"} {
+		if !strings.Contains(page, asset) {
+			t.Errorf("docs page does not reference %s", asset)
+		}
+		rec := httptest.NewRecorder()
+		DocsAssetHandler(rec, httptest.NewRequest(http.MethodGet, asset, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s = %d with %d bytes", asset, rec.Code, rec.Body.Len())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	DocsAssetHandler(rec, httptest.NewRequest(http.MethodGet, "/docs/index.go", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /docs/index.go = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,323 @@
+package openapi
+
+import (
+	"embed"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"server/internal/view"
+)
+
+//go:generate go run ../../cmd/openapi -o openapi.json
+
+//go:embed openapi.json
+var specJSON []byte
+
+// docs is a small viewer for the spec, served from this binary so the
+// documentation works offline and under a same-origin Content-Security-Policy.
+//
+//go:embed docs
+var docs embed.FS
+
+var docsTypes = map[string]string{
+	".html": "text/html; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+	".css":  "text/css; charset=utf-8",
+}
+
+const (
+	title      = "StarSeek API"
+	apiVersion = "1.0.0"
+)
+
+func Spec() *Document {
+	return Build(title, apiVersion, Routes())
+}
+
+func SpecJSON() []byte {
+	return specJSON
+}
+
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	serveDocsFile(w, r, "index.html")
+}
+
+// DocsAssetHandler serves the viewer's scripts and styles from /docs/{file}.
+func DocsAssetHandler(w http.ResponseWriter, r *http.Request) {
+	serveDocsFile(w, r, path.Base(r.URL.Path))
+}
+
+func serveDocsFile(w http.ResponseWriter, r *http.Request, name string) {
+	contentType, ok := docsTypes[path.Ext(name)]
+	data, err := docs.ReadFile("docs/" + name)
+	if !ok || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", "default-src 'self'")
+	w.Write(data)
+}
+
+func Routes() []Operation {
+	ops := []Operation{
+		{
+			Method: http.MethodGet, Path: "/", OperationID: "getRoot", Summary: "Service banner", Tag: "meta",
+			Responses: []Response{{Status: 200, Description: "Plain text banner", ContentType: "text/plain", Body: ""}},
+		},
+		{
+			Method: http.MethodGet, Path: "/health", OperationID: "getHealth", Summary: "Liveness probe", Tag: "meta",
+			Responses: []Response{{Status: 200, Description: "Service is up", ContentType: "text/plain", Body: ""}},
+		},
+		{
+			Method: http.MethodGet, Path: "/openapi.json", OperationID: "getOpenAPI", Summary: "This document", Tag: "meta",
+			Responses: []Response{{Status: 200, Description: "OpenAPI document", Body: map[string]any{}}},
+		},
+		{
+			Method: http.MethodGet, Path: "/docs", OperationID: "getDocs", Summary: "Interactive API documentation", Tag: "meta",
+			Responses: []Response{{Status: 200, Description: "HTML documentation", ContentType: "text/html", Body: ""}},
+		},
+		{
+			Method: http.MethodGet, Path: "/docs/{file}", OperationID: "getDocsAsset", Summary: "Script or stylesheet for the documentation page", Tag: "meta",
+			Params: []Param{{Name: "file", In: "path", Description: "docs.js or docs.css", Type: "string"}},
+			Responses: []Response{
+				{Status: 200, Description: "Documentation asset", ContentType: "text/javascript", Body: ""},
+				{Status: 404, Description: "No such asset", ContentType: "text/plain", Body: ""},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/admin/keys", OperationID: "createAPIKey", Summary: "Create an API key (admin scope)", Tag: "admin",
+			Body:      view.CreateAPIKeyRequest{},
+			Responses: withErrors(Response{Status: 201, Description: "Created key; the raw key is only returned once", Body: view.APIKeyResponse{}}),
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/admin/keys/{id}", OperationID: "revokeAPIKey", Summary: "Revoke an API key (admin scope)", Tag: "admin",
+			Params:    []Param{{Name: "id", In: "path", Description: "Key ID", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "Revoked key", Body: view.APIKeyResponse{}}),
+		},
+		{
+			Method: http.MethodPost, Path: "/api/admin/devices/{id}/revoke", OperationID: "revokeDevice", Summary: "Revoke a device token (admin scope)", Tag: "admin",
+			Params:    []Param{{Name: "id", In: "path", Description: "Device ID", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "Device revoked", Body: view.DeviceRevocationResponse{}}),
+		},
+		{
+			Method: http.MethodPost, Path: "/api/admin/device-keys/rotate", OperationID: "rotateDeviceKeys", Summary: "Rotate the device token signing key (admin scope)", Tag: "admin",
+			Responses: withErrors(Response{Status: 200, Description: "New active signing key", Body: view.DeviceKeyRotationResponse{}}),
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/admin/device-keys/{kid}", OperationID: "retireDeviceKey",
+			Summary: "Retire a rotated-out device token signing key; tokens it signed stop verifying (admin scope)", Tag: "admin",
+			Params:    []Param{{Name: "kid", In: "path", Description: "Signing key ID", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "Key retired", Body: view.DeviceKeyRetirementResponse{}}),
+		},
+		{
+			Method: http.MethodPost, Path: "/api/admin/minor-bodies", OperationID: "refreshMinorBodies",
+			Summary: "Replace the comet and asteroid orbital elements (admin scope)", Tag: "admin",
+			Form: []FormField{
+				{Name: "file", Description: "MPC one-line elements: MPCORB.DAT and/or CometEls.txt lines", Binary: true, Required: true},
+			},
+			Responses: withErrors(Response{Status: 200, Description: "Bodies loaded from the upload", Body: view.MinorBodyRefreshResponse{}}),
+		},
+		{
+			Method: http.MethodGet, Path: "/api/admin/astrometry-session", OperationID: "getAstrometrySession",
+			Summary: "Astrometry.net session diagnostics (admin scope)", Tag: "admin",
+			Responses: withErrors(Response{Status: 200, Description: "Current Nova session state", Body: view.SessionState{}}),
+		},
+	}
+
+	ops = append(ops, apiOperations(apiVersions[0], "/api", "")...)
+	for _, v := range apiVersions {
+		ops = append(ops, apiOperations(v, "/api/"+v.name, strings.ToUpper(v.name))...)
+	}
+	return ops
+}
+
+type apiVersionBodies struct {
+	name       string
+	deprecated bool
+	// baselineOnly keeps a version to the endpoints it shipped with.
+	baselineOnly bool
+	jobStatus    any
+	objectDetail any
+}
+
+var apiVersions = []apiVersionBodies{
+	{name: "v1", deprecated: true, baselineOnly: true, jobStatus: view.JobStatusResponse{}, objectDetail: view.ObjectDetailResponse{}},
+	{name: "v2", jobStatus: view.JobStatusResponseV2{}, objectDetail: view.ObjectDetailResponseV2{}},
+}
+
+func apiOperations(v apiVersionBodies, prefix, suffix string) []Operation {
+	annotatedParams := []Param{
+		{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"},
+		{Name: "types", In: "query", Description: "Comma-separated object types to mark: star, nebula, galaxy, cluster, sun, planet, moon, comet, asteroid. Defaults to all"},
+		{Name: "labelSize", In: "query", Description: "small, medium (default) or large"},
+		{Name: "theme", In: "query", Description: "default (colours by type), red or mono"},
+	}
+	listParams := []Param{
+		{Name: "format", In: "query", Description: "skylist (SkySafari), stellarium, astroplanner, csv (default) or jsonl"},
+		{Name: "columns", In: "query", Description: "Comma-separated csv and jsonl columns: name, displayName, type, constellation, ra, dec, magnitude, size. Defaults to all"},
+	}
+	tonightParams := []Param{
+		{Name: "lat", In: "query", Description: "Observer latitude in degrees", Required: true, Type: "number"},
+		{Name: "lon", In: "query", Description: "Observer longitude in degrees, east positive", Required: true, Type: "number"},
+		{Name: "date", In: "query", Description: "Evening date, YYYY-MM-DD. Defaults to the night in progress or coming up"},
+		{Name: "limitMag", In: "query", Description: "Faintest magnitude to include", Type: "number"},
+		{Name: "minAlt", In: "query", Description: "Minimum altitude in degrees (default 30)", Type: "number"},
+		{Name: "limit", In: "query", Description: "Objects per type, 1-50 (default 10)", Type: "integer"},
+	}
+	ops := []Operation{
+		{
+			Method: http.MethodPost, Path: prefix + "/solve", OperationID: "submitImage" + suffix, Summary: "Submit an image for plate solving", Tag: "solve",
+			Form: []FormField{
+				{Name: "image", Description: "Image in any format Nova accepts (JPEG, PNG, GIF, TIFF, FITS, ...)", Binary: true, Required: true},
+				{Name: "capturedAt", Description: "Capture time, RFC 3339. Defaults to the EXIF DateTimeOriginal or GPS time"},
+				{Name: "latitude", Description: "Observer latitude in degrees. Defaults to the EXIF GPS position", Type: "number"},
+				{Name: "longitude", Description: "Observer longitude in degrees, east positive", Type: "number"},
+				{Name: "elevation", Description: "Observer elevation in metres", Type: "number"},
+				{Name: "telescope", Description: "Telescope or lens. Defaults to the EXIF lens model"},
+				{Name: "focalLength", Description: "Focal length in millimetres", Type: "number"},
+				{Name: "camera", Description: "Camera. Defaults to the EXIF make and model"},
+			},
+			Responses: withErrors(Response{Status: 200, Description: "Submission accepted", Body: view.SolveResponse{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}", OperationID: "getSolveStatus" + suffix, Summary: "Poll a plate solving job", Tag: "solve",
+			Params:    []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "Job status and result; the observer location and object altitudes are included only for the device or key that submitted the job", Body: v.jobStatus}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/grid", OperationID: "getSolveGrid" + suffix, Summary: "RA/Dec grid lines over a solved image", Tag: "solve",
+			Params: []Param{
+				{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"},
+				{Name: "density", In: "query", Description: "sparse, normal (default) or dense"},
+			},
+			Responses: withErrors(Response{Status: 200, Description: "Grid lines as pixel polylines with labels", Body: view.GridResponse{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/chart.svg", OperationID: "getSolveChart" + suffix, Summary: "Printable finder chart around a solved field", Tag: "solve",
+			Params:    []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "SVG chart with a detail and a context panel, north up", ContentType: "image/svg+xml", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/wcs.fits", OperationID: "getSolveWCSFITS" + suffix, Summary: "Plate solution as a header-only FITS file", Tag: "solve",
+			Params:    []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "TAN-SIP WCS header, with DATE-OBS and, for the job's owner, observer location when known", ContentType: "application/fits", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/wcs.txt", OperationID: "getSolveWCSText" + suffix, Summary: "Plate solution as plain-text FITS header cards", Tag: "solve",
+			Params:    []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "The wcs.fits cards, one per line", ContentType: "text/plain", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/image-with-avm.jpg", OperationID: "getSolveImageWithAVM" + suffix, Summary: "Original image with embedded AVM astrometry metadata", Tag: "solve",
+			Params: []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{
+				Status: 200, Description: "JPEG with an XMP packet of AVM Spatial.* and Subject.Name tags; EXIF is preserved for the job's owner and dropped for anyone else",
+				ContentType: "image/jpeg", Body: []byte{},
+			}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/sky.kml", OperationID: "getSolveKML" + suffix, Summary: "Google Earth Sky-mode overlay of a solved image", Tag: "solve",
+			Params: []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{
+				Status: 200, Description: "KML GroundOverlay with a placemark per object; the image is expected alongside as image.jpg",
+				ContentType: "application/vnd.google-earth.kml+xml", Body: []byte{},
+			}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/sky.kmz", OperationID: "getSolveKMZ" + suffix, Summary: "Google Earth Sky-mode overlay with the image included", Tag: "solve",
+			Params:    []Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "KMZ archive of the KML and the image with AVM metadata; EXIF is preserved for the job's owner and dropped for anyone else", ContentType: "application/vnd.google-earth.kmz", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/list", OperationID: "getSolveList" + suffix, Summary: "Identified objects as an observing list for planetarium software", Tag: "solve",
+			Params:    append([]Param{{Name: "jobId", In: "path", Description: "Job ID returned by submitImage", Type: "string"}}, listParams...),
+			Responses: withErrors(Response{Status: 200, Description: "Observing list file in the requested format", ContentType: "text/plain", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/annotated.png", OperationID: "getSolveAnnotatedPNG" + suffix, Summary: "Solved image with objects, compass and scale bar drawn on it", Tag: "solve",
+			Params:    annotatedParams,
+			Responses: withErrors(Response{Status: 200, Description: "Annotated PNG", ContentType: "image/png", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/solve/{jobId}/annotated.jpg", OperationID: "getSolveAnnotatedJPEG" + suffix, Summary: "Solved image with objects, compass and scale bar drawn on it", Tag: "solve",
+			Params:    annotatedParams,
+			Responses: withErrors(Response{Status: 200, Description: "Annotated JPEG", ContentType: "image/jpeg", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/object/{name}", OperationID: "getObjectDetail" + suffix, Summary: "Look up a catalog object", Tag: "object",
+			Params:    []Param{{Name: "name", In: "path", Description: "Catalog name, e.g. M42 or Vega", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "Object detail with a fun fact", Body: v.objectDetail}),
+		},
+		{
+			Method: http.MethodPost, Path: prefix + "/devices", OperationID: "registerDevice" + suffix,
+			Summary: "Register an anonymous device, or refresh the token of the calling device; a token that expired within the grace period (90 days by default) can still be refreshed", Tag: "device",
+			Responses: withErrors(
+				Response{Status: 201, Description: "New device token", Body: view.DeviceRegistrationResponse{}},
+				Response{Status: 200, Description: "Refreshed token for the calling device", Body: view.DeviceRegistrationResponse{}},
+			),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/tonight", OperationID: "getTonight" + suffix,
+			Summary: "Recommend catalog objects to observe tonight, grouped by type", Tag: "object",
+			Params:    tonightParams,
+			Responses: withErrors(Response{Status: 200, Description: "Dark window, Moon and ranked objects. On nights the Sun stays within 18° of the horizon noAstronomicalDarkness is set and there is no window or objects", Body: view.TonightResponse{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/tonight/list", OperationID: "getTonightList" + suffix,
+			Summary: "Tonight's recommendations as an observing list for planetarium software", Tag: "object",
+			Params:    append(slices.Clone(tonightParams), listParams...),
+			Responses: withErrors(Response{Status: 200, Description: "Observing list file in the requested format", ContentType: "text/plain", Body: []byte{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/history", OperationID: "listHistory" + suffix,
+			Summary: "List the calling device's solves, newest first", Tag: "history",
+			Params: []Param{
+				{Name: "cursor", In: "query", Description: "nextCursor from the previous page"},
+				{Name: "limit", In: "query", Description: "Page size, 1-100 (default 20)", Type: "integer"},
+				{Name: "from", In: "query", Description: "Earliest submission time, RFC 3339 or YYYY-MM-DD"},
+				{Name: "to", In: "query", Description: "Latest submission time, RFC 3339 or YYYY-MM-DD (inclusive)"},
+				{Name: "object", In: "query", Description: "Only solves containing this object, e.g. M42"},
+				{Name: "constellation", In: "query", Description: "Only solves containing an object in this constellation"},
+			},
+			Responses: withErrors(Response{Status: 200, Description: "A page of history entries", Body: view.HistoryPage{}}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/history/{id}", OperationID: "getHistoryEntry" + suffix, Summary: "Get one history entry with its full result", Tag: "history",
+			Params:    []Param{{Name: "id", In: "path", Description: "Job ID", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "History entry", Body: view.HistoryDetail{}}),
+		},
+		{
+			Method: http.MethodDelete, Path: prefix + "/history/{id}", OperationID: "deleteHistoryEntry" + suffix, Summary: "Delete a history entry and its stored images", Tag: "history",
+			Params:    []Param{{Name: "id", In: "path", Description: "Job ID", Type: "string"}},
+			Responses: withErrors(Response{Status: 204, Description: "Deleted"}),
+		},
+		{
+			Method: http.MethodGet, Path: prefix + "/history/{id}/thumbnail.jpg", OperationID: "getHistoryThumbnail" + suffix, Summary: "Thumbnail of the submitted image", Tag: "history",
+			Params:    []Param{{Name: "id", In: "path", Description: "Job ID", Type: "string"}},
+			Responses: withErrors(Response{Status: 200, Description: "JPEG thumbnail", ContentType: "image/jpeg", Body: []byte{}}),
+		},
+	}
+
+	if v.baselineOnly {
+		ops = slices.DeleteFunc(ops, func(op Operation) bool {
+			return !slices.Contains(baselinePaths, strings.TrimPrefix(op.Path, prefix))
+		})
+	}
+	for i := range ops {
+		ops[i].Deprecated = v.deprecated
+	}
+	return ops
+}
+
+// baselinePaths are the endpoints the unversioned API had before /api/v2.
+var baselinePaths = []string{"/solve", "/solve/{jobId}", "/object/{name}"}
+
+func withErrors(responses ...Response) []Response {
+	return append(responses,
+		Response{Status: 0, Description: "Error", Body: view.ErrorResponse{}},
+		Response{Status: 0, Description: "Error", ContentType: "application/problem+json", Body: view.Problem{}},
+	)
+}
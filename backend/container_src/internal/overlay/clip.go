@@ -0,0 +1,40 @@
+// Package overlay computes vector overlays, such as coordinate grids, in
+// the pixel space of a plate-solved image.
+package overlay
+
+// Point is a position in image pixels, measured from the centre of the
+// top-left pixel.
+type Point struct {
+	X, Y float64
+}
+
+// ClipLine clips the segment a-b to the rectangle min-max (Liang-Barsky),
+// reporting whether any of it is inside.
+func ClipLine(a, b, minPt, maxPt Point) (Point, Point, bool) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t0, t1 := 0.0, 1.0
+	for _, edge := range [][2]float64{
+		{-dx, a.X - minPt.X},
+		{dx, maxPt.X - a.X},
+		{-dy, a.Y - minPt.Y},
+		{dy, maxPt.Y - a.Y},
+	} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return Point{}, Point{}, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = max(t0, t)
+		} else {
+			t1 = min(t1, t)
+		}
+		if t0 > t1 {
+			return Point{}, Point{}, false
+		}
+	}
+	return Point{a.X + t0*dx, a.Y + t0*dy}, Point{a.X + t1*dx, a.Y + t1*dy}, true
+}
@@ -0,0 +1,279 @@
+package overlay
+
+import (
+	"fmt"
+	"math"
+
+	"server/internal/wcs"
+)
+
+// Density sets how many grid lines cross the field.
+type Density string
+
+const (
+	DensitySparse Density = "sparse"
+	DensityNormal Density = "normal"
+	DensityDense  Density = "dense"
+)
+
+// linesAcross is roughly how many lines of each family cross the longer
+// side of the image.
+var linesAcross = map[Density]float64{
+	DensitySparse: 3,
+	DensityNormal: 6,
+	DensityDense:  12,
+}
+
+// ParseDensity reads a density name; empty means DensityNormal.
+func ParseDensity(s string) (Density, bool) {
+	if s == "" {
+		return DensityNormal, true
+	}
+	_, ok := linesAcross[Density(s)]
+	return Density(s), ok
+}
+
+type Axis string
+
+const (
+	AxisRA  Axis = "ra"
+	AxisDec Axis = "dec"
+)
+
+// Grid is a coordinate grid over an image. Steps are in degrees.
+type Grid struct {
+	RAStep  float64
+	DecStep float64
+	Lines   []GridLine
+}
+
+// GridLine is a line of constant right ascension or declination. A line
+// can leave the image and come back, so it may be in several pieces.
+// LabelAt is where it meets the bottom (RA) or left (Dec) side.
+type GridLine struct {
+	Axis      Axis
+	Value     float64
+	Label     string
+	LabelAt   Point
+	Polylines [][]Point
+}
+
+// Grid steps, smallest first: declination in arcseconds, right ascension
+// in seconds of time.
+var (
+	decSteps = []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600, 900, 1800, 3600, 7200, 18000, 36000, 54000, 108000}
+	raSteps  = []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600, 900, 1200, 1800, 3600, 7200}
+)
+
+const (
+	edgeSamples = 64
+	lineSamples = 120
+)
+
+// NewGrid computes RA and Dec grid lines over the plate solution's image at
+// round intervals suited to the field size.
+func NewGrid(field *wcs.WCS, density Density) *Grid {
+	width, height := float64(field.ImageWidth), float64(field.ImageHeight)
+	imageMin, imageMax := Point{-0.5, -0.5}, Point{width - 0.5, height - 0.5}
+	bounds := extent(field)
+
+	fov := math.Max(width, height) * field.PixelScale() / 3600
+	target := fov / linesAcross[density]
+	decStep := niceStep(target*3600, decSteps) / 3600
+	// Meridians converge, so the RA step widens with declination; it
+	// stays at the coarsest step near a pole.
+	cosDec := math.Max(math.Cos(bounds.centerDec*math.Pi/180), 0.1)
+	raStep := niceStep(target/cosDec*240, raSteps) / 240
+
+	grid := &Grid{RAStep: raStep, DecStep: decStep}
+	add := func(axis Axis, value float64, label string, at func(t float64) (float64, float64)) {
+		polylines := trace(field, at, imageMin, imageMax)
+		if len(polylines) == 0 {
+			return
+		}
+		grid.Lines = append(grid.Lines, GridLine{
+			Axis:      axis,
+			Value:     value,
+			Label:     label,
+			LabelAt:   labelAnchor(polylines, axis),
+			Polylines: polylines,
+		})
+	}
+
+	// Lines are stepped by index so values stay exact multiples of the step.
+	for i := math.Ceil(bounds.raMin / raStep); i*raStep <= bounds.raMax && i*raStep < bounds.raMin+360; i++ {
+		value := normalize(i * raStep)
+		add(AxisRA, value, formatRA(value, raStep), func(t float64) (float64, float64) {
+			return value, bounds.decMin + t*(bounds.decMax-bounds.decMin)
+		})
+	}
+	for i := math.Ceil(bounds.decMin / decStep); i*decStep <= bounds.decMax; i++ {
+		value := i * decStep
+		if math.Abs(value) >= 90 {
+			continue
+		}
+		add(AxisDec, value, formatDec(value, decStep), func(t float64) (float64, float64) {
+			return normalize(bounds.raMin + t*(bounds.raMax-bounds.raMin)), value
+		})
+	}
+	return grid
+}
+
+// trace samples a coordinate line, parameterised by t from 0 to 1, and
+// returns the pieces of it inside the image.
+func trace(field *wcs.WCS, at func(t float64) (float64, float64), imageMin, imageMax Point) [][]Point {
+	var polylines [][]Point
+	var prev Point
+	prevOK, joined := false, false
+	for i := 0; i <= lineSamples; i++ {
+		ra, dec := at(float64(i) / lineSamples)
+		x, y, ok := field.SkyToPixel(ra, dec)
+		p := Point{x, y}
+
+		if ok && prevOK {
+			a, b, in := ClipLine(prev, p, imageMin, imageMax)
+			switch {
+			case !in:
+				joined = false
+			case joined:
+				polylines[len(polylines)-1] = append(polylines[len(polylines)-1], b)
+			default:
+				polylines = append(polylines, []Point{a, b})
+			}
+			// The next piece continues this one only if p is inside.
+			joined = in && b == p
+		} else {
+			joined = false
+		}
+		prev, prevOK = p, ok
+	}
+	return polylines
+}
+
+// labelAnchor picks the end of the line nearest the bottom of the image for
+// RA lines, or nearest the left for Dec lines.
+func labelAnchor(polylines [][]Point, axis Axis) Point {
+	var best Point
+	first := true
+	for _, line := range polylines {
+		for _, p := range []Point{line[0], line[len(line)-1]} {
+			better := p.X < best.X
+			if axis == AxisRA {
+				better = p.Y > best.Y
+			}
+			if first || better {
+				best, first = p, false
+			}
+		}
+	}
+	return best
+}
+
+type bounds struct {
+	// RA limits are continuous across 0h, so raMax may exceed 360.
+	raMin, raMax   float64
+	decMin, decMax float64
+	centerDec      float64
+}
+
+// extent finds the range of coordinates the image spans by walking its
+// edges; a celestial pole inside the image takes in every right ascension.
+func extent(field *wcs.WCS) bounds {
+	width, height := float64(field.ImageWidth), float64(field.ImageHeight)
+	centerRA, centerDec := field.PixelToSky((width-1)/2, (height-1)/2)
+	b := bounds{raMin: centerRA, raMax: centerRA, decMin: centerDec, decMax: centerDec, centerDec: centerDec}
+
+	visit := func(x, y float64) {
+		ra, dec := field.PixelToSky(x, y)
+		offset := math.Remainder(ra-centerRA, 360)
+		b.raMin = math.Min(b.raMin, centerRA+offset)
+		b.raMax = math.Max(b.raMax, centerRA+offset)
+		b.decMin = math.Min(b.decMin, dec)
+		b.decMax = math.Max(b.decMax, dec)
+	}
+	for i := 0; i <= edgeSamples; i++ {
+		t := float64(i) / edgeSamples
+		visit(t*width-0.5, -0.5)
+		visit(t*width-0.5, height-0.5)
+		visit(-0.5, t*height-0.5)
+		visit(width-0.5, t*height-0.5)
+	}
+
+	for _, pole := range []float64{90, -90} {
+		if x, y, ok := field.SkyToPixel(0, pole); ok && field.Contains(x, y) {
+			b.raMin, b.raMax = 0, 360
+			if pole > 0 {
+				b.decMax = 90
+			} else {
+				b.decMin = -90
+			}
+		}
+	}
+	return b
+}
+
+// niceStep returns the smallest step not below target, or the largest.
+func niceStep(target float64, steps []float64) float64 {
+	for _, step := range steps {
+		if step >= target {
+			return step
+		}
+	}
+	return steps[len(steps)-1]
+}
+
+func normalize(ra float64) float64 {
+	ra = math.Mod(ra, 360)
+	if ra < 0 {
+		ra += 360
+	}
+	return ra
+}
+
+// formatRA writes a right ascension to the precision of the grid step,
+// e.g. "05h", "05h30m" or "05h30m15s".
+func formatRA(ra, step float64) string {
+	unit := 1
+	switch {
+	case step >= 15:
+		unit = 3600
+	case step >= 0.25:
+		unit = 60
+	}
+	seconds := int(math.Round(ra*240/float64(unit))) * unit % 86400
+	h, m, s := seconds/3600, seconds/60%60, seconds%60
+	switch unit {
+	case 3600:
+		return fmt.Sprintf("%02dh", h)
+	case 60:
+		return fmt.Sprintf("%02dh%02dm", h, m)
+	default:
+		return fmt.Sprintf("%02dh%02dm%02ds", h, m, s)
+	}
+}
+
+// formatDec writes a declination to the precision of the grid step, e.g.
+// "+22°", "+22°30′" or "-05°10′30″".
+func formatDec(dec, step float64) string {
+	unit := 1
+	switch {
+	case step >= 1:
+		unit = 3600
+	case step >= 1.0/60:
+		unit = 60
+	}
+	arcsec := int(math.Round(math.Abs(dec)*3600/float64(unit))) * unit
+	sign := "+"
+	if dec < 0 && arcsec > 0 {
+		sign = "-"
+	}
+	d, m, s := arcsec/3600, arcsec/60%60, arcsec%60
+	switch unit {
+	case 3600:
+		return fmt.Sprintf("%s%02d°", sign, d)
+	case 60:
+		return fmt.Sprintf("%s%02d°%02d′", sign, d, m)
+	default:
+		return fmt.Sprintf("%s%02d°%02d′%02d″", sign, d, m, s)
+	}
+}
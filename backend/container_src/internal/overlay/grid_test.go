@@ -0,0 +1,105 @@
+package overlay
+
+import (
+	"math"
+	"testing"
+
+	"server/internal/wcs"
+)
+
+// tan returns a plain gnomonic solution centred on the image, north up and
+// east left.
+func tan(ra, dec, scale float64, width, height int) *wcs.WCS {
+	return &wcs.WCS{
+		CRVAL:       [2]float64{ra, dec},
+		CRPIX:       [2]float64{float64(width+1) / 2, float64(height+1) / 2},
+		CD:          [2][2]float64{{-scale, 0}, {0, scale}},
+		ImageWidth:  width,
+		ImageHeight: height,
+	}
+}
+
+func TestGridOrion(t *testing.T) {
+	field := tan(83.8, -1, 0.01, 1200, 800) // 12° × 8°
+	grid := NewGrid(field, DensityNormal)
+
+	if grid.DecStep != 2 {
+		t.Errorf("DecStep = %v, want 2", grid.DecStep)
+	}
+	if grid.RAStep != 2.5 {
+		t.Errorf("RAStep = %v, want 2.5 (10m)", grid.RAStep)
+	}
+
+	labels := make(map[string]bool)
+	for _, line := range grid.Lines {
+		labels[line.Label] = true
+		for _, poly := range line.Polylines {
+			for _, p := range poly {
+				if p.X < -0.5-1e-9 || p.Y < -0.5-1e-9 || p.X > 1199.5+1e-9 || p.Y > 799.5+1e-9 {
+					t.Fatalf("%s: point %v outside the image", line.Label, p)
+				}
+				ra, dec := field.PixelToSky(p.X, p.Y)
+				var off float64
+				if line.Axis == AxisRA {
+					off = math.Abs(math.Remainder(ra-line.Value, 360)) * math.Cos(dec*math.Pi/180)
+				} else {
+					off = math.Abs(dec - line.Value)
+				}
+				if off > 1e-6 {
+					t.Fatalf("%s: point %v is %.2g° off the line", line.Label, p, off)
+				}
+			}
+		}
+	}
+	for _, want := range []string{"05h30m", "05h40m", "+00°", "-02°", "+02°"} {
+		if !labels[want] {
+			t.Errorf("missing line %q; have %v", want, labels)
+		}
+	}
+}
+
+func TestGridAroundPole(t *testing.T) {
+	grid := NewGrid(tan(0, 90, 0.02, 1000, 1000), DensityNormal)
+
+	meridians := 0
+	for _, line := range grid.Lines {
+		if line.Axis == AxisRA {
+			meridians++
+		}
+		if line.Axis == AxisDec && line.Value >= 90 {
+			t.Error("grid has a line at the pole")
+		}
+	}
+	if meridians != 12 {
+		t.Errorf("%d meridians around the pole, want 12", meridians)
+	}
+}
+
+func TestGridAcrossZeroHours(t *testing.T) {
+	grid := NewGrid(tan(0.5, 20, 0.002, 1000, 1000), DensityNormal)
+	labels := make(map[string]bool)
+	for _, line := range grid.Lines {
+		labels[line.Label] = true
+	}
+	if !labels["00h00m"] || !labels["23h58m"] {
+		t.Errorf("labels %v do not span 0h", labels)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct{ got, want string }{
+		{formatRA(82.5, 2.5), "05h30m"},
+		{formatRA(30, 30), "02h"},
+		{formatRA(82.5625, 1.0/240*15), "05h30m15s"},
+		{formatRA(359.9999, 2.5), "00h00m"},
+		{formatDec(22, 2), "+22°"},
+		{formatDec(-5.175, 5.0/60), "-05°11′"},
+		{formatDec(-5.175, 1.0/3600*10), "-05°10′30″"},
+		{formatDec(-1e-12, 1), "+00°"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
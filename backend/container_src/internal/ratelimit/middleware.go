@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/internal/apperr"
+	"server/internal/auth"
+	"server/internal/device"
+)
+
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, err error)
+
+type Limiter struct {
+	store Store
+	// fallback keeps limits in force on this instance while the shared
+	// store is failing.
+	fallback *MemoryStore
+	onError  ErrorWriter
+	now      func() time.Time
+}
+
+func NewLimiter(store Store, onError ErrorWriter) *Limiter {
+	return &Limiter{store: store, fallback: NewMemoryStore(), onError: onError, now: time.Now}
+}
+
+func (l *Limiter) Middleware(rule Rule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := rule.Name + ":" + Identity(r)
+			result, err := l.store.Take(r.Context(), key, rule, l.now())
+			if err != nil {
+				log.Printf("Rate limit store failed for %s, using a local bucket: %v", key, err)
+				result, _ = l.fallback.Take(r.Context(), key, rule, l.now())
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(rule.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+			h.Set("RateLimit-Policy", rule.Policy())
+
+			if !result.Allowed {
+				retryAfter := int(result.RetryAfter.Seconds())
+				h.Set("Retry-After", strconv.Itoa(retryAfter))
+				l.onError(w, r, apperr.ErrRateLimited.WithDetail("retryAfterSeconds", retryAfter))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Identity names the bucket a request spends from. An app's API key is
+// shared by all of its users, so a verified device gets a bucket of its own
+// under the key; the key alone, or the client IP, is the fallback. Only a
+// device ID from a verified token counts, since a raw header could be
+// rotated to dodge the limit.
+func Identity(r *http.Request) string {
+	key, hasKey := auth.FromContext(r.Context())
+	deviceID, hasDevice := device.FromContext(r.Context())
+	switch {
+	case hasKey && hasDevice:
+		return "key:" + key.ID + ":device:" + deviceID
+	case hasDevice:
+		return "device:" + deviceID
+	case hasKey:
+		return "key:" + key.ID
+	}
+	if ip := r.Header.Get("CF-Connecting-IP"); ip != "" {
+		return "ip:" + ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Rule struct {
+	Name   string
+	Rate   float64
+	Burst  int
+	Window time.Duration
+}
+
+// ParseRule reads "<count>/<s|m|h>[:burst]", e.g. "10/m:20".
+func ParseRule(name, spec string) (Rule, error) {
+	rate, burstSpec, hasBurst := strings.Cut(spec, ":")
+	countSpec, unit, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate limit %q for %s", spec, name)
+	}
+
+	count, err := strconv.Atoi(countSpec)
+	if err != nil || count <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit count %q for %s", countSpec, name)
+	}
+
+	var window time.Duration
+	switch unit {
+	case "s":
+		window = time.Second
+	case "m":
+		window = time.Minute
+	case "h":
+		window = time.Hour
+	default:
+		return Rule{}, fmt.Errorf("invalid rate limit unit %q for %s", unit, name)
+	}
+
+	burst := count
+	if hasBurst {
+		if burst, err = strconv.Atoi(burstSpec); err != nil || burst <= 0 {
+			return Rule{}, fmt.Errorf("invalid rate limit burst %q for %s", burstSpec, name)
+		}
+	}
+
+	return Rule{
+		Name:   name,
+		Rate:   float64(count) / window.Seconds(),
+		Burst:  burst,
+		Window: window,
+	}, nil
+}
+
+func (r Rule) Policy() string {
+	count := int(math.Round(r.Rate * r.Window.Seconds()))
+	return fmt.Sprintf("%d;w=%d;burst=%d", count, int(r.Window.Seconds()), r.Burst)
+}
@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+// refill adds the tokens earned since the bucket was last used; a new
+// bucket starts full.
+func (b *bucket) refill(rule Rule, now time.Time) {
+	if b.Last.IsZero() {
+		b.Tokens = float64(rule.Burst)
+	} else if elapsed := now.Sub(b.Last).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(rule.Burst), b.Tokens+elapsed*rule.Rate)
+	}
+	if now.After(b.Last) {
+		b.Last = now
+	}
+}
+
+func (b *bucket) take(rule Rule, now time.Time) Result {
+	b.refill(rule, now)
+
+	result := Result{}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / rule.Rate)
+	}
+
+	result.Remaining = int(b.Tokens)
+	result.Reset = seconds((float64(rule.Burst) - b.Tokens) / rule.Rate)
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPrune) > time.Minute {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{}
+		s.buckets[key] = b
+	}
+	return b.take(rule, now), nil
+}
+
+func (s *MemoryStore) prune(now time.Time) {
+	s.lastPrune = now
+	for key, b := range s.buckets {
+		if now.Sub(b.Last) > time.Hour {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+type KVClient interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	PutWithTTL(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// SyncInterval is how often KVStore exchanges buckets with KV. Cloudflare
+// KV takes at most one write per key per second.
+const SyncInterval = 5 * time.Second
+
+// KVStore shares buckets between container instances through Cloudflare KV
+// without putting KV on the request path. Requests spend tokens from local
+// buckets; Sync then folds each active bucket into the copy other instances
+// published and writes the result back, once per key per SyncInterval.
+// Limits across instances are therefore approximate: a client can overspend
+// by what it manages on each instance within one interval, and concurrent
+// syncs of the same key may drop one instance's spending.
+type KVStore struct {
+	kv    KVClient
+	local *MemoryStore
+
+	mu    sync.Mutex
+	spent map[string]spending
+}
+
+// spending is what a key has spent locally since the last sync.
+type spending struct {
+	rule   Rule
+	tokens float64
+}
+
+func NewKVStore(kv KVClient) *KVStore {
+	return &KVStore{kv: kv, local: NewMemoryStore(), spent: make(map[string]spending)}
+}
+
+func (s *KVStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	result, err := s.local.Take(ctx, key, rule, now)
+	if err != nil || !result.Allowed {
+		return result, err
+	}
+
+	s.mu.Lock()
+	entry := s.spent[key]
+	entry.rule = rule
+	entry.tokens++
+	s.spent[key] = entry
+	s.mu.Unlock()
+	return result, nil
+}
+
+// Run syncs every SyncInterval until ctx is done.
+func (s *KVStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Sync(ctx, now)
+		}
+	}
+}
+
+// Sync publishes the buckets spent from since the last call. A key that
+// fails to sync keeps its spending for the next attempt; the local bucket
+// goes on enforcing the limit meanwhile.
+func (s *KVStore) Sync(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	pending := s.spent
+	s.spent = make(map[string]spending)
+	s.mu.Unlock()
+
+	for key, entry := range pending {
+		if err := s.syncKey(ctx, key, entry, now); err != nil {
+			log.Printf("Rate limit sync failed for %s: %v", key, err)
+			s.mu.Lock()
+			retry := s.spent[key]
+			retry.rule = entry.rule
+			retry.tokens += entry.tokens
+			s.spent[key] = retry
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *KVStore) syncKey(ctx context.Context, key string, entry spending, now time.Time) error {
+	kvKey := "ratelimit:" + key
+	value, found, err := s.kv.Get(ctx, kvKey)
+	if err != nil {
+		return err
+	}
+
+	s.local.mu.Lock()
+	b, ok := s.local.buckets[key]
+	if !ok {
+		b = &bucket{}
+		s.local.buckets[key] = b
+	}
+	b.refill(entry.rule, now)
+	if found {
+		var shared bucket
+		if err := json.Unmarshal([]byte(value), &shared); err != nil {
+			s.local.mu.Unlock()
+			return fmt.Errorf("failed to decode bucket: %w", err)
+		}
+		shared.refill(entry.rule, now)
+		// The shared copy already includes other instances' spending, but
+		// not ours since the last sync.
+		b.Tokens = math.Max(shared.Tokens-entry.tokens, 0)
+	}
+	merged := *b
+	s.local.mu.Unlock()
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to encode bucket: %w", err)
+	}
+	// A bucket left alone refills completely, which is the same as having
+	// none, so there is no need to keep it longer than that.
+	return s.kv.PutWithTTL(ctx, kvKey, string(data), seconds(float64(entry.rule.Burst)/entry.rule.Rate))
+}
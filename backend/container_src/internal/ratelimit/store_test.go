@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"server/internal/auth"
+	"server/internal/device"
+)
+
+type fakeKV struct {
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+	calls  int
+	err    error
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{values: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string) (string, bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.calls++
+	if kv.err != nil {
+		return "", false, kv.err
+	}
+	value, ok := kv.values[key]
+	return value, ok, nil
+}
+
+func (kv *fakeKV) PutWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.calls++
+	if kv.err != nil {
+		return kv.err
+	}
+	kv.values[key] = value
+	kv.ttls[key] = ttl
+	return nil
+}
+
+var testRule = Rule{Name: "submit", Rate: 0.1, Burst: 4}
+
+func spend(t *testing.T, store Store, n int, now time.Time) (allowed int) {
+	t.Helper()
+	for range n {
+		result, err := store.Take(context.Background(), "key:a", testRule, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestKVStoreSharesSpendingBetweenInstances(t *testing.T) {
+	kv := newFakeKV()
+	first, second := NewKVStore(kv), NewKVStore(kv)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	if got := spend(t, first, 3, now); got != 3 {
+		t.Fatalf("first instance allowed %d of 3", got)
+	}
+	if kv.calls != 0 {
+		t.Errorf("Take reached KV %d times, want none", kv.calls)
+	}
+
+	first.Sync(context.Background(), now)
+	if ttl := kv.ttls["ratelimit:key:a"]; ttl != 40*time.Second {
+		t.Errorf("bucket TTL = %v, want the 40s full refill time", ttl)
+	}
+
+	// The second instance starts with a full local bucket; after one sync
+	// it knows only one token is left.
+	if got := spend(t, second, 1, now); got != 1 {
+		t.Fatalf("second instance allowed %d of 1", got)
+	}
+	second.Sync(context.Background(), now)
+	if got := spend(t, second, 2, now); got != 0 {
+		t.Errorf("second instance allowed %d more after sync, want 0", got)
+	}
+}
+
+func TestKVStoreKeepsSpendingWhenSyncFails(t *testing.T) {
+	kv := newFakeKV()
+	store := NewKVStore(kv)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	spend(t, store, 2, now)
+	kv.err = errors.New("kv unavailable")
+	store.Sync(context.Background(), now)
+	if got := spend(t, store, 4, now); got != 2 {
+		t.Errorf("allowed %d during the outage, want the 2 left locally", got)
+	}
+
+	kv.err = nil
+	store.Sync(context.Background(), now)
+	if _, ok := kv.values["ratelimit:key:a"]; !ok {
+		t.Fatal("bucket not published after KV recovered")
+	}
+	if got := spend(t, NewKVStore(kv), 1, now); got != 1 {
+		t.Fatal("a fresh instance should start from its own full bucket")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func TestLimiterFallsBackToLocalBuckets(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Middleware(testRule)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var limited int
+	for range testRule.Burst + 2 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/solve", nil))
+		if w.Code == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+	if limited != 2 {
+		t.Errorf("%d requests limited while the store was down, want 2", limited)
+	}
+}
+
+func TestIdentityIgnoresUnverifiedDeviceHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.7:4242"
+	r.Header.Set("X-Device-ID", "../../values/other")
+	if got := Identity(r); got != "ip:203.0.113.7" {
+		t.Errorf("Identity = %q, want the client IP", got)
+	}
+}
+
+func TestIdentitySeparatesDevicesSharingAKey(t *testing.T) {
+	shared := &auth.Key{ID: "app"}
+	identity := func(ctx context.Context) string {
+		r := httptest.NewRequest(http.MethodPost, "/solve", nil)
+		r.RemoteAddr = "203.0.113.7:4242"
+		return Identity(r.WithContext(ctx))
+	}
+
+	ctx := auth.WithKey(context.Background(), shared)
+	first := identity(device.WithID(ctx, "dev_a"))
+	second := identity(device.WithID(ctx, "dev_b"))
+	if first == second {
+		t.Errorf("devices sharing a key share the bucket %q", first)
+	}
+	if got := identity(ctx); got != "key:app" {
+		t.Errorf("Identity without a device = %q, want key:app", got)
+	}
+	if got := identity(device.WithID(context.Background(), "dev_a")); got != "device:dev_a" {
+		t.Errorf("Identity without a key = %q, want device:dev_a", got)
+	}
+}
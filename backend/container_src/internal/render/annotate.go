@@ -0,0 +1,252 @@
+// Package render draws plate-solving results: annotated copies of the image,
+// using pure-Go raster drawing and a built-in bitmap font, and SVG finder
+// charts.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"server/internal/imaging"
+	"server/internal/model"
+	"server/internal/overlay"
+	"server/internal/wcs"
+)
+
+// MaxSize is the longest side of an annotated image; larger originals are
+// scaled down before drawing.
+const MaxSize = 4096
+
+const jpegQuality = 90
+
+// niceAngles are the scale bar lengths to choose from, in arcseconds.
+var niceAngles = []float64{
+	1, 2, 5, 10, 15, 30,
+	60, 120, 300, 600, 900, 1800,
+	3600, 7200, 5 * 3600, 10 * 3600, 20 * 3600, 30 * 3600, 45 * 3600,
+}
+
+// layout holds the sizes shared by everything drawn on one image.
+type layout struct {
+	canvas
+	style  Style
+	scale  float64 // rendered pixels per solve-frame pixel
+	font   int     // font pixel size
+	stroke float64
+	margin int
+}
+
+type label struct {
+	text string
+	x, y float64
+	r    float64
+	typ  string
+}
+
+// Annotate returns a copy of img with the result's objects, a compass and
+// a scale bar drawn on it. The compass and scale bar need the plate
+// solution and are left out without it.
+func Annotate(img image.Image, result *model.SolveResult, style Style) *image.RGBA {
+	src := imaging.Thumbnail(img, MaxSize)
+	b := src.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Rect, src, b.Min, draw.Src)
+
+	frameWidth := float64(img.Bounds().Dx())
+	if result != nil && result.WCS != nil && result.WCS.ImageWidth > 0 {
+		frameWidth = float64(result.WCS.ImageWidth)
+	}
+	shorter := float64(min(b.Dx(), b.Dy()))
+	font := max(1, int(math.Round(labelHeight[style.LabelSize]*shorter/lineHeight)))
+	l := &layout{
+		canvas: canvas{img: out},
+		style:  style,
+		scale:  float64(b.Dx()) / frameWidth,
+		font:   font,
+		stroke: max(1, shorter/500),
+		margin: 2 * lineHeight * font,
+	}
+	if result == nil {
+		return out
+	}
+
+	l.objects(result.Objects)
+	if result.WCS != nil {
+		l.compass(result.WCS)
+		l.scaleBar(result.WCS)
+	}
+	return out
+}
+
+// objects draws a ring around each selected object, then the labels on top
+// so no ring covers a name. Labels sit above and right of their ring and
+// flip to the other side at the image edges.
+func (l *layout) objects(objects []model.CelestialObject) {
+	var labels []label
+	for _, o := range objects {
+		if !l.style.shows(o.Type) {
+			continue
+		}
+		x, y, r, ok := marker(o)
+		if !ok {
+			continue
+		}
+		x, y, r = x*l.scale, y*l.scale, max(r*l.scale, 4*l.stroke)
+		l.outlinedRing(x, y, r, l.stroke, l.style.Theme.objectColor(o.Type))
+		labels = append(labels, label{text: o.GetDisplayName(), x: x, y: y, r: r, typ: o.Type})
+	}
+
+	bounds := l.img.Rect
+	height := glyphHeight * l.font
+	for _, lb := range labels {
+		width := textWidth(lb.text, l.font)
+		offset := lb.r/math.Sqrt2 + float64(l.font)
+		x := int(lb.x + offset)
+		if x+width > bounds.Max.X {
+			x = int(lb.x-offset) - width
+		}
+		y := int(lb.y-lb.r/math.Sqrt2) - height
+		if y < bounds.Min.Y {
+			y = int(lb.y + lb.r/math.Sqrt2)
+		}
+		x = max(bounds.Min.X, min(x, bounds.Max.X-width))
+		y = max(bounds.Min.Y, min(y, bounds.Max.Y-height))
+		l.text(lb.text, x, y, l.font, l.style.Theme.objectColor(lb.typ))
+	}
+}
+
+// marker returns where an object is in the solve frame and how big to
+// draw it.
+func marker(o model.CelestialObject) (x, y, r float64, ok bool) {
+	if fp := o.Footprint; fp != nil {
+		return fp.PixelX, fp.PixelY, fp.Radius, true
+	}
+	if o.PixelX != nil && o.PixelY != nil {
+		return *o.PixelX, *o.PixelY, 0, true
+	}
+	return 0, 0, 0, false
+}
+
+// compass draws north and east arrows in the bottom-left corner.
+func (l *layout) compass(field *wcs.WCS) {
+	north, east, ok := directions(field, float64(field.ImageWidth-1)/2, float64(field.ImageHeight-1)/2)
+	if !ok {
+		return
+	}
+
+	length := 0.07 * float64(min(l.img.Rect.Dx(), l.img.Rect.Dy()))
+	ox := float64(l.margin) + length
+	oy := float64(l.img.Rect.Dy()-l.margin) - length
+	col := l.style.Theme.chromeColor()
+	for _, arrow := range []struct {
+		dir  overlay.Point
+		name string
+	}{{north, "N"}, {east, "E"}} {
+		tx, ty := ox+arrow.dir.X*length, oy+arrow.dir.Y*length
+		l.outlinedLine(ox, oy, tx, ty, l.stroke, col)
+		for _, side := range []float64{-1, 1} {
+			angle := math.Atan2(arrow.dir.Y, arrow.dir.X) + math.Pi - side*math.Pi/7
+			l.outlinedLine(tx, ty, tx+math.Cos(angle)*length/4, ty+math.Sin(angle)*length/4, l.stroke, col)
+		}
+
+		gap := float64(lineHeight * l.font)
+		lx := tx + arrow.dir.X*gap - float64(textWidth(arrow.name, l.font))/2
+		ly := ty + arrow.dir.Y*gap - float64(glyphHeight*l.font)/2
+		l.text(arrow.name, int(lx), int(ly), l.font, col)
+	}
+}
+
+// directions returns unit vectors in image pixels pointing north and east
+// from x, y in the solve frame. They are not perpendicular when the image
+// is distorted or skewed.
+func directions(field *wcs.WCS, x, y float64) (north, east overlay.Point, ok bool) {
+	ra, dec := field.PixelToSky(x, y)
+	step := field.PixelScale() / 3600 * 20
+	cosDec := math.Cos(dec * math.Pi / 180)
+	if step == 0 || cosDec < 1e-6 {
+		return north, east, false
+	}
+
+	toward := func(ra, dec float64) (overlay.Point, bool) {
+		px, py, ok := field.SkyToPixel(ra, dec)
+		n := math.Hypot(px-x, py-y)
+		if !ok || n == 0 {
+			return overlay.Point{}, false
+		}
+		return overlay.Point{X: (px - x) / n, Y: (py - y) / n}, true
+	}
+
+	okNorth := false
+	if dec+step <= 90 {
+		north, okNorth = toward(ra, dec+step)
+	} else {
+		north, okNorth = toward(ra+180, 180-dec-step)
+	}
+	east, okEast := toward(ra+step/cosDec, dec)
+	return north, east, okNorth && okEast
+}
+
+// scaleBar draws a bar of a round angular length in the bottom-right
+// corner.
+func (l *layout) scaleBar(field *wcs.WCS) {
+	pixelScale := field.PixelScale() / l.scale
+	length, text, ok := barLength(pixelScale, 0.25*float64(l.img.Rect.Dx()))
+	if !ok {
+		return
+	}
+
+	x2 := float64(l.img.Rect.Dx() - l.margin)
+	x1 := x2 - length
+	y := float64(l.img.Rect.Dy() - l.margin)
+	tick := float64(glyphHeight*l.font) / 2
+	col := l.style.Theme.chromeColor()
+	l.outlinedLine(x1, y, x2, y, l.stroke, col)
+	l.outlinedLine(x1, y-tick, x1, y+tick, l.stroke, col)
+	l.outlinedLine(x2, y-tick, x2, y+tick, l.stroke, col)
+
+	tx := (x1+x2)/2 - float64(textWidth(text, l.font))/2
+	ty := y - tick - float64(lineHeight*l.font)
+	l.text(text, int(tx), int(ty), l.font, col)
+}
+
+// barLength picks the longest round angle that fits in maxPixels at
+// pixelScale arcseconds per pixel, returning its length in pixels and
+// its label.
+func barLength(pixelScale, maxPixels float64) (float64, string, bool) {
+	if pixelScale <= 0 {
+		return 0, "", false
+	}
+	for i := len(niceAngles) - 1; i >= 0; i-- {
+		angle := niceAngles[i]
+		if length := angle / pixelScale; length <= maxPixels {
+			return length, formatAngle(angle), true
+		}
+	}
+	return 0, "", false
+}
+
+func formatAngle(arcsec float64) string {
+	switch {
+	case arcsec >= 3600:
+		return fmt.Sprintf("%g°", arcsec/3600)
+	case arcsec >= 60:
+		return fmt.Sprintf("%g'", arcsec/60)
+	}
+	return fmt.Sprintf("%g\"", arcsec)
+}
+
+// Encode writes a rendered image in the given format.
+func Encode(img image.Image, format Format) ([]byte, error) {
+	if format == FormatJPEG {
+		return imaging.EncodeJPEG(img, jpegQuality)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"server/internal/model"
+	"server/internal/wcs"
+)
+
+// northUp returns a plain gnomonic solution centred on the image with north
+// up and east left.
+func northUp(ra, dec, scale float64, width, height int) *wcs.WCS {
+	return &wcs.WCS{
+		CRVAL:       [2]float64{ra, dec},
+		CRPIX:       [2]float64{float64(width+1) / 2, float64(height+1) / 2},
+		CD:          [2][2]float64{{-scale, 0}, {0, -scale}},
+		ImageWidth:  width,
+		ImageHeight: height,
+	}
+}
+
+func TestGlyphs(t *testing.T) {
+	for i, g := range glyphs {
+		blank := true
+		for _, row := range g {
+			if row >= 1<<glyphWidth {
+				t.Errorf("glyph %q is wider than %d pixels", rune(' '+i), glyphWidth)
+			}
+			blank = blank && row == 0
+		}
+		if blank != (i == 0) {
+			t.Errorf("glyph %q: blank = %v", rune(' '+i), blank)
+		}
+	}
+	if glyph('ö') != glyph('o') || glyph('′') != glyph('\'') || glyph('☉') != glyph('?') {
+		t.Error("non-ASCII characters should fall back to the nearest glyph")
+	}
+	if got := textWidth("M 42", 2); got != (4*advance-1)*2 {
+		t.Errorf("textWidth = %d", got)
+	}
+}
+
+func TestBarLength(t *testing.T) {
+	cases := []struct {
+		pixelScale, maxPixels float64
+		length                float64
+		label                 string
+	}{
+		{2, 300, 300, `10'`},
+		{2, 299, 150, `5'`},
+		{0.5, 100, 60, `30"`},
+		{36, 600, 500, `5°`},
+		{100, 100, 72, `2°`},
+	}
+	for _, c := range cases {
+		length, label, ok := barLength(c.pixelScale, c.maxPixels)
+		if !ok || math.Abs(length-c.length) > 1e-9 || label != c.label {
+			t.Errorf("barLength(%v, %v) = %v %q %v, want %v %q", c.pixelScale, c.maxPixels, length, label, ok, c.length, c.label)
+		}
+	}
+	if _, _, ok := barLength(0, 100); ok {
+		t.Error("barLength with no pixel scale should fail")
+	}
+}
+
+func TestDirections(t *testing.T) {
+	field := northUp(83.8, -5.4, 2.0/3600, 1200, 800)
+	north, east, ok := directions(field, 599.5, 399.5)
+	if !ok {
+		t.Fatal("directions failed")
+	}
+	if math.Abs(north.X) > 1e-6 || math.Abs(north.Y+1) > 1e-6 {
+		t.Errorf("north = %v, want (0, -1)", north)
+	}
+	if math.Abs(east.X+1) > 1e-4 || math.Abs(east.Y) > 1e-4 {
+		t.Errorf("east = %v, want (-1, 0)", east)
+	}
+
+	// Past the pole "north" keeps going the same way across the image.
+	polar := northUp(0, 89.999, 2.0/3600, 1200, 800)
+	north, _, ok = directions(polar, 599.5, 399.5)
+	if !ok || north.Y > -0.99 {
+		t.Errorf("north near the pole = %v %v, want (0, -1)", north, ok)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	result := &model.SolveResult{
+		WCS: northUp(83.8, -5.4, 2.0/3600, 600, 400),
+		Objects: []model.CelestialObject{
+			{Name: "M 42", Type: "nebula", Footprint: &model.Footprint{PixelX: 300, PixelY: 200, Radius: 50}},
+			{Name: "M 43", Type: "galaxy", Footprint: &model.Footprint{PixelX: 100, PixelY: 100, Radius: 20}},
+		},
+	}
+
+	out := Annotate(src, result, Style{Types: []string{"nebula"}, LabelSize: LabelMedium, Theme: ThemeRed})
+	if out.Bounds() != src.Bounds() {
+		t.Fatalf("bounds = %v, want %v", out.Bounds(), src.Bounds())
+	}
+	if c := out.RGBAAt(350, 200); c.R != 255 || c.G > 60 {
+		t.Errorf("pixel on the M 42 ring = %v, want red", c)
+	}
+	if c := out.RGBAAt(120, 100); c.R != 0 {
+		t.Errorf("pixel on the M 43 ring = %v, want it left out", c)
+	}
+	if c := src.RGBAAt(350, 200); c.R != 0 {
+		t.Error("Annotate drew on the source image")
+	}
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+var shadow = color.RGBA{0, 0, 0, 255}
+
+// canvas draws antialiased strokes and bitmap text onto an RGBA image.
+// Coordinates are in pixels from the centre of the top-left pixel.
+type canvas struct {
+	img *image.RGBA
+}
+
+// blend mixes col into the pixel at x, y with the given coverage in [0, 1].
+func (c *canvas) blend(x, y int, col color.RGBA, coverage float64) {
+	if coverage <= 0 || !(image.Point{x, y}.In(c.img.Rect)) {
+		return
+	}
+	coverage = math.Min(coverage, 1)
+	i := c.img.PixOffset(x, y)
+	p := c.img.Pix[i : i+4 : i+4]
+	p[0] = uint8(float64(p[0])*(1-coverage) + float64(col.R)*coverage + 0.5)
+	p[1] = uint8(float64(p[1])*(1-coverage) + float64(col.G)*coverage + 0.5)
+	p[2] = uint8(float64(p[2])*(1-coverage) + float64(col.B)*coverage + 0.5)
+	p[3] = 255
+}
+
+// cover turns a distance from a stroke's centre line into pixel coverage.
+func cover(dist, width float64) float64 {
+	return width/2 + 0.5 - dist
+}
+
+// line draws the segment x1,y1-x2,y2 with the given stroke width.
+func (c *canvas) line(x1, y1, x2, y2, width float64, col color.RGBA) {
+	pad := width/2 + 1
+	minX, maxX := int(math.Floor(math.Min(x1, x2)-pad)), int(math.Ceil(math.Max(x1, x2)+pad))
+	minY, maxY := int(math.Floor(math.Min(y1, y2)-pad)), int(math.Ceil(math.Max(y1, y2)+pad))
+	bounds := c.img.Rect
+	minX, minY = max(minX, bounds.Min.X), max(minY, bounds.Min.Y)
+	maxX, maxY = min(maxX, bounds.Max.X-1), min(maxY, bounds.Max.Y-1)
+
+	dx, dy := x2-x1, y2-y1
+	length2 := dx*dx + dy*dy
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float64(x)-x1, float64(y)-y1
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, (px*dx+py*dy)/length2))
+			}
+			c.blend(x, y, col, cover(math.Hypot(px-t*dx, py-t*dy), width))
+		}
+	}
+}
+
+// ring draws a circle outline of radius r centred on cx, cy.
+func (c *canvas) ring(cx, cy, r, width float64, col color.RGBA) {
+	pad := r + width/2 + 1
+	bounds := c.img.Rect
+	minX, maxX := max(int(math.Floor(cx-pad)), bounds.Min.X), min(int(math.Ceil(cx+pad)), bounds.Max.X-1)
+	minY, maxY := max(int(math.Floor(cy-pad)), bounds.Min.Y), min(int(math.Ceil(cy+pad)), bounds.Max.Y-1)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			d := math.Abs(math.Hypot(float64(x)-cx, float64(y)-cy) - r)
+			c.blend(x, y, col, cover(d, width))
+		}
+	}
+}
+
+// outlinedLine draws a line over a slightly wider dark one so it stays
+// visible on bright parts of the image.
+func (c *canvas) outlinedLine(x1, y1, x2, y2, width float64, col color.RGBA) {
+	c.line(x1, y1, x2, y2, width+2, shadow)
+	c.line(x1, y1, x2, y2, width, col)
+}
+
+func (c *canvas) outlinedRing(cx, cy, r, width float64, col color.RGBA) {
+	c.ring(cx, cy, r, width+2, shadow)
+	c.ring(cx, cy, r, width, col)
+}
+
+// text draws s with its top-left corner at x, y, each font pixel scaled to
+// a scale×scale block and surrounded by a dark halo.
+func (c *canvas) text(s string, x, y, scale int, col color.RGBA) {
+	halo := max(1, scale/2)
+	c.glyphs(s, x, y, scale, halo, shadow)
+	c.glyphs(s, x, y, scale, 0, col)
+}
+
+// glyphs fills each set font pixel, grown by pad pixels on every side.
+func (c *canvas) glyphs(s string, x, y, scale, pad int, col color.RGBA) {
+	for _, r := range s {
+		g := glyph(r)
+		for row, bits := range g {
+			for bit := 0; bit < glyphWidth; bit++ {
+				if bits&(1<<(glyphWidth-1-bit)) == 0 {
+					continue
+				}
+				px, py := x+bit*scale, y+row*scale
+				c.fill(image.Rect(px-pad, py-pad, px+scale+pad, py+scale+pad), col)
+			}
+		}
+		x += advance * scale
+	}
+}
+
+func (c *canvas) fill(rect image.Rectangle, col color.RGBA) {
+	rect = rect.Intersect(c.img.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c.blend(x, y, col, 1)
+		}
+	}
+}
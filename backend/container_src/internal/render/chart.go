@@ -0,0 +1,298 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"server/internal/astro"
+	"server/internal/constellation"
+	"server/internal/model/data"
+	"server/internal/overlay"
+	"server/internal/wcs"
+)
+
+// Chart layout, in SVG user units.
+const (
+	chartPanel  = 560
+	chartMargin = 20
+	chartTitle  = 28
+	chartWidth  = 2*chartPanel + 3*chartMargin
+	chartHeight = chartPanel + chartTitle + 2*chartMargin
+)
+
+// Field of view limits in degrees. The detail panel shows the footprint
+// with some sky around it; the context panel shows the surrounding
+// constellations. Both stay well inside the gnomonic projection's limit.
+const (
+	detailPadding = 1.4
+	contextFactor = 8
+	minContext    = 20
+	maxChartField = 100
+)
+
+// chartColors are chosen to print well on white paper.
+var chartColors = map[string]string{
+	"galaxy":  "#c0392b",
+	"nebula":  "#1e8449",
+	"cluster": "#b7950b",
+}
+
+const (
+	footprintColor = "#1f5fbf"
+	figureColor    = "#b0b0b0"
+)
+
+// chartObjects is the catalog with one entry per position, preferring
+// designations such as "M42" over common names.
+var chartObjects = sync.OnceValue(func() []data.ObjectInfo {
+	best := make(map[*data.Position]data.ObjectInfo)
+	for _, info := range data.All() {
+		if info.Position == nil {
+			continue
+		}
+		if current, ok := best[info.Position]; !ok || data.IsDesignation(info.Name) && !data.IsDesignation(current.Name) {
+			best[info.Position] = info
+		}
+	}
+
+	objects := make([]data.ObjectInfo, 0, len(best))
+	for _, info := range best {
+		objects = append(objects, info)
+	}
+	// Draw faint objects first so bright stars sit on top.
+	sort.Slice(objects, func(i, j int) bool {
+		return magnitudeOf(objects[i]) > magnitudeOf(objects[j])
+	})
+	return objects
+})
+
+func magnitudeOf(info data.ObjectInfo) float64 {
+	if info.Position.Magnitude == nil {
+		return 99
+	}
+	return *info.Position.Magnitude
+}
+
+// panel is one north-up, east-left gnomonic view placed in the chart.
+type panel struct {
+	x, y   float64
+	across float64 // degrees
+	proj   *wcs.WCS
+	id     string
+}
+
+func newPanel(id string, x, y float64, center astro.Equatorial, across float64) *panel {
+	scale := math.Tan(across/2*math.Pi/180) * 180 / math.Pi / (chartPanel / 2)
+	return &panel{
+		x: x, y: y, across: across, id: id,
+		proj: &wcs.WCS{
+			CRVAL:       [2]float64{center.RA, center.Dec},
+			CRPIX:       [2]float64{chartPanel/2 + 1, chartPanel/2 + 1},
+			CD:          [2][2]float64{{-scale, 0}, {0, -scale}},
+			ImageWidth:  chartPanel,
+			ImageHeight: chartPanel,
+		},
+	}
+}
+
+// point returns where a position falls in the chart, reporting false when
+// it is outside the panel by more than pad units.
+func (p *panel) point(ra, dec, pad float64) (overlay.Point, bool) {
+	x, y, ok := p.proj.SkyToPixel(ra, dec)
+	if !ok || x < -pad || y < -pad || x > chartPanel+pad || y > chartPanel+pad {
+		return overlay.Point{}, false
+	}
+	return overlay.Point{X: p.x + x, Y: p.y + y}, true
+}
+
+// pixels converts an angle in degrees to chart units at the panel centre.
+func (p *panel) pixels(deg float64) float64 {
+	return deg / math.Abs(p.proj.CD[0][0])
+}
+
+// Chart draws a printable SVG finder chart for a solved field: a detail
+// panel around the image footprint and a wider context panel, both north
+// up and east left, with catalog stars sized by magnitude, deep-sky
+// symbols, constellation figures and the footprint outline.
+func Chart(field *wcs.WCS) []byte {
+	center, radius := fieldExtent(field)
+	detail := newPanel("detail", chartMargin, chartMargin+chartTitle, center,
+		math.Min(2*detailPadding*radius, maxChartField))
+	context := newPanel("context", 2*chartMargin+chartPanel, chartMargin+chartTitle, center,
+		math.Min(math.Max(contextFactor*radius, minContext), maxChartField))
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", chartWidth, chartHeight)
+
+	for _, p := range []*panel{detail, context} {
+		title := "Field"
+		if p == context {
+			title = "Context"
+		}
+		svgText(&b, p.x, p.y-10, 14, "start", "#000", fmt.Sprintf("%s, %s across", title, formatAcross(p.across)))
+		fmt.Fprintf(&b, `<clipPath id="%s"><rect x="%.1f" y="%.1f" width="%d" height="%d"/></clipPath>`+"\n",
+			p.id, p.x, p.y, chartPanel, chartPanel)
+		fmt.Fprintf(&b, `<g clip-path="url(#%s)">`+"\n", p.id)
+		p.figures(&b)
+		p.footprint(&b, field)
+		p.objects(&b, p == detail)
+		b.WriteString("</g>\n")
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%d" height="%d" fill="none" stroke="#000"/>`+"\n",
+			p.x, p.y, chartPanel, chartPanel)
+	}
+	detail.arrows(&b)
+
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+func formatAcross(deg float64) string {
+	if deg >= 1 {
+		return fmt.Sprintf("%.1f°", deg)
+	}
+	return fmt.Sprintf("%.0f'", deg*60)
+}
+
+// fieldExtent returns the centre of the image and the distance in degrees
+// to its furthest corner.
+func fieldExtent(field *wcs.WCS) (astro.Equatorial, float64) {
+	w, h := float64(field.ImageWidth), float64(field.ImageHeight)
+	ra, dec := field.PixelToSky((w-1)/2, (h-1)/2)
+	center := astro.Equatorial{RA: ra, Dec: dec}
+
+	radius := 0.0
+	for _, c := range [][2]float64{{-0.5, -0.5}, {w - 0.5, -0.5}, {-0.5, h - 0.5}, {w - 0.5, h - 0.5}} {
+		ra, dec := field.PixelToSky(c[0], c[1])
+		radius = math.Max(radius, astro.Separation(center, astro.Equatorial{RA: ra, Dec: dec}))
+	}
+	return center, radius
+}
+
+// figures draws the constellation stick figures.
+func (p *panel) figures(b *bytes.Buffer) {
+	lo, hi := overlay.Point{X: p.x, Y: p.y}, overlay.Point{X: p.x + chartPanel, Y: p.y + chartPanel}
+	for _, f := range constellation.Figures() {
+		for _, s := range f.Segments {
+			from, ok1 := p.point(s.From.Position.RA, s.From.Position.Dec, chartPanel)
+			to, ok2 := p.point(s.To.Position.RA, s.To.Position.Dec, chartPanel)
+			if !ok1 || !ok2 {
+				continue
+			}
+			if from, to, ok := overlay.ClipLine(from, to, lo, hi); ok {
+				fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`+"\n",
+					from.X, from.Y, to.X, to.Y, figureColor)
+			}
+		}
+	}
+}
+
+// footprint outlines the image, following its edges so distortion and
+// rotation show.
+func (p *panel) footprint(b *bytes.Buffer, field *wcs.WCS) {
+	const steps = 8
+	w, h := float64(field.ImageWidth)-0.5, float64(field.ImageHeight)-0.5
+	corners := [][2]float64{{-0.5, -0.5}, {w, -0.5}, {w, h}, {-0.5, h}}
+
+	var path strings.Builder
+	for i, c := range corners {
+		next := corners[(i+1)%len(corners)]
+		for s := range steps {
+			t := float64(s) / steps
+			ra, dec := field.PixelToSky(c[0]+t*(next[0]-c[0]), c[1]+t*(next[1]-c[1]))
+			pt, ok := p.point(ra, dec, math.Inf(1))
+			if !ok {
+				return
+			}
+			cmd := "L"
+			if path.Len() == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&path, "%s%.1f %.1f ", cmd, pt.X, pt.Y)
+		}
+	}
+	fmt.Fprintf(b, `<path d="%sZ" fill="%s" fill-opacity="0.06" stroke="%s" stroke-width="2"/>`+"\n",
+		path.String(), footprintColor, footprintColor)
+}
+
+// objects draws catalog stars and deep-sky objects. The detail panel
+// labels everything; the context panel only the brighter stars.
+func (p *panel) objects(b *bytes.Buffer, labelAll bool) {
+	for _, o := range chartObjects() {
+		pos := o.Position
+		pt, ok := p.point(pos.RA, pos.Dec, 20)
+		if !ok {
+			continue
+		}
+
+		var r float64
+		if o.Type == "star" {
+			r = starRadius(magnitudeOf(o))
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="#000" stroke="#fff" stroke-width="0.8"/>`+"\n", pt.X, pt.Y, r)
+			if !labelAll && magnitudeOf(o) > 2 {
+				continue
+			}
+		} else {
+			r = math.Max(p.pixels(pos.Size/60)/2, 4)
+			deepSkySymbol(b, o.Type, pt, r)
+			if !labelAll {
+				continue
+			}
+		}
+		svgText(b, pt.X+r+3, pt.Y+4, 11, "start", "#000", o.Name)
+	}
+}
+
+// starRadius sizes a star symbol by visual magnitude.
+func starRadius(mag float64) float64 {
+	return math.Max(1, 1.2+0.9*(4-mag))
+}
+
+// deepSkySymbol draws the usual atlas symbols: an ellipse for a galaxy, a
+// square for a nebula and a dashed circle for a cluster.
+func deepSkySymbol(b *bytes.Buffer, typ string, pt overlay.Point, r float64) {
+	color, ok := chartColors[typ]
+	if !ok {
+		color = "#555"
+	}
+	switch typ {
+	case "galaxy":
+		fmt.Fprintf(b, `<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n",
+			pt.X, pt.Y, r, r/2, color)
+	case "nebula":
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n",
+			pt.X-r, pt.Y-r, 2*r, 2*r, color)
+	default:
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="1.5" stroke-dasharray="3 2"/>`+"\n",
+			pt.X, pt.Y, r, color)
+	}
+}
+
+// arrows marks north and east in the panel's bottom-left corner.
+func (p *panel) arrows(b *bytes.Buffer) {
+	const length = 40
+	ox, oy := p.x+length+20, p.y+chartPanel-20
+	for _, a := range []struct {
+		dx, dy float64
+		name   string
+	}{{0, -1, "N"}, {-1, 0, "E"}} {
+		tx, ty := ox+a.dx*length, oy+a.dy*length
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#000" stroke-width="1.5"/>`+"\n", ox, oy, tx, ty)
+		// Arrowhead: the tip and two points 8 units back, 4 either side.
+		fmt.Fprintf(b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="#000"/>`+"\n",
+			tx, ty, tx-a.dx*8-a.dy*4, ty-a.dy*8+a.dx*4, tx-a.dx*8+a.dy*4, ty-a.dy*8-a.dx*4)
+		svgText(b, tx+a.dx*10, ty+a.dy*10+4, 13, "middle", "#000", a.name)
+	}
+}
+
+func svgText(b *bytes.Buffer, x, y, size float64, anchor, color, text string) {
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="%g" text-anchor="%s" fill="%s">`, x, y, size, anchor, color)
+	xml.EscapeText(b, []byte(text))
+	b.WriteString("</text>\n")
+}
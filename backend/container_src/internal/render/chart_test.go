@@ -0,0 +1,64 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestChart(t *testing.T) {
+	field := northUp(83.8, -5.4, 0.005, 1200, 800) // 6° × 4° around M42
+	svg := Chart(field)
+
+	texts := make(map[string]int)
+	elements := make(map[string]int)
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	var inText bool
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("chart is not well-formed XML: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			elements[tok.Name.Local]++
+			inText = tok.Name.Local == "text"
+		case xml.CharData:
+			if inText {
+				texts[string(tok)]++
+			}
+		case xml.EndElement:
+			inText = false
+		}
+	}
+
+	for _, name := range []string{"M42", "Alnilam", "N", "E"} {
+		if texts[name] == 0 {
+			t.Errorf("chart has no %q label", name)
+		}
+	}
+	if texts["Betelgeuse"] != 1 {
+		t.Errorf("Betelgeuse labelled %d times, want once in the context panel", texts["Betelgeuse"])
+	}
+	if elements["path"] != 2 {
+		t.Errorf("%d footprint outlines, want one per panel", elements["path"])
+	}
+	if elements["rect"] < 4 || elements["line"] == 0 {
+		t.Errorf("missing panel frames or figure lines: %v", elements)
+	}
+}
+
+func TestFieldExtent(t *testing.T) {
+	center, radius := fieldExtent(northUp(83.8, -5.4, 0.005, 1200, 800))
+	if math.Abs(center.RA-83.8) > 1e-3 || math.Abs(center.Dec+5.4) > 1e-3 {
+		t.Errorf("centre = %v, want 83.8 -5.4", center)
+	}
+	if want := math.Hypot(3, 2); math.Abs(radius-want) > 0.01 {
+		t.Errorf("radius = %v, want about %v", radius, want)
+	}
+}
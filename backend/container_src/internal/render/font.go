@@ -0,0 +1,145 @@
+package render
+
+// glyphWidth and glyphHeight are the size of the built-in font's cells.
+// Glyphs are drawn in a 6×8 advance so characters do not touch.
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	advance     = glyphWidth + 1
+	lineHeight  = glyphHeight + 1
+)
+
+// glyphs is a 5×7 bitmap font for printable ASCII. Each row is five bits,
+// the leftmost pixel in bit 4.
+var glyphs = [95][glyphHeight]uint8{
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // ' '
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x04}, // !
+	{0x0A, 0x0A, 0x0A, 0x00, 0x00, 0x00, 0x00}, // "
+	{0x0A, 0x0A, 0x1F, 0x0A, 0x1F, 0x0A, 0x0A}, // #
+	{0x04, 0x0F, 0x14, 0x0E, 0x05, 0x1E, 0x04}, // $
+	{0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03}, // %
+	{0x0C, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0D}, // &
+	{0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00}, // '
+	{0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02}, // (
+	{0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08}, // )
+	{0x00, 0x04, 0x15, 0x0E, 0x15, 0x04, 0x00}, // *
+	{0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00}, // +
+	{0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08}, // ,
+	{0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00}, // -
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C}, // .
+	{0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00}, // /
+	{0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E}, // 0
+	{0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E}, // 1
+	{0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F}, // 2
+	{0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E}, // 3
+	{0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02}, // 4
+	{0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E}, // 5
+	{0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E}, // 6
+	{0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08}, // 7
+	{0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E}, // 8
+	{0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C}, // 9
+	{0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00}, // :
+	{0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x04, 0x08}, // ;
+	{0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02}, // <
+	{0x00, 0x00, 0x1F, 0x00, 0x1F, 0x00, 0x00}, // =
+	{0x08, 0x04, 0x02, 0x01, 0x02, 0x04, 0x08}, // >
+	{0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04}, // ?
+	{0x0E, 0x11, 0x01, 0x0D, 0x15, 0x15, 0x0E}, // @
+	{0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11}, // A
+	{0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E}, // B
+	{0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E}, // C
+	{0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C}, // D
+	{0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F}, // E
+	{0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10}, // F
+	{0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F}, // G
+	{0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11}, // H
+	{0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E}, // I
+	{0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C}, // J
+	{0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11}, // K
+	{0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F}, // L
+	{0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11}, // M
+	{0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11}, // N
+	{0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E}, // O
+	{0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10}, // P
+	{0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D}, // Q
+	{0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11}, // R
+	{0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E}, // S
+	{0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}, // T
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E}, // U
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04}, // V
+	{0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A}, // W
+	{0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11}, // X
+	{0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04}, // Y
+	{0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F}, // Z
+	{0x0E, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0E}, // [
+	{0x00, 0x10, 0x08, 0x04, 0x02, 0x01, 0x00}, // \
+	{0x0E, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0E}, // ]
+	{0x04, 0x0A, 0x11, 0x00, 0x00, 0x00, 0x00}, // ^
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F}, // _
+	{0x08, 0x04, 0x02, 0x00, 0x00, 0x00, 0x00}, // `
+	{0x00, 0x00, 0x0E, 0x01, 0x0F, 0x11, 0x0F}, // a
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1E}, // b
+	{0x00, 0x00, 0x0E, 0x10, 0x10, 0x11, 0x0E}, // c
+	{0x01, 0x01, 0x0D, 0x13, 0x11, 0x11, 0x0F}, // d
+	{0x00, 0x00, 0x0E, 0x11, 0x1F, 0x10, 0x0E}, // e
+	{0x06, 0x09, 0x08, 0x1C, 0x08, 0x08, 0x08}, // f
+	{0x00, 0x0F, 0x11, 0x11, 0x0F, 0x01, 0x0E}, // g
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11}, // h
+	{0x04, 0x00, 0x0C, 0x04, 0x04, 0x04, 0x0E}, // i
+	{0x02, 0x00, 0x06, 0x02, 0x02, 0x12, 0x0C}, // j
+	{0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12}, // k
+	{0x0C, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E}, // l
+	{0x00, 0x00, 0x1A, 0x15, 0x15, 0x11, 0x11}, // m
+	{0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11}, // n
+	{0x00, 0x00, 0x0E, 0x11, 0x11, 0x11, 0x0E}, // o
+	{0x00, 0x00, 0x1E, 0x11, 0x1E, 0x10, 0x10}, // p
+	{0x00, 0x00, 0x0D, 0x13, 0x0F, 0x01, 0x01}, // q
+	{0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10}, // r
+	{0x00, 0x00, 0x0E, 0x10, 0x0E, 0x01, 0x1E}, // s
+	{0x08, 0x08, 0x1C, 0x08, 0x08, 0x09, 0x06}, // t
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0D}, // u
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x0A, 0x04}, // v
+	{0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0A}, // w
+	{0x00, 0x00, 0x11, 0x0A, 0x04, 0x0A, 0x11}, // x
+	{0x00, 0x00, 0x11, 0x11, 0x0F, 0x01, 0x0E}, // y
+	{0x00, 0x00, 0x1F, 0x02, 0x04, 0x08, 0x1F}, // z
+	{0x02, 0x04, 0x04, 0x08, 0x04, 0x04, 0x02}, // {
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}, // |
+	{0x08, 0x04, 0x04, 0x02, 0x04, 0x04, 0x08}, // }
+	{0x00, 0x00, 0x08, 0x15, 0x02, 0x00, 0x00}, // ~
+}
+
+var degreeGlyph = [glyphHeight]uint8{0x0C, 0x12, 0x12, 0x0C, 0x00, 0x00, 0x00}
+
+// fallbacks map the non-ASCII characters catalog names and labels use to
+// the nearest glyph.
+var fallbacks = map[rune]rune{
+	'′': '\'', '″': '"', '–': '-', '—': '-', '’': '\'',
+	'ä': 'a', 'á': 'a', 'à': 'a', 'é': 'e', 'è': 'e', 'ë': 'e',
+	'í': 'i', 'ï': 'i', 'ö': 'o', 'ó': 'o', 'ü': 'u', 'ú': 'u', 'ñ': 'n',
+}
+
+func glyph(r rune) [glyphHeight]uint8 {
+	if r == '°' {
+		return degreeGlyph
+	}
+	if f, ok := fallbacks[r]; ok {
+		r = f
+	}
+	if r < ' ' || r > '~' {
+		r = '?'
+	}
+	return glyphs[r-' ']
+}
+
+// textWidth returns the width in pixels of s drawn at the given scale.
+func textWidth(s string, scale int) int {
+	n := 0
+	for range s {
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return (n*advance - 1) * scale
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"image/color"
+	"slices"
+	"strings"
+)
+
+// ObjectTypes are the object types Style.Types may select.
+var ObjectTypes = []string{"star", "nebula", "galaxy", "cluster", "sun", "planet", "moon", "comet", "asteroid"}
+
+// LabelSize sets the label height relative to the image.
+type LabelSize string
+
+const (
+	LabelSmall  LabelSize = "small"
+	LabelMedium LabelSize = "medium"
+	LabelLarge  LabelSize = "large"
+)
+
+// labelHeight is the height of a line of text as a fraction of the
+// image's shorter side.
+var labelHeight = map[LabelSize]float64{
+	LabelSmall:  0.015,
+	LabelMedium: 0.022,
+	LabelLarge:  0.035,
+}
+
+// ParseLabelSize reads a label size name; empty means LabelMedium.
+func ParseLabelSize(s string) (LabelSize, bool) {
+	if s == "" {
+		return LabelMedium, true
+	}
+	_, ok := labelHeight[LabelSize(s)]
+	return LabelSize(s), ok
+}
+
+// Theme is the colour scheme of an annotated image.
+type Theme string
+
+const (
+	// ThemeDefault colours markers by object type.
+	ThemeDefault Theme = "default"
+	// ThemeRed draws everything in red to keep night vision.
+	ThemeRed Theme = "red"
+	// ThemeMono draws everything in white.
+	ThemeMono Theme = "mono"
+)
+
+// ParseTheme reads a theme name; empty means ThemeDefault.
+func ParseTheme(s string) (Theme, bool) {
+	switch Theme(s) {
+	case "":
+		return ThemeDefault, true
+	case ThemeDefault, ThemeRed, ThemeMono:
+		return Theme(s), true
+	}
+	return Theme(s), false
+}
+
+var typeColors = map[string]color.RGBA{
+	"star":     {255, 255, 255, 255},
+	"nebula":   {255, 110, 220, 255},
+	"galaxy":   {255, 220, 70, 255},
+	"cluster":  {90, 220, 255, 255},
+	"sun":      {255, 240, 120, 255},
+	"planet":   {255, 160, 60, 255},
+	"moon":     {200, 200, 200, 255},
+	"comet":    {120, 255, 140, 255},
+	"asteroid": {120, 255, 140, 255},
+}
+
+var (
+	white = color.RGBA{255, 255, 255, 255}
+	red   = color.RGBA{255, 40, 40, 255}
+)
+
+// objectColor returns the marker and label colour for an object type.
+func (t Theme) objectColor(typ string) color.RGBA {
+	switch t {
+	case ThemeRed:
+		return red
+	case ThemeMono:
+		return white
+	}
+	if c, ok := typeColors[typ]; ok {
+		return c
+	}
+	return white
+}
+
+// chromeColor returns the colour of the compass and scale bar.
+func (t Theme) chromeColor() color.RGBA {
+	if t == ThemeRed {
+		return red
+	}
+	return white
+}
+
+// Style chooses what an annotated image shows and how.
+type Style struct {
+	// Types limits markers to these object types; empty means all.
+	Types     []string
+	LabelSize LabelSize
+	Theme     Theme
+}
+
+// ParseTypes reads a comma-separated list of object types, reporting the
+// first unknown one.
+func ParseTypes(s string) ([]string, string, bool) {
+	if s == "" {
+		return nil, "", true
+	}
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !slices.Contains(ObjectTypes, t) {
+			return nil, t, false
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	slices.Sort(types)
+	return types, "", true
+}
+
+// Key identifies the style for caching rendered images.
+func (s Style) Key() string {
+	return strings.Join(s.Types, ",") + "|" + string(s.LabelSize) + "|" + string(s.Theme)
+}
+
+func (s Style) shows(typ string) bool {
+	return len(s.Types) == 0 || slices.Contains(s.Types, typ)
+}
+
+// Format is the file format of a rendered image.
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpg"
+)
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatJPEG {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
@@ -0,0 +1,215 @@
+package history
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/apperr"
+	"server/internal/model"
+	"server/internal/store"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type JobStore interface {
+	Get(ctx context.Context, id int) (*model.Job, error)
+	Delete(ctx context.Context, id int) error
+	ListByDevice(ctx context.Context, deviceID string) ([]store.IndexEntry, error)
+	Summary(ctx context.Context, deviceID string, entry store.IndexEntry) (*model.JobSummary, error)
+}
+
+type ImageStore interface {
+	Get(ctx context.Context, jobID int, kind store.ImageKind) ([]byte, error)
+	Delete(ctx context.Context, jobID int) error
+}
+
+// Renders caches images drawn from a job, which must go with it.
+type Renders interface {
+	ForgetRenders(jobID int)
+}
+
+type Query struct {
+	Cursor        string
+	Limit         int
+	From          time.Time
+	To            time.Time
+	Object        string
+	Constellation string
+}
+
+type Page struct {
+	Items      []*model.JobSummary
+	NextCursor string
+}
+
+type Service struct {
+	jobs    JobStore
+	images  ImageStore
+	renders Renders
+}
+
+func NewService(jobs JobStore, images ImageStore, renders Renders) *Service {
+	return &Service{jobs: jobs, images: images, renders: renders}
+}
+
+func (s *Service) List(ctx context.Context, deviceID string, q Query) (*Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	var after *position
+	if q.Cursor != "" {
+		pos, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, apperr.New(apperr.CodeInvalidRequest, "Invalid cursor")
+		}
+		after = &pos
+	}
+
+	entries, err := s.jobs.ListByDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index is sorted newest first, so the cursor and date range narrow
+	// it without loading anything; summaries are fetched only for the
+	// entries a page inspects.
+	if after != nil {
+		start, _ := slices.BinarySearchFunc(entries, *after, func(e store.IndexEntry, pos position) int {
+			return pos.compare(positionOf(e))
+		})
+		for start < len(entries) && positionOf(entries[start]) == *after {
+			start++
+		}
+		entries = entries[start:]
+	}
+
+	page := &Page{Items: []*model.JobSummary{}}
+	for i, entry := range entries {
+		if !q.From.IsZero() && entry.CreatedAt.Before(q.From) {
+			break
+		}
+		if !q.To.IsZero() && !entry.CreatedAt.Before(q.To) {
+			continue
+		}
+		if len(page.Items) == limit {
+			page.NextCursor = positionOf(entries[i-1]).encode()
+			break
+		}
+
+		summary, err := s.jobs.Summary(ctx, deviceID, entry)
+		if err != nil {
+			return nil, err
+		}
+		if summary != nil && q.matches(summary) {
+			page.Items = append(page.Items, summary)
+		}
+	}
+	return page, nil
+}
+
+func (s *Service) Get(ctx context.Context, deviceID string, id int) (*model.Job, error) {
+	job, err := s.jobs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.DeviceID != deviceID {
+		return nil, apperr.New(apperr.CodeNotFound, "History entry not found")
+	}
+	return job, nil
+}
+
+func (s *Service) Delete(ctx context.Context, deviceID string, id int) error {
+	if _, err := s.Get(ctx, deviceID, id); err != nil {
+		return err
+	}
+	if err := s.images.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.renders.ForgetRenders(id)
+	return s.jobs.Delete(ctx, id)
+}
+
+func (s *Service) Thumbnail(ctx context.Context, deviceID string, id int) ([]byte, error) {
+	if _, err := s.Get(ctx, deviceID, id); err != nil {
+		return nil, err
+	}
+
+	data, err := s.images.Get(ctx, id, store.ImageThumbnail)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Thumbnail not found")
+	}
+	return data, nil
+}
+
+func (q Query) matches(summary *model.JobSummary) bool {
+	if q.Object == "" && q.Constellation == "" {
+		return true
+	}
+
+	return slices.ContainsFunc(summary.Objects, func(o model.ObjectRef) bool {
+		if q.Object != "" && !strings.EqualFold(o.Name, q.Object) && !strings.EqualFold(o.DisplayName, q.Object) {
+			return false
+		}
+		return q.Constellation == "" || strings.EqualFold(o.Constellation, q.Constellation)
+	})
+}
+
+// position orders history entries newest first, breaking ties on the job ID
+// so a cursor stays stable when several jobs share a timestamp.
+type position struct {
+	createdAt int64
+	id        int
+}
+
+func positionOf(entry store.IndexEntry) position {
+	return position{createdAt: entry.CreatedAt.UnixNano(), id: entry.ID}
+}
+
+func (p position) compare(other position) int {
+	if p.createdAt != other.createdAt {
+		if p.createdAt < other.createdAt {
+			return -1
+		}
+		return 1
+	}
+	return p.id - other.id
+}
+
+func (p position) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", p.createdAt, p.id)))
+}
+
+func decodeCursor(cursor string) (position, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return position{}, err
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return position{}, fmt.Errorf("malformed cursor")
+	}
+
+	var pos position
+	if pos.createdAt, err = strconv.ParseInt(createdAt, 10, 64); err != nil {
+		return position{}, err
+	}
+	if pos.id, err = strconv.Atoi(id); err != nil {
+		return position{}, err
+	}
+	return pos, nil
+}
@@ -0,0 +1,110 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"server/internal/model"
+	"server/internal/store"
+)
+
+// countingStore records how many summaries a listing fetches.
+type countingStore struct {
+	*store.MemoryJobStore
+	summaries int
+}
+
+func (s *countingStore) Summary(ctx context.Context, deviceID string, entry store.IndexEntry) (*model.JobSummary, error) {
+	s.summaries++
+	return s.MemoryJobStore.Summary(ctx, deviceID, entry)
+}
+
+type noRenders struct{}
+
+func (noRenders) ForgetRenders(jobID int) {}
+
+var base = time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T) (*Service, *countingStore) {
+	t.Helper()
+	jobs := &countingStore{MemoryJobStore: store.NewMemoryJobStore()}
+	for i := range 10 {
+		job := &model.Job{ID: i + 1, DeviceID: "dev_a", CreatedAt: base.Add(time.Duration(i) * time.Hour), Status: "success"}
+		if i%3 == 0 {
+			job.Result = &model.SolveResult{Objects: []model.CelestialObject{{Name: "M42", Type: "nebula", Constellation: "Ori"}}}
+		}
+		if err := jobs.Save(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Same timestamp as job 10, so the cursor has to break the tie on ID.
+	if err := jobs.Save(context.Background(), &model.Job{ID: 11, DeviceID: "dev_a", CreatedAt: base.Add(9 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(jobs, store.NewMemoryImageStore(), noRenders{}), jobs
+}
+
+func pageIDs(page *Page) []int {
+	ids := make([]int, len(page.Items))
+	for i, item := range page.Items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestListPagesOverIndex(t *testing.T) {
+	ctx := context.Background()
+	s, jobs := newTestService(t)
+
+	var got []int
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("cursor never ran out")
+		}
+		jobs.summaries = 0
+		page, err := s.List(ctx, "dev_a", Query{Cursor: cursor, Limit: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jobs.summaries > 4 {
+			t.Errorf("page %d fetched %d summaries, want at most 4", pages, jobs.summaries)
+		}
+		got = append(got, pageIDs(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	want := []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	if len(got) != len(want) {
+		t.Fatalf("pages returned %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("pages returned %v, want %v", got, want)
+		}
+	}
+}
+
+func TestListFiltersWithoutLoadingOutOfRangeEntries(t *testing.T) {
+	ctx := context.Background()
+	s, jobs := newTestService(t)
+
+	page, err := s.List(ctx, "dev_a", Query{From: base.Add(2 * time.Hour), To: base.Add(8 * time.Hour), Object: "m42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := pageIDs(page); len(ids) != 2 || ids[0] != 7 || ids[1] != 4 {
+		t.Errorf("filtered page = %v, want [7 4]", ids)
+	}
+	if jobs.summaries != 6 {
+		t.Errorf("fetched %d summaries, want the 6 entries in range", jobs.summaries)
+	}
+
+	if page, _ := s.List(ctx, "dev_b", Query{}); len(page.Items) != 0 {
+		t.Errorf("another device sees %v", pageIDs(page))
+	}
+}
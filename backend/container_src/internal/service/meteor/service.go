@@ -0,0 +1,119 @@
+// Package meteor labels streaks with the meteor shower they may belong to.
+package meteor
+
+import (
+	"math"
+
+	"server/internal/astro"
+	"server/internal/model"
+)
+
+const (
+	// Sporadic labels meteors that no active shower accounts for.
+	Sporadic = "sporadic"
+
+	// maxOffset is how far, in degrees, a radiant may lie from the streak's
+	// back-projected great circle. Radiants are a few degrees across, and
+	// short streaks extrapolate poorly.
+	maxOffset = 4.0
+	// maxDistance is how far, in degrees, a shower meteor is seen from its
+	// radiant; ones farther out are too rare to assign.
+	maxDistance = 100.0
+)
+
+type Service struct {
+	showers []Shower
+}
+
+func NewService() *Service {
+	return &Service{showers: Showers()}
+}
+
+// Classify names the shower each streak may belong to, or Sporadic. A
+// meteor travels away from its radiant, so the streak extended backwards
+// along its great circle must pass through it. The image does not say which
+// end the meteor started from, so either direction is tried. Streaks that
+// already match a satellite, and all streaks when the capture time or the
+// plate solution is unknown, are left unlabelled.
+func (s *Service) Classify(streaks []model.Streak, observation *model.Observation) {
+	if observation == nil || observation.CapturedAt == nil {
+		return
+	}
+	at := *observation.CapturedAt
+	jd := astro.JulianDate(at)
+
+	var observer *astro.Observer
+	if loc := observation.Location; loc != nil {
+		observer = &astro.Observer{Latitude: loc.Latitude, Longitude: loc.Longitude}
+	}
+
+	for i := range streaks {
+		streak := &streaks[i]
+		if streak.Start == nil || streak.End == nil || len(streak.Satellites) > 0 {
+			continue
+		}
+		start := astro.Equatorial{RA: streak.Start.RA, Dec: streak.Start.Dec}
+		end := astro.Equatorial{RA: streak.End.RA, Dec: streak.End.Dec}
+
+		streak.Shower = Sporadic
+		best := maxOffset
+		for _, shower := range s.showers {
+			radiant, ok := shower.Active(at)
+			if !ok {
+				continue
+			}
+			// A radiant below the horizon sends no meteors.
+			if observer != nil && astro.ToHorizontal(astro.ApparentPlace(radiant, jd), *observer, jd).Altitude < 0 {
+				continue
+			}
+			if offset, ok := alignment(start, end, radiant); ok && offset <= best {
+				best = offset
+				streak.Shower = shower.Name
+			}
+		}
+	}
+}
+
+// alignment returns the angular distance in degrees from the radiant to the
+// great circle through the streak's endpoints. It fails when the radiant
+// lies alongside the streak itself rather than behind one of its ends, or is
+// too far away.
+func alignment(start, end, radiant astro.Equatorial) (float64, bool) {
+	a, b, r := unit(start), unit(end), unit(radiant)
+	pole := cross(a, b)
+	norm := math.Sqrt(dot(pole, pole))
+	if norm == 0 {
+		return 0, false
+	}
+	for i := range pole {
+		pole[i] /= norm
+	}
+
+	offset := math.Abs(math.Asin(dot(pole, r))) * 180 / math.Pi
+	if math.Min(astro.Separation(start, radiant), astro.Separation(end, radiant)) > maxDistance {
+		return 0, false
+	}
+
+	// Project the radiant onto the great circle and reject it if it falls
+	// between the endpoints.
+	along := cross(pole, a)
+	angle := math.Atan2(dot(r, along), dot(r, a)) * 180 / math.Pi
+	length := math.Atan2(dot(b, along), dot(b, a)) * 180 / math.Pi
+	if angle >= 0 && angle <= length {
+		return 0, false
+	}
+	return offset, true
+}
+
+func unit(eq astro.Equatorial) [3]float64 {
+	ra, dec := eq.RA*math.Pi/180, eq.Dec*math.Pi/180
+	return [3]float64{math.Cos(dec) * math.Cos(ra), math.Cos(dec) * math.Sin(ra), math.Sin(dec)}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
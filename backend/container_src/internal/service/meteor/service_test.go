@@ -0,0 +1,75 @@
+package meteor
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"server/internal/astro"
+	"server/internal/model"
+)
+
+func TestActiveAcrossNewYear(t *testing.T) {
+	var quadrantids Shower
+	for _, s := range Showers() {
+		if s.Code == "QUA" {
+			quadrantids = s
+		}
+	}
+	if quadrantids.Name == "" {
+		t.Fatal("Quadrantids missing from the table")
+	}
+
+	for _, date := range []string{"2023-12-30", "2024-01-03", "2024-01-12"} {
+		at, _ := time.Parse(time.DateOnly, date)
+		if _, ok := quadrantids.Active(at.Add(12 * time.Hour)); !ok {
+			t.Errorf("Quadrantids inactive on %s", date)
+		}
+	}
+	at, _ := time.Parse(time.DateOnly, "2024-02-01")
+	if _, ok := quadrantids.Active(at); ok {
+		t.Error("Quadrantids active on 2024-02-01")
+	}
+
+	peak := time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC)
+	radiant, _ := quadrantids.Active(peak.AddDate(0, 0, 2))
+	if math.Abs(radiant.RA-231.6) > 1e-9 || math.Abs(radiant.Dec-48.6) > 1e-9 {
+		t.Errorf("radiant two days after peak = %v, want drift to (231.6, 48.6)", radiant)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	at := time.Date(2024, time.August, 12, 22, 0, 0, 0, time.UTC)
+	observation := &model.Observation{CapturedAt: &at}
+
+	streaks := []model.Streak{
+		// Along the meridian through the Perseid radiant, moving away from it.
+		{Start: &model.Coordinates{RA: 48, Dec: 38}, End: &model.Coordinates{RA: 48, Dec: 28}},
+		// The same trail with its ends swapped.
+		{Start: &model.Coordinates{RA: 48, Dec: 28}, End: &model.Coordinates{RA: 48, Dec: 38}},
+		// Crossing the meridian at right angles.
+		{Start: &model.Coordinates{RA: 40, Dec: 30}, End: &model.Coordinates{RA: 56, Dec: 30}},
+		// A trail that already matched a satellite.
+		{
+			Start:      &model.Coordinates{RA: 48, Dec: 38},
+			End:        &model.Coordinates{RA: 48, Dec: 28},
+			Satellites: []model.SatelliteMatch{{Name: "ISS (ZARYA)"}},
+		},
+	}
+	NewService().Classify(streaks, observation)
+
+	want := []string{"Perseids", "Perseids", Sporadic, ""}
+	for i, streak := range streaks {
+		if streak.Shower != want[i] {
+			t.Errorf("streak %d: shower = %q, want %q", i, streak.Shower, want[i])
+		}
+	}
+}
+
+func TestAlignmentRejectsRadiantWithinStreak(t *testing.T) {
+	start := astro.Equatorial{RA: 48, Dec: 68}
+	end := astro.Equatorial{RA: 48, Dec: 48}
+	if _, ok := alignment(start, end, astro.Equatorial{RA: 48, Dec: 58}); ok {
+		t.Error("radiant between the endpoints accepted")
+	}
+}
@@ -0,0 +1,124 @@
+package meteor
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/astro"
+)
+
+//go:embed showers.txt
+var showersData string
+
+// Shower is an annual meteor shower. Dates are month and day; the active
+// period may run over the new year.
+type Shower struct {
+	Code     string
+	Name     string
+	Start    monthDay
+	End      monthDay
+	Peak     monthDay
+	Radiant  astro.Equatorial // J2000, at the peak
+	DriftRA  float64          // degrees per day
+	DriftDec float64          // degrees per day
+}
+
+type monthDay struct {
+	Month time.Month
+	Day   int
+}
+
+// in returns the date in the given year, at midnight UTC.
+func (d monthDay) in(year int) time.Time {
+	return time.Date(year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// Active reports whether the shower is active at t and, if so, returns its
+// radiant moved by the daily drift from the peak.
+func (s Shower) Active(t time.Time) (astro.Equatorial, bool) {
+	t = t.UTC()
+	for year := t.Year() - 1; year <= t.Year()+1; year++ {
+		peak := s.Peak.in(year)
+		start := s.Start.in(year)
+		if start.After(peak) {
+			start = s.Start.in(year - 1)
+		}
+		end := s.End.in(year)
+		if end.Before(peak) {
+			end = s.End.in(year + 1)
+		}
+		if t.Before(start) || !t.Before(end.AddDate(0, 0, 1)) {
+			continue
+		}
+
+		days := t.Sub(peak).Hours() / 24
+		return astro.Equatorial{
+			RA:  astro.Normalize(s.Radiant.RA + s.DriftRA*days),
+			Dec: s.Radiant.Dec + s.DriftDec*days,
+		}, true
+	}
+	return astro.Equatorial{}, false
+}
+
+// Showers returns the bundled shower table.
+func Showers() []Shower {
+	return showers
+}
+
+var showers = parseShowers(showersData)
+
+func parseShowers(text string) []Shower {
+	var list []Shower
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if len(parts) < 9 {
+			continue
+		}
+		start, okStart := parseMonthDay(parts[2])
+		end, okEnd := parseMonthDay(parts[3])
+		peak, okPeak := parseMonthDay(parts[4])
+		numbers, okNumbers := parseFloats(parts[5:9])
+		if !okStart || !okEnd || !okPeak || !okNumbers {
+			continue
+		}
+
+		list = append(list, Shower{
+			Code:     parts[0],
+			Name:     parts[1],
+			Start:    start,
+			End:      end,
+			Peak:     peak,
+			Radiant:  astro.Equatorial{RA: numbers[0], Dec: numbers[1]},
+			DriftRA:  numbers[2],
+			DriftDec: numbers[3],
+		})
+	}
+	return list
+}
+
+func parseMonthDay(s string) (monthDay, bool) {
+	t, err := time.Parse("01-02", strings.TrimSpace(s))
+	if err != nil {
+		return monthDay{}, false
+	}
+	return monthDay{Month: t.Month(), Day: t.Day()}, true
+}
+
+func parseFloats(fields []string) ([]float64, bool) {
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
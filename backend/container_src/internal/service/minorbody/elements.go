@@ -0,0 +1,188 @@
+package minorbody
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/astro"
+)
+
+const (
+	KindComet    = "comet"
+	KindAsteroid = "asteroid"
+)
+
+// Body is one comet or numbered asteroid with its osculating orbit.
+// Magnitude parameters follow the MPC: for asteroids H and the slope G of
+// the H-G system, for comets the total magnitude H and the activity
+// coefficient K in m = H + 5 log Δ + 2.5 K log r.
+type Body struct {
+	Name  string
+	Kind  string
+	Orbit astro.Orbit
+	H     float64
+	Slope float64
+}
+
+// Parse reads MPC one-line orbital elements: MPCORB.DAT lines for
+// asteroids and CometEls.txt lines for comets, mixed in any order. Headers,
+// unnumbered asteroids and lines without an absolute magnitude are skipped.
+func Parse(data []byte) ([]Body, error) {
+	var bodies []Body
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 512), 4096)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if body, ok := parseAsteroid(line); ok {
+			bodies = append(bodies, body)
+		} else if body, ok := parseComet(line); ok {
+			bodies = append(bodies, body)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read orbital elements: %w", err)
+	}
+	return bodies, nil
+}
+
+// earthAphelion is the Earth's greatest distance from the Sun in AU.
+const earthAphelion = 1.0167
+
+// brightest returns the brightest magnitude the body can reach seen from
+// Earth: at perihelion, with Earth as close as its orbit allows and no phase
+// darkening. It reports false when there is no useful bound, for orbits
+// reaching inside Earth's and for comets that fade toward the Sun.
+func (b Body) brightest() (float64, bool) {
+	q := b.Orbit.PerihelionDistance
+	if q <= earthAphelion || (b.Kind == KindComet && b.Slope < 0) {
+		return 0, false
+	}
+	delta := q - earthAphelion
+	if b.Kind == KindComet {
+		return b.H + 5*math.Log10(delta) + 2.5*b.Slope*math.Log10(q), true
+	}
+	return b.H + 5*math.Log10(q*delta), true
+}
+
+// parseAsteroid reads an MPCORB.DAT line (columns per the MPC's "Export
+// Format for Minor-Planet Orbits").
+func parseAsteroid(line string) (Body, bool) {
+	if len(line) < 103 || strings.ContainsAny(line[:1], "-#") {
+		return Body{}, false
+	}
+	// Numbered asteroids use five-character packed designations.
+	if len(strings.TrimSpace(line[0:7])) != 5 {
+		return Body{}, false
+	}
+
+	epoch, ok := unpackEpoch(line[20:25])
+	if !ok {
+		return Body{}, false
+	}
+
+	var f fields
+	h := f.float(line[8:13])
+	g := f.float(line[14:19])
+	m := f.float(line[26:35])
+	peri := f.float(line[37:46])
+	node := f.float(line[48:57])
+	incl := f.float(line[59:68])
+	e := f.float(line[70:79])
+	a := f.float(line[92:103])
+	if f.err != nil || a <= 0 || e >= 1 {
+		return Body{}, false
+	}
+
+	name := strings.TrimSpace(line[0:7])
+	if len(line) >= 175 {
+		name = strings.TrimSpace(line[166:min(len(line), 194)])
+	}
+	return Body{
+		Name:  name,
+		Kind:  KindAsteroid,
+		Orbit: astro.EllipticOrbit(a, e, incl, node, peri, m, epoch),
+		H:     h,
+		Slope: g,
+	}, true
+}
+
+// parseComet reads a CometEls.txt line (columns per the MPC's "Format of
+// the Orbital Elements of Comets").
+func parseComet(line string) (Body, bool) {
+	if len(line) < 103 || !strings.ContainsAny(line[4:5], "CPDXI") {
+		return Body{}, false
+	}
+
+	var f fields
+	year := f.float(line[14:18])
+	month := f.float(line[19:21])
+	day := f.float(line[22:29])
+	q := f.float(line[30:39])
+	e := f.float(line[41:49])
+	peri := f.float(line[51:59])
+	node := f.float(line[61:69])
+	incl := f.float(line[71:79])
+	h := f.float(line[91:95])
+	k := f.float(line[96:100])
+	if f.err != nil || q <= 0 {
+		return Body{}, false
+	}
+
+	start := time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return Body{
+		Name: strings.TrimSpace(line[102:min(len(line), 158)]),
+		Kind: KindComet,
+		Orbit: astro.Orbit{
+			PerihelionDistance: q,
+			Eccentricity:       e,
+			Inclination:        incl,
+			Node:               node,
+			ArgPerihelion:      peri,
+			PerihelionTime:     astro.JulianDate(start) + day - 1,
+		},
+		H:     h,
+		Slope: k,
+	}, true
+}
+
+// unpackEpoch decodes a packed MPC date such as K2555 (2025 May 5) to a
+// Julian Date at 0h TT.
+func unpackEpoch(packed string) (float64, bool) {
+	century := strings.Index("IJK", packed[:1])
+	year, err := strconv.Atoi(packed[1:3])
+	month, day := packedDigit(packed[3]), packedDigit(packed[4])
+	if century < 0 || err != nil || month < 1 || month > 12 || day < 1 {
+		return 0, false
+	}
+	return astro.JulianDate(time.Date(1800+100*century+year, time.Month(month), day, 0, 0, 0, 0, time.UTC)), true
+}
+
+// packedDigit decodes 1-9 and A-V (10-31).
+func packedDigit(c byte) int {
+	switch {
+	case c >= '1' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'V':
+		return int(c-'A') + 10
+	default:
+		return 0
+	}
+}
+
+// fields parses fixed-width numbers, keeping the first error.
+type fields struct {
+	err error
+}
+
+func (f *fields) float(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil && f.err == nil {
+		f.err = fmt.Errorf("invalid field %q: %w", s, err)
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package minorbody
+
+import (
+	"bufio"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"server/internal/astro"
+)
+
+// ceres is the MPC's sample MPCORB.DAT line and halley a CometEls.txt line.
+const (
+	ceres  = "00001    3.34  0.12 K205V 162.68631   73.73161   80.28698   10.58862  0.0775571  0.21406009   2.7676569  0 MPO492748  6751 115 1801-2019 0.60 M-v 30h Williams   0000 (1) Ceres                   20190915"
+	halley = "0001P         1986 02  9.4589  0.587104  0.967143  111.8657   58.8601  162.2422  19860219   5.5  8.0  1P/Halley                                                98, 883"
+)
+
+func assertNear(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := strings.Join([]string{
+		"MINOR PLANET CENTER ORBIT DATABASE (MPCORB)",
+		"--------------------------------------------------------------------------------------------------------",
+		ceres,
+		halley,
+		"",
+	}, "\n")
+	bodies, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("Parse returned %d bodies, want 2", len(bodies))
+	}
+
+	c := bodies[0]
+	if c.Name != "(1) Ceres" || c.Kind != KindAsteroid {
+		t.Errorf("first body = %q (%s), want (1) Ceres (asteroid)", c.Name, c.Kind)
+	}
+	assertNear(t, "Ceres H", c.H, 3.34, 1e-9)
+	assertNear(t, "Ceres G", c.Slope, 0.12, 1e-9)
+	assertNear(t, "Ceres q", c.Orbit.PerihelionDistance, 2.7676569*(1-0.0775571), 1e-9)
+	assertNear(t, "Ceres i", c.Orbit.Inclination, 10.58862, 1e-9)
+	// Epoch K205V is 2020 May 31; mean anomaly 162.68631° at 0.21406009°/day.
+	epoch := astro.JulianDate(time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC))
+	assertNear(t, "Ceres perihelion", c.Orbit.PerihelionTime, epoch-162.68631/0.21406009, 0.5)
+
+	h := bodies[1]
+	if h.Name != "1P/Halley" || h.Kind != KindComet {
+		t.Errorf("second body = %q (%s), want 1P/Halley (comet)", h.Name, h.Kind)
+	}
+	assertNear(t, "Halley H", h.H, 5.5, 1e-9)
+	assertNear(t, "Halley K", h.Slope, 8, 1e-9)
+	assertNear(t, "Halley q", h.Orbit.PerihelionDistance, 0.587104, 1e-9)
+	assertNear(t, "Halley e", h.Orbit.Eccentricity, 0.967143, 1e-9)
+	// 1986 February 9.4589 TT.
+	assertNear(t, "Halley perihelion", h.Orbit.PerihelionTime, 2446470.9589, 1e-6)
+}
+
+func TestParseReportsScannerError(t *testing.T) {
+	data := ceres + "\n" + strings.Repeat("x", 5000) + "\n"
+	if _, err := Parse([]byte(data)); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Parse error = %v, want bufio.ErrTooLong", err)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	bodies, err := Parse([]byte(ceres + "\n" + halley))
+	if err != nil {
+		t.Fatal(err)
+	}
+	faint := bodies[0]
+	faint.Name, faint.H = "faint main belt", 15
+	nearEarth := faint
+	nearEarth.Name, nearEarth.Orbit.PerihelionDistance = "near Earth", 0.9
+	bodies = append(bodies, faint, nearEarth)
+
+	var names []string
+	for _, b := range candidates(bodies, 14) {
+		names = append(names, b.Name)
+	}
+	// Ceres reaches about 6.3; the faint asteroid never gets past 18.
+	if got := strings.Join(names, ", "); got != "(1) Ceres, 1P/Halley, near Earth" {
+		t.Errorf("candidates = %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package minorbody
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"server/internal/apperr"
+	"server/internal/astro"
+	"server/internal/model"
+	"server/internal/wcs"
+)
+
+// markerRadius is the footprint radius in pixels given to minor bodies,
+// which are point sources at any practical image scale.
+const markerRadius = 10.0
+
+type Summary struct {
+	Comets    int
+	Asteroids int
+	UpdatedAt time.Time
+	// Persisted is false when no elements file is configured and the
+	// refreshed elements only live until the server restarts.
+	Persisted bool
+}
+
+type Service struct {
+	path           string
+	limitMagnitude float64
+
+	mu     sync.RWMutex
+	bodies []Body
+	// candidates are the bodies that can ever reach limitMagnitude, the only
+	// ones InField propagates.
+	candidates []Body
+	updated    time.Time
+}
+
+// NewService loads elements from path, if set. A missing or unreadable file
+// is logged and leaves the service empty until an admin refreshes it.
+func NewService(path string, limitMagnitude float64) *Service {
+	s := &Service{path: path, limitMagnitude: limitMagnitude}
+	if path == "" {
+		return s
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Minor body elements not loaded: %v", err)
+		return s
+	}
+	bodies, err := Parse(data)
+	if err != nil {
+		log.Printf("Minor body elements not loaded: %v", err)
+		return s
+	}
+	s.bodies, s.candidates = bodies, candidates(bodies, limitMagnitude)
+	if info, err := os.Stat(path); err == nil {
+		s.updated = info.ModTime().UTC()
+	}
+	log.Printf("Loaded %d minor bodies from %s", len(s.bodies), path)
+	return s
+}
+
+// Refresh replaces the elements with an uploaded MPC file, writing it to
+// the configured path so it survives a restart.
+func (s *Service) Refresh(ctx context.Context, data []byte) (*Summary, error) {
+	bodies, err := Parse(data)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInvalidRequest, "Invalid MPC orbital elements", err)
+	}
+	if len(bodies) == 0 {
+		return nil, apperr.New(apperr.CodeInvalidRequest, "No MPC orbital elements found in upload")
+	}
+
+	if s.path != "" {
+		if err := writeFile(s.path, data); err != nil {
+			return nil, apperr.Wrap(apperr.CodeInternal, "Failed to save orbital elements", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.bodies, s.candidates = bodies, candidates(bodies, s.limitMagnitude)
+	s.updated = time.Now().UTC()
+	s.mu.Unlock()
+
+	summary := s.Summary()
+	return &summary, nil
+}
+
+func (s *Service) Summary() Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := Summary{UpdatedAt: s.updated, Persisted: s.path != ""}
+	for _, b := range s.bodies {
+		if b.Kind == KindComet {
+			summary.Comets++
+		} else {
+			summary.Asteroids++
+		}
+	}
+	return summary
+}
+
+// InField returns the comets and asteroids brighter than the limiting
+// magnitude that fall inside a solved image at the given time. Positions
+// are geocentric, which is close enough for anything not passing Earth.
+func (s *Service) InField(field *wcs.WCS, at time.Time) []model.CelestialObject {
+	s.mu.RLock()
+	bodies := s.candidates
+	s.mu.RUnlock()
+
+	jd := astro.JulianDate(at)
+	_, sunDistance := astro.SunEcliptic(jd)
+
+	var objects []model.CelestialObject
+	for _, b := range bodies {
+		eq, delta, r := b.Orbit.Position(jd)
+		x, y, ok := field.SkyToPixel(eq.RA, eq.Dec)
+		if !ok || !field.Contains(x, y) || b.magnitude(r, delta, sunDistance) > s.limitMagnitude {
+			continue
+		}
+
+		objects = append(objects, model.CelestialObject{
+			Name:        b.Name,
+			Type:        b.Kind,
+			PixelX:      &x,
+			PixelY:      &y,
+			Footprint:   &model.Footprint{PixelX: x, PixelY: y, Radius: markerRadius},
+			Coordinates: &model.Coordinates{RA: eq.RA, Dec: eq.Dec},
+		})
+	}
+	return objects
+}
+
+// candidates returns the bodies whose brightest possible magnitude reaches
+// limit, dropping the great majority of asteroids, which never do.
+func candidates(bodies []Body, limit float64) []Body {
+	var kept []Body
+	for _, b := range bodies {
+		if brightest, ok := b.brightest(); !ok || brightest <= limit {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+// magnitude returns the predicted visual magnitude r AU from the Sun and
+// delta AU from Earth.
+func (b Body) magnitude(r, delta, sunDistance float64) float64 {
+	if b.Kind == KindComet {
+		return b.H + 5*math.Log10(delta) + 2.5*b.Slope*math.Log10(r)
+	}
+
+	// The IAU H-G system (Bowell et al. 1989).
+	half := math.Tan(astro.PhaseAngle(r, delta, sunDistance) * math.Pi / 360)
+	phi1 := math.Exp(-3.33 * math.Pow(half, 0.63))
+	phi2 := math.Exp(-1.87 * math.Pow(half, 1.22))
+	return b.H + 5*math.Log10(r*delta) - 2.5*math.Log10((1-b.Slope)*phi1+b.Slope*phi2)
+}
+
+// writeFile replaces path atomically so a crash never leaves half a file.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
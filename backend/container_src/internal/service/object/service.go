@@ -19,9 +19,13 @@ type GeminiClient interface {
 
 type ObjectDetail struct {
 	Name          string
+	DisplayName   string
 	Type          string
 	Constellation string
 	FunFact       string
+	// Generated is set when the fun fact was written for this request
+	// rather than read from the cache.
+	Generated bool
 }
 
 type Service struct {
@@ -39,35 +43,37 @@ func (s *Service) GetObjectDetail(ctx context.Context, name string) (*ObjectDeta
 		return nil, nil
 	}
 
-	funFact, err := s.getFunFact(ctx, obj.Name, obj.GetDisplayName(), obj.Type)
+	funFact, generated, err := s.getFunFact(ctx, obj.Name, obj.GetDisplayName(), obj.Type)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ObjectDetail{
 		Name:          obj.Name,
+		DisplayName:   obj.GetDisplayName(),
 		Type:          obj.Type,
 		Constellation: obj.Constellation,
 		FunFact:       funFact,
+		Generated:     generated,
 	}, nil
 }
 
-func (s *Service) getFunFact(ctx context.Context, name, displayName, objectType string) (string, error) {
+func (s *Service) getFunFact(ctx context.Context, name, displayName, objectType string) (string, bool, error) {
 	cacheKey := strings.ToLower(name)
 	cached, found, err := s.kvClient.Get(ctx, cacheKey)
 	if err != nil {
 		log.Printf("KV read failed for %s: %v", cacheKey, err)
 	} else if found {
-		return cached, nil
+		return cached, false, nil
 	}
 
 	funFact, err := s.geminiClient.GenerateFunFact(ctx, displayName, objectType)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	if err := s.kvClient.Put(ctx, cacheKey, funFact); err != nil {
 		log.Printf("KV write failed for %s: %v", cacheKey, err)
 	}
-	return funFact, nil
+	return funFact, true, nil
 }
@@ -0,0 +1,94 @@
+// Package observinglist builds observing lists from solve results and
+// tonight's recommendations, filling in catalog metadata for export.
+package observinglist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"server/internal/apperr"
+	"server/internal/export"
+	"server/internal/model/data"
+	"server/internal/service/solve"
+	"server/internal/service/tonight"
+)
+
+type SolveResults interface {
+	GetJobStatus(ctx context.Context, subID int) (*solve.JobStatus, error)
+}
+
+type Recommender interface {
+	Recommend(q tonight.Query) *tonight.Night
+}
+
+type Service struct {
+	solves  SolveResults
+	tonight Recommender
+	now     func() time.Time
+}
+
+func NewService(solves SolveResults, tonight Recommender) *Service {
+	return &Service{solves: solves, tonight: tonight, now: time.Now}
+}
+
+// FromSolve lists the objects identified in a solved image that have
+// known coordinates, with magnitudes and sizes from the catalog.
+func (s *Service) FromSolve(ctx context.Context, subID int) (*export.List, error) {
+	status, err := s.solves.GetJobStatus(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Job not found")
+	}
+	if status.Result == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Solve result not available").WithDetail("status", status.Status)
+	}
+
+	list := &export.List{Name: fmt.Sprintf("StarSeek solve %d", subID), Created: s.now()}
+	for _, o := range status.Result.Objects {
+		if o.Coordinates == nil {
+			continue
+		}
+		item := export.ListItem{
+			Name:          o.Name,
+			DisplayName:   o.DisplayName,
+			Type:          o.Type,
+			Constellation: o.Constellation,
+			RA:            o.Coordinates.RA,
+			Dec:           o.Coordinates.Dec,
+		}
+		if info, ok := data.GetObjectInfo(o.Name); ok && info.Position != nil {
+			item.Magnitude = info.Position.Magnitude
+			item.Size = info.Position.Size
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+// FromTonight lists tonight's recommendations, grouped by type as
+// Recommend returns them.
+func (s *Service) FromTonight(q tonight.Query) *export.List {
+	night := s.tonight.Recommend(q)
+	list := &export.List{
+		Name:    fmt.Sprintf("StarSeek tonight %s", night.Date.Format(time.DateOnly)),
+		Created: s.now(),
+	}
+	for _, g := range night.Groups {
+		for _, r := range g.Objects {
+			list.Items = append(list.Items, export.ListItem{
+				Name:          r.Name,
+				DisplayName:   r.DisplayName,
+				Type:          r.Type,
+				Constellation: r.Constellation,
+				RA:            r.RA,
+				Dec:           r.Dec,
+				Magnitude:     r.Magnitude,
+				Size:          r.Size,
+			})
+		}
+	}
+	return list
+}
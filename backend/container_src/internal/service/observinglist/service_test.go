@@ -0,0 +1,136 @@
+package observinglist
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"server/internal/apperr"
+	"server/internal/model"
+	"server/internal/model/data"
+	"server/internal/service/solve"
+	"server/internal/service/tonight"
+)
+
+type fakeSolves map[int]*solve.JobStatus
+
+func (f fakeSolves) GetJobStatus(ctx context.Context, subID int) (*solve.JobStatus, error) {
+	return f[subID], nil
+}
+
+type fakeRecommender struct {
+	night *tonight.Night
+}
+
+func (f fakeRecommender) Recommend(q tonight.Query) *tonight.Night {
+	return f.night
+}
+
+func newTestService(solves SolveResults, recommender Recommender) *Service {
+	s := NewService(solves, recommender)
+	s.now = func() time.Time { return time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func isNotFound(err error) bool {
+	var appErr *apperr.Error
+	return errors.As(err, &appErr) && appErr.Code == apperr.CodeNotFound
+}
+
+func TestFromSolve(t *testing.T) {
+	solves := fakeSolves{
+		1: {Status: solve.StatusSuccess, Result: &model.SolveResult{Objects: []model.CelestialObject{
+			{Name: "M42", DisplayName: "Orion Nebula", Type: "nebula", Coordinates: &model.Coordinates{RA: 83.82, Dec: -5.39}},
+			{Name: "Unplaced label"},
+			{Name: "Unknown 1", Type: "star", Coordinates: &model.Coordinates{RA: 10, Dec: 20}},
+		}}},
+		2: {Status: solve.StatusFailed},
+	}
+	s := newTestService(solves, nil)
+
+	list, err := s.FromSolve(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Name != "StarSeek solve 1" || !list.Created.Equal(s.now()) {
+		t.Errorf("list = %q created %v", list.Name, list.Created)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("%d items, want the 2 with coordinates", len(list.Items))
+	}
+
+	m42, unknown := list.Items[0], list.Items[1]
+	info, ok := data.GetObjectInfo("M42")
+	if !ok || info.Position == nil || info.Position.Magnitude == nil {
+		t.Fatal("M42 missing from the catalog")
+	}
+	if m42.Name != "M42" || m42.DisplayName != "Orion Nebula" || m42.RA != 83.82 || m42.Dec != -5.39 {
+		t.Errorf("M42 item = %+v", m42)
+	}
+	if m42.Magnitude == nil || *m42.Magnitude != *info.Position.Magnitude || m42.Size != info.Position.Size {
+		t.Errorf("M42 magnitude and size = %v, %g; want the catalog's %v, %g", m42.Magnitude, m42.Size, *info.Position.Magnitude, info.Position.Size)
+	}
+	if unknown.Magnitude != nil || unknown.Size != 0 {
+		t.Errorf("uncatalogued item = %+v, want no magnitude or size", unknown)
+	}
+
+	for name, subID := range map[string]int{"unknown job": 3, "job without a result": 2} {
+		if _, err := s.FromSolve(context.Background(), subID); !isNotFound(err) {
+			t.Errorf("%s: err = %v, want not found", name, err)
+		}
+	}
+}
+
+func TestFromTonight(t *testing.T) {
+	date := time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC)
+	mag := 3.4
+	tests := []struct {
+		name  string
+		night *tonight.Night
+		want  []string
+	}{
+		{
+			"groups in order",
+			&tonight.Night{Date: date, Darkness: &tonight.Window{}, Groups: []tonight.Group{
+				{Type: "galaxy", Objects: []tonight.Recommendation{{Name: "M31", Type: "galaxy", Magnitude: &mag}, {Name: "M33", Type: "galaxy"}}},
+				{Type: "cluster", Objects: []tonight.Recommendation{{Name: "M45", Type: "cluster"}}},
+			}},
+			[]string{"M31", "M33", "M45"},
+		},
+		{
+			"no astronomical darkness",
+			&tonight.Night{Date: date, NoAstronomicalDarkness: true, Groups: []tonight.Group{}},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := newTestService(nil, fakeRecommender{tt.night}).FromTonight(tonight.Query{Date: date})
+			if list.Name != "StarSeek tonight 2026-06-21" {
+				t.Errorf("name = %q", list.Name)
+			}
+			var names []string
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("items = %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Errorf("items = %v, want %v", names, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	// The real recommender finds no darkness in London at midsummer.
+	list := newTestService(nil, tonight.NewService()).FromTonight(tonight.Query{
+		Latitude: 51.5, Longitude: -0.13, Date: date, MinAltitude: tonight.DefaultMinAltitude, Limit: tonight.DefaultLimit,
+	})
+	if len(list.Items) != 0 {
+		t.Errorf("midsummer list has %d items, want none", len(list.Items))
+	}
+}
@@ -0,0 +1,224 @@
+package satellite
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"server/internal/astro"
+	"server/internal/model"
+	"server/internal/sgp4"
+	"server/internal/wcs"
+)
+
+const (
+	// The capture time may be the start or the end of the exposure and
+	// camera clocks drift, so tracks are searched around it.
+	windowBefore = 3 * time.Minute
+	windowAfter  = 3 * time.Minute
+	coarseStep   = 10 * time.Second
+	fineStep     = time.Second
+	// coarseMargin covers how far a low satellite can move between coarse
+	// samples, in degrees.
+	coarseMargin = 12.0
+
+	// maxOffset is how far, in degrees, a predicted track may run beside a
+	// streak; element sets a few days old are off by about this much.
+	maxOffset = 0.5
+	// maxAngle is how far, in degrees, the track's direction may differ
+	// from the streak's.
+	maxAngle      = 10.0
+	maxCandidates = 3
+
+	// WGS-84 ellipsoid.
+	earthRadiusKm = 6378.137
+	flattening    = 1 / 298.257223563
+)
+
+type Service struct {
+	satellites []*sgp4.Satellite
+}
+
+// NewService loads element sets from path, if set. Deep-space orbits, which
+// SGP4 alone cannot propagate, are left out; they rarely trail visibly.
+// Element sets SGP4 rejects as invalid are logged and left out too.
+func NewService(path string) *Service {
+	s := &Service{}
+	if path == "" {
+		return s
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Satellite elements not loaded: %v", err)
+		return s
+	}
+
+	deepSpace, invalid := 0, 0
+	for _, tle := range sgp4.ParseTLEs(data) {
+		sat, err := sgp4.New(tle)
+		switch {
+		case errors.Is(err, sgp4.ErrDeepSpace):
+			deepSpace++
+		case err != nil:
+			log.Printf("Skipping satellite %q (%d): %v", tle.Name, tle.NoradID, err)
+			invalid++
+		default:
+			s.satellites = append(s.satellites, sat)
+		}
+	}
+	log.Printf("Loaded %d satellites from %s (%d deep-space skipped, %d invalid)", len(s.satellites), path, deepSpace, invalid)
+	return s
+}
+
+// Identify fills in the satellites whose predicted track runs along each
+// streak. It needs the plate solution, the capture time and the observer's
+// location; without them streaks are left unmatched.
+func (s *Service) Identify(streaks []model.Streak, field *wcs.WCS, observation *model.Observation) {
+	if len(streaks) == 0 || len(s.satellites) == 0 || field == nil ||
+		observation == nil || observation.CapturedAt == nil || observation.Location == nil {
+		return
+	}
+
+	observer := astro.Observer{Latitude: observation.Location.Latitude, Longitude: observation.Location.Longitude}
+	if observation.Location.Elevation != nil {
+		observer.Elevation = *observation.Location.Elevation
+	}
+	at := *observation.CapturedAt
+	jd := astro.JulianDate(at)
+
+	// Coarse filtering happens in the frame of date, which is what SGP4
+	// positions are in; only samples near the field are converted to J2000.
+	centerRA, centerDec := field.PixelToSky(float64(field.ImageWidth-1)/2, float64(field.ImageHeight-1)/2)
+	center := astro.ApparentPlace(astro.Equatorial{RA: centerRA, Dec: centerDec}, jd)
+	scale := field.PixelScale() / 3600
+	radius := math.Hypot(float64(field.ImageWidth), float64(field.ImageHeight)) / 2 * scale
+	tolerance := max(maxOffset/scale, 10)
+
+	for _, sat := range s.satellites {
+		if !nearField(sat, observer, at, center, radius+coarseMargin) {
+			continue
+		}
+
+		track := projectTrack(sat, observer, at, field)
+		for i := range streaks {
+			if offset, ok := follows(track, streaks[i], tolerance); ok {
+				streaks[i].Satellites = append(streaks[i].Satellites, model.SatelliteMatch{
+					Name:    sat.TLE.Name,
+					NoradID: sat.TLE.NoradID,
+					Offset:  offset * scale,
+				})
+			}
+		}
+	}
+
+	for i := range streaks {
+		matches := streaks[i].Satellites
+		sort.Slice(matches, func(a, b int) bool { return matches[a].Offset < matches[b].Offset })
+		streaks[i].Satellites = matches[:min(len(matches), maxCandidates)]
+	}
+}
+
+// nearField reports whether the satellite comes within radius degrees of
+// the field centre, above the horizon, at any coarse sample.
+func nearField(sat *sgp4.Satellite, observer astro.Observer, at time.Time, center astro.Equatorial, radius float64) bool {
+	for t := at.Add(-windowBefore); !t.After(at.Add(windowAfter)); t = t.Add(coarseStep) {
+		eq, ok := topocentric(sat, observer, t)
+		if ok && astro.Separation(eq, center) <= radius {
+			return true
+		}
+	}
+	return false
+}
+
+// projectTrack samples the satellite's path across the window in image
+// pixels, leaving out samples where it is below the horizon or behind the
+// tangent plane.
+func projectTrack(sat *sgp4.Satellite, observer astro.Observer, at time.Time, field *wcs.WCS) [][2]float64 {
+	var track [][2]float64
+	for t := at.Add(-windowBefore); !t.After(at.Add(windowAfter)); t = t.Add(fineStep) {
+		eq, ok := topocentric(sat, observer, t)
+		if !ok {
+			continue
+		}
+		j2000 := astro.CatalogPlace(eq, astro.JulianDate(t))
+		if x, y, ok := field.SkyToPixel(j2000.RA, j2000.Dec); ok {
+			track = append(track, [2]float64{x, y})
+		}
+	}
+	return track
+}
+
+// follows measures how closely consecutive track samples run along a
+// streak, returning the mean perpendicular offset in pixels.
+func follows(track [][2]float64, streak model.Streak, tolerance float64) (float64, bool) {
+	dx, dy := streak.X2-streak.X1, streak.Y2-streak.Y1
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return 0, false
+	}
+	ux, uy := dx/length, dy/length
+	minCos := math.Cos(maxAngle * math.Pi / 180)
+
+	var total float64
+	matched := 0
+	for i := 1; i < len(track); i++ {
+		px, py := track[i][0]-streak.X1, track[i][1]-streak.Y1
+		along := px*ux + py*uy
+		offset := math.Abs(px*uy - py*ux)
+		if offset > tolerance || along < -0.1*length || along > 1.1*length {
+			continue
+		}
+
+		mx, my := track[i][0]-track[i-1][0], track[i][1]-track[i-1][1]
+		step := math.Hypot(mx, my)
+		if step == 0 || math.Abs(mx*ux+my*uy)/step < minCos {
+			continue
+		}
+		total += offset
+		matched++
+	}
+
+	if matched < 2 {
+		return 0, false
+	}
+	return total / float64(matched), true
+}
+
+// topocentric returns the satellite's position of date as seen by the
+// observer, and whether it is above the horizon.
+func topocentric(sat *sgp4.Satellite, observer astro.Observer, t time.Time) (astro.Equatorial, bool) {
+	r, err := sat.PositionAt(t)
+	if err != nil {
+		return astro.Equatorial{}, false
+	}
+
+	jd := astro.JulianDate(t)
+	o := observerPosition(observer, astro.MeanSiderealTime(jd))
+	x, y, z := r[0]-o[0], r[1]-o[1], r[2]-o[2]
+	distance := math.Sqrt(x*x + y*y + z*z)
+	eq := astro.Equatorial{
+		RA:  astro.Normalize(math.Atan2(y, x) * 180 / math.Pi),
+		Dec: math.Asin(z/distance) * 180 / math.Pi,
+	}
+	return eq, astro.ToHorizontal(eq, observer, jd).Altitude > 0
+}
+
+// observerPosition returns the observer's position in kilometres in the
+// TEME frame, rotating the geodetic position by Greenwich sidereal time.
+func observerPosition(observer astro.Observer, gmst float64) [3]float64 {
+	lat := observer.Latitude * math.Pi / 180
+	theta := (gmst + observer.Longitude) * math.Pi / 180
+	e2 := flattening * (2 - flattening)
+	n := earthRadiusKm / math.Sqrt(1-e2*math.Sin(lat)*math.Sin(lat))
+	h := observer.Elevation / 1000
+
+	return [3]float64{
+		(n + h) * math.Cos(lat) * math.Cos(theta),
+		(n + h) * math.Cos(lat) * math.Sin(theta),
+		(n*(1-e2) + h) * math.Sin(lat),
+	}
+}
@@ -0,0 +1,285 @@
+package satellite
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"server/internal/astro"
+	"server/internal/model"
+	"server/internal/sgp4"
+	"server/internal/wcs"
+)
+
+// The ISS element set from 2008-09-20 used as the worked example in most
+// descriptions of the format.
+const (
+	issLine1 = "1 25544U 98067A   08264.51782528 -.00002182  00000-0 -11606-4 0  2927"
+	issLine2 = "2 25544  51.6416 247.4627 0006703 130.5360 325.0288 15.72125391563537"
+)
+
+// withMeanMotion replaces the revolutions per day in line 2.
+func withMeanMotion(line2, revs string) string {
+	return line2[:52] + revs + line2[63:]
+}
+
+func iss(t *testing.T) *sgp4.Satellite {
+	t.Helper()
+	tle, err := sgp4.ParseTLE("ISS (ZARYA)", issLine1, issLine2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sat, err := sgp4.New(tle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sat
+}
+
+// subpoint returns the point on the ground under the satellite at t.
+func subpoint(t *testing.T, sat *sgp4.Satellite, at time.Time) astro.Observer {
+	t.Helper()
+	r, err := sat.PositionAt(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gmst := astro.MeanSiderealTime(astro.JulianDate(at))
+	lat := math.Atan2(r[2], math.Hypot(r[0], r[1])) * 180 / math.Pi
+	lon := astro.NormalizeSigned(math.Atan2(r[1], r[0])*180/math.Pi - gmst)
+	return astro.Observer{Latitude: lat, Longitude: lon}
+}
+
+func TestNewServiceSkipsDeepSpaceAndInvalidElements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elements.txt")
+	data := strings.Join([]string{
+		"ISS (ZARYA)", issLine1, issLine2,
+		"GPS-LIKE", issLine1, withMeanMotion(issLine2, " 2.00563452"),
+		"STOPPED", issLine1, withMeanMotion(issLine2, " 0.00000000"),
+	}, "\n")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	s := NewService(path)
+	if len(s.satellites) != 1 || s.satellites[0].TLE.Name != "ISS (ZARYA)" {
+		t.Errorf("loaded %d satellites, want only the ISS", len(s.satellites))
+	}
+	if !strings.Contains(logs.String(), "1 deep-space skipped, 1 invalid") {
+		t.Errorf("log = %q, want deep-space and invalid sets counted apart", logs.String())
+	}
+	if !strings.Contains(logs.String(), `"STOPPED"`) {
+		t.Errorf("log = %q, want the invalid set named", logs.String())
+	}
+}
+
+func TestObserverPosition(t *testing.T) {
+	const polarRadius = 6356.752314
+	tests := []struct {
+		name     string
+		observer astro.Observer
+		gmst     float64
+		want     [3]float64
+	}{
+		{"equator at Greenwich", astro.Observer{}, 0, [3]float64{earthRadiusKm, 0, 0}},
+		{"equator a quarter turn later", astro.Observer{}, 90, [3]float64{0, earthRadiusKm, 0}},
+		{"east longitude adds to sidereal time", astro.Observer{Longitude: 45}, 45, [3]float64{0, earthRadiusKm, 0}},
+		{"north pole", astro.Observer{Latitude: 90}, 123, [3]float64{0, 0, polarRadius}},
+		{"south pole", astro.Observer{Latitude: -90}, 0, [3]float64{0, 0, -polarRadius}},
+		{"elevation in metres", astro.Observer{Elevation: 2000}, 0, [3]float64{earthRadiusKm + 2, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := observerPosition(tt.observer, tt.gmst)
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-3 {
+					t.Errorf("position = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	// At 45° the geodetic normal misses the centre, so the position sits
+	// slightly closer to the equator than a sphere would put it.
+	p := observerPosition(astro.Observer{Latitude: 45}, 0)
+	if geocentric := math.Atan2(p[2], p[0]) * 180 / math.Pi; math.Abs(geocentric-44.8076) > 1e-3 {
+		t.Errorf("geocentric latitude = %.4f, want 44.8076", geocentric)
+	}
+}
+
+func TestTopocentric(t *testing.T) {
+	sat := iss(t)
+	at := sat.TLE.Epoch.Add(10 * time.Minute)
+	below := subpoint(t, sat, at)
+
+	eq, up := topocentric(sat, below, at)
+	if !up {
+		t.Fatal("ISS below the horizon from directly underneath it")
+	}
+	if altitude := astro.ToHorizontal(eq, below, astro.JulianDate(at)).Altitude; altitude < 85 {
+		t.Errorf("altitude from the subpoint = %.1f, want near the zenith", altitude)
+	}
+
+	antipode := astro.Observer{Latitude: -below.Latitude, Longitude: astro.NormalizeSigned(below.Longitude + 180)}
+	if _, up := topocentric(sat, antipode, at); up {
+		t.Error("ISS above the horizon from the far side of the Earth")
+	}
+}
+
+func TestFollows(t *testing.T) {
+	streak := model.Streak{X1: 100, Y1: 100, X2: 300, Y2: 100}
+	line := func(y0, slope float64, x0, x1 float64) [][2]float64 {
+		var track [][2]float64
+		for x := x0; x <= x1; x += 20 {
+			track = append(track, [2]float64{x, y0 + slope*(x-x0)})
+		}
+		return track
+	}
+
+	tests := []struct {
+		name   string
+		track  [][2]float64
+		streak model.Streak
+		offset float64
+		ok     bool
+	}{
+		{"along the streak", line(103, 0, 60, 340), streak, 3, true},
+		{"reversed direction", reverse(line(97, 0, 60, 340)), streak, 3, true},
+		{"beside the streak, past the tolerance", line(120, 0, 60, 340), streak, 0, false},
+		{"crossing at right angles", [][2]float64{{200, 0}, {200, 50}, {200, 100}, {200, 150}, {200, 200}}, streak, 0, false},
+		{"slightly tilted", line(98, math.Tan(5*math.Pi/180), 100, 200), streak, 0, true},
+		{"tilted past the angle gate", line(95, math.Tan(20*math.Pi/180), 100, 130), streak, 0, false},
+		{"beyond the streak's ends", line(100, 0, 400, 600), streak, 0, false},
+		{"a single matching sample", [][2]float64{{0, 100}, {200, 100}}, streak, 0, false},
+		{"zero-length streak", line(100, 0, 60, 340), model.Streak{X1: 100, Y1: 100, X2: 100, Y2: 100}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, ok := follows(tt.track, tt.streak, 10)
+			if ok != tt.ok {
+				t.Fatalf("follows = %.2f, %v; want ok = %v", offset, ok, tt.ok)
+			}
+			if ok && tt.offset != 0 && math.Abs(offset-tt.offset) > 1e-9 {
+				t.Errorf("offset = %g, want %g", offset, tt.offset)
+			}
+		})
+	}
+}
+
+func reverse(track [][2]float64) [][2]float64 {
+	out := make([][2]float64, len(track))
+	for i, p := range track {
+		out[len(track)-1-i] = p
+	}
+	return out
+}
+
+// passField places a field on the satellite's position at t, as seen by the
+// observer, and a streak along its track over two seconds.
+func passField(t *testing.T, sat *sgp4.Satellite, observer astro.Observer, at time.Time) (*wcs.WCS, model.Streak) {
+	t.Helper()
+	center, up := topocentric(sat, observer, at)
+	if !up {
+		t.Fatal("satellite below the horizon")
+	}
+	j2000 := astro.CatalogPlace(center, astro.JulianDate(at))
+	scale := 10.0 / 3600
+	field := &wcs.WCS{
+		CRVAL:       [2]float64{j2000.RA, j2000.Dec},
+		CRPIX:       [2]float64{1000.5, 750.5},
+		CD:          [2][2]float64{{-scale, 0}, {0, scale}},
+		ImageWidth:  2000,
+		ImageHeight: 1500,
+	}
+
+	var ends [2][2]float64
+	for i, dt := range []time.Duration{-time.Second, time.Second} {
+		eq, _ := topocentric(sat, observer, at.Add(dt))
+		p := astro.CatalogPlace(eq, astro.JulianDate(at.Add(dt)))
+		x, y, ok := field.SkyToPixel(p.RA, p.Dec)
+		if !ok {
+			t.Fatal("track end off the tangent plane")
+		}
+		ends[i] = [2]float64{x, y}
+	}
+	return field, model.Streak{X1: ends[0][0], Y1: ends[0][1], X2: ends[1][0], Y2: ends[1][1]}
+}
+
+func TestIdentifyKnownPass(t *testing.T) {
+	sat := iss(t)
+	at := sat.TLE.Epoch.Add(10 * time.Minute)
+	observer := subpoint(t, sat, at)
+	observer.Latitude += 3
+	field, streak := passField(t, sat, observer, at)
+
+	// Later in the same orbit the ISS is over the other side of the Earth.
+	elsewhere := *sat
+	elsewhere.TLE.Name, elsewhere.TLE.NoradID = "ELSEWHERE", 99999
+	elsewhere.TLE.Epoch = elsewhere.TLE.Epoch.Add(45 * time.Minute)
+
+	s := &Service{satellites: []*sgp4.Satellite{sat, &elsewhere}}
+	streaks := []model.Streak{streak, {X1: 10, Y1: 1400, X2: 60, Y2: 1450}}
+	s.Identify(streaks, field, &model.Observation{
+		CapturedAt: &at,
+		Location:   &model.Location{Latitude: observer.Latitude, Longitude: observer.Longitude},
+	})
+
+	matches := streaks[0].Satellites
+	if len(matches) != 1 || matches[0].NoradID != 25544 {
+		t.Fatalf("matches = %+v, want the ISS alone", matches)
+	}
+	if matches[0].Offset > 0.01 {
+		t.Errorf("offset = %g°, want the track on the streak", matches[0].Offset)
+	}
+	if len(streaks[1].Satellites) != 0 {
+		t.Errorf("unrelated streak matched %+v", streaks[1].Satellites)
+	}
+}
+
+func TestIdentifyKeepsClosestCandidates(t *testing.T) {
+	base := iss(t)
+	at := base.TLE.Epoch.Add(10 * time.Minute)
+	observer := subpoint(t, base, at)
+	observer.Latitude += 3
+	field, streak := passField(t, base, observer, at)
+
+	// Copies on planes a little further round each time run beside the
+	// streak at growing offsets.
+	s := &Service{}
+	for i := range maxCandidates + 2 {
+		tle := base.TLE
+		tle.Name, tle.NoradID = fmt.Sprintf("COPY %d", i), 90000+i
+		tle.Node += float64(i) * 0.001 * math.Pi / 180
+		sat, err := sgp4.New(tle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.satellites = append(s.satellites, sat)
+	}
+
+	streaks := []model.Streak{streak}
+	s.Identify(streaks, field, &model.Observation{
+		CapturedAt: &at,
+		Location:   &model.Location{Latitude: observer.Latitude, Longitude: observer.Longitude},
+	})
+
+	matches := streaks[0].Satellites
+	if len(matches) != maxCandidates {
+		t.Fatalf("%d matches, want the closest %d", len(matches), maxCandidates)
+	}
+	for i, m := range matches {
+		if m.NoradID != 90000+i {
+			t.Errorf("match %d = %s (offset %g°), want COPY %d", i, m.Name, m.Offset, i)
+		}
+	}
+}
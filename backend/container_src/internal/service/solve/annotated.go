@@ -0,0 +1,118 @@
+package solve
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"server/internal/apperr"
+	"server/internal/imaging"
+	"server/internal/render"
+	"server/internal/store"
+)
+
+// maxRenderBytes bounds the memory held by cached renders.
+const maxRenderBytes = 64 << 20
+
+// Annotated renders a solved job's original image with its objects,
+// compass and scale bar. Renders are cached per job, format and style.
+func (s *Service) Annotated(ctx context.Context, subID int, format render.Format, style render.Style) ([]byte, error) {
+	key := fmt.Sprintf("%d|%s|%s", subID, format, style.Key())
+	if data, ok := s.renders.get(key); ok {
+		return data, nil
+	}
+
+	status, err := s.GetJobStatus(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Job not found")
+	}
+	if status.Result == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Plate solution not available").WithDetail("status", status.Status)
+	}
+
+	original, err := s.images.Get(ctx, subID, store.ImageOriginal)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to load image", err)
+	}
+	if original == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Image not found")
+	}
+
+	img, err := imaging.Decode(original)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to decode stored image", err)
+	}
+	data, err := render.Encode(render.Annotate(img, status.Result, style), format)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to encode annotated image", err)
+	}
+	s.renders.put(key, data)
+	return data, nil
+}
+
+// ForgetRenders drops a job's cached renders, as when it is deleted.
+func (s *Service) ForgetRenders(subID int) {
+	s.renders.forget(fmt.Sprintf("%d|", subID))
+}
+
+// renderCache keeps the most recent renders up to maxRenderBytes in total,
+// evicting the oldest first.
+type renderCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	order   []string
+	size    int
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{entries: make(map[string][]byte)}
+}
+
+func (c *renderCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[key]
+	return data, ok
+}
+
+func (c *renderCache) put(key string, data []byte) {
+	if len(data) > maxRenderBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.entries[key]; ok {
+		c.size -= len(old)
+	} else {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = data
+	c.size += len(data)
+
+	for c.size > maxRenderBytes {
+		c.size -= len(c.entries[c.order[0]])
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// forget drops the entries whose keys start with prefix.
+func (c *renderCache) forget(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order = slices.DeleteFunc(c.order, func(key string) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+		c.size -= len(c.entries[key])
+		delete(c.entries, key)
+		return true
+	})
+}
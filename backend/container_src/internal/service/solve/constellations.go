@@ -0,0 +1,107 @@
+package solve
+
+import (
+	"math"
+	"sort"
+
+	"server/internal/astro"
+	"server/internal/constellation"
+	"server/internal/model"
+	"server/internal/overlay"
+)
+
+// constellationGrid is the number of samples across each side of the image
+// when measuring which constellations the field covers.
+const constellationGrid = 24
+
+// addConstellations lists the constellations the solved field overlaps,
+// largest share first, by sampling the plate solution on a regular grid.
+func addConstellations(result *model.SolveResult) {
+	if result == nil || result.WCS == nil {
+		return
+	}
+	field := result.WCS
+	if field.ImageWidth <= 0 || field.ImageHeight <= 0 {
+		return
+	}
+
+	counts := make(map[constellation.Constellation]int)
+	for i := range constellationGrid {
+		for j := range constellationGrid {
+			x := (float64(i) + 0.5) / constellationGrid * float64(field.ImageWidth)
+			y := (float64(j) + 0.5) / constellationGrid * float64(field.ImageHeight)
+			ra, dec := field.PixelToSky(x-0.5, y-0.5)
+			counts[constellation.At(astro.Equatorial{RA: ra, Dec: dec})]++
+		}
+	}
+
+	result.Constellations = result.Constellations[:0]
+	for c, n := range counts {
+		result.Constellations = append(result.Constellations, model.FieldConstellation{
+			Abbreviation: c.Abbreviation,
+			Name:         c.Name,
+			Coverage:     float64(n) / (constellationGrid * constellationGrid),
+		})
+	}
+	sort.Slice(result.Constellations, func(i, j int) bool {
+		a, b := result.Constellations[i], result.Constellations[j]
+		if a.Coverage != b.Coverage {
+			return a.Coverage > b.Coverage
+		}
+		return a.Name < b.Name
+	})
+}
+
+// addConstellationLines projects the stick figures into the image, keeping
+// the part of each segment that crosses it. Gnomonic projection maps great
+// circles to straight lines, so projecting the endpoints is enough; SIP
+// distortion bends them by no more than a pixel or two.
+func addConstellationLines(result *model.SolveResult) {
+	if result == nil || result.WCS == nil {
+		return
+	}
+	field := result.WCS
+	width, height := float64(field.ImageWidth), float64(field.ImageHeight)
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	ra, dec := field.PixelToSky((width-1)/2, (height-1)/2)
+	center := astro.Equatorial{RA: ra, Dec: dec}
+	radius := math.Hypot(width, height) / 2 * field.PixelScale() / 3600
+	imageMin, imageMax := overlay.Point{X: -0.5, Y: -0.5}, overlay.Point{X: width - 0.5, Y: height - 0.5}
+
+	result.ConstellationLines = result.ConstellationLines[:0]
+	for _, figure := range constellation.Figures() {
+		for _, seg := range figure.Segments {
+			from, to := seg.From.Position, seg.To.Position
+			// Points far from the tangent point project to huge or
+			// mirrored coordinates; segments that distant cannot cross.
+			if astro.Separation(center, from) > 80 || astro.Separation(center, to) > 80 {
+				continue
+			}
+			if min(astro.Separation(center, from), astro.Separation(center, to)) > radius+astro.Separation(from, to) {
+				continue
+			}
+
+			x1, y1, ok1 := field.SkyToPixel(from.RA, from.Dec)
+			x2, y2, ok2 := field.SkyToPixel(to.RA, to.Dec)
+			if !ok1 || !ok2 {
+				continue
+			}
+			start, end, ok := overlay.ClipLine(overlay.Point{X: x1, Y: y1}, overlay.Point{X: x2, Y: y2}, imageMin, imageMax)
+			if !ok {
+				continue
+			}
+			result.ConstellationLines = append(result.ConstellationLines, model.ConstellationLine{
+				Constellation: figure.Abbreviation,
+				FromHIP:       seg.From.HIP,
+				ToHIP:         seg.To.HIP,
+				X1:            start.X,
+				Y1:            start.Y,
+				X2:            end.X,
+				Y2:            end.Y,
+			})
+		}
+	}
+}
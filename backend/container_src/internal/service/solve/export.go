@@ -0,0 +1,171 @@
+package solve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+
+	"server/internal/apperr"
+	"server/internal/avm"
+	"server/internal/export"
+	"server/internal/fits"
+	"server/internal/imaging"
+	"server/internal/model"
+	"server/internal/store"
+)
+
+const (
+	// fitsDateLayout is the FITS form of DATE-OBS, in UTC.
+	fitsDateLayout = "2006-01-02T15:04:05"
+	// convertedJPEGQuality is used when a PNG or GIF original has to
+	// become a JPEG for export.
+	convertedJPEGQuality = 95
+)
+
+// WCSHeader returns a solved job's plate solution as FITS header cards,
+// with the capture time and, for the job's owner, the observer location
+// when they are known.
+func (s *Service) WCSHeader(ctx context.Context, subID int, caller Caller) (*fits.Header, error) {
+	status, err := s.solved(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	status = status.For(caller)
+
+	h := status.Result.WCS.Header()
+	observation := status.Observation
+	if observation == nil {
+		return h, nil
+	}
+	if t := observation.CapturedAt; t != nil {
+		h.String("DATE-OBS", t.UTC().Format(fitsDateLayout), "Capture time (UTC)")
+	}
+	if loc := observation.Location; loc != nil {
+		h.Float("OBSGEO-B", loc.Latitude, "Observer latitude (deg)")
+		h.Float("OBSGEO-L", loc.Longitude, "Observer longitude (deg, east positive)")
+		if loc.Elevation != nil {
+			h.Float("OBSGEO-H", *loc.Elevation, "Observer elevation (m)")
+		}
+		// SITELAT and SITELONG are what most capture and stacking
+		// software writes and reads.
+		h.Float("SITELAT", loc.Latitude, "Observer latitude (deg)")
+		h.Float("SITELONG", loc.Longitude, "Observer longitude (deg)")
+	}
+	return h, nil
+}
+
+// ImageWithAVM returns the original image as a JPEG carrying AVM metadata:
+// the plate solution and the names of the identified objects. JPEG
+// originals keep every other segment; EXIF, which may hold GPS coordinates,
+// is kept only for the job's owner. Other formats are converted first.
+func (s *Service) ImageWithAVM(ctx context.Context, subID int, caller Caller) ([]byte, error) {
+	status, err := s.solved(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	return s.imageWithAVM(ctx, subID, status, caller)
+}
+
+func (s *Service) imageWithAVM(ctx context.Context, subID int, status *JobStatus, caller Caller) ([]byte, error) {
+	original, cfg, err := s.originalJPEG(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	packet := avm.XMP(avm.FromWCS(status.Result.WCS, cfg.Width, cfg.Height), subjectNames(status.Result.Objects))
+	setXMP := imaging.SetXMPWithoutEXIF
+	if status.OwnedBy(caller) {
+		setXMP = imaging.SetXMP
+	}
+	data, err := setXMP(original, packet)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to embed AVM metadata", err)
+	}
+	return data, nil
+}
+
+// KML returns a Google Earth Sky-mode document that lays the image over its
+// solved footprint; the image is expected next to it as image.jpg.
+func (s *Service) KML(ctx context.Context, subID int) ([]byte, error) {
+	status, err := s.solved(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := export.KML(overlayName(subID), status.Result.WCS, status.Result.Objects)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to encode KML", err)
+	}
+	return data, nil
+}
+
+// KMZ returns the KML document and the image packed in one archive. The
+// image is the one ImageWithAVM serves, so only the owner gets its EXIF.
+func (s *Service) KMZ(ctx context.Context, subID int, caller Caller) ([]byte, error) {
+	status, err := s.solved(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := s.imageWithAVM(ctx, subID, status, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := export.KMZ(overlayName(subID), status.Result.WCS, status.Result.Objects, image)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to encode KMZ", err)
+	}
+	return data, nil
+}
+
+func overlayName(subID int) string {
+	return fmt.Sprintf("StarSeek solve %d", subID)
+}
+
+// originalJPEG loads a job's original image as a JPEG, converting other
+// formats, and returns its size.
+func (s *Service) originalJPEG(ctx context.Context, subID int) ([]byte, image.Config, error) {
+	original, err := s.images.Get(ctx, subID, store.ImageOriginal)
+	if err != nil {
+		return nil, image.Config{}, apperr.Wrap(apperr.CodeInternal, "Failed to load image", err)
+	}
+	if original == nil {
+		return nil, image.Config{}, apperr.New(apperr.CodeNotFound, "Image not found")
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		return nil, cfg, apperr.Wrap(apperr.CodeInternal, "Failed to decode stored image", err)
+	}
+	if format == "jpeg" {
+		return original, cfg, nil
+	}
+
+	img, err := imaging.Decode(original)
+	if err != nil {
+		return nil, cfg, apperr.Wrap(apperr.CodeInternal, "Failed to decode stored image", err)
+	}
+	data, err := imaging.EncodeJPEG(img, convertedJPEGQuality)
+	if err != nil {
+		return nil, cfg, apperr.Wrap(apperr.CodeInternal, "Failed to encode image", err)
+	}
+	return data, cfg, nil
+}
+
+// subjectNames lists each object's catalog name and, when it differs, its
+// common name.
+func subjectNames(objects []model.CelestialObject) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, o := range objects {
+		for _, name := range []string{o.Name, o.DisplayName} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
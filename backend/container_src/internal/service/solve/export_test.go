@@ -0,0 +1,108 @@
+package solve
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+	"time"
+
+	"server/internal/export"
+	"server/internal/imaging"
+	"server/internal/model"
+	"server/internal/store"
+	"server/internal/wcs"
+)
+
+// jpegWithEXIF encodes a small image with an EXIF APP1 segment after SOI.
+func jpegWithEXIF(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 32, 24)), nil); err != nil {
+		t.Fatal(err)
+	}
+	payload := append([]byte("Exif\x00\x00"), "MM\x00\x2a\x00\x00\x00\x08\x00\x00"...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(segment, payload...)...), data[2:]...)
+}
+
+func kmzImage(t *testing.T, kmz []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(kmz), int64(len(kmz)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name != export.KMLImageHref {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	t.Fatalf("KMZ has no %s", export.KMLImageHref)
+	return nil
+}
+
+func TestKMZStripsEXIFForOthers(t *testing.T) {
+	ctx := context.Background()
+	jobs := store.NewMemoryJobStore()
+	s := newTestService(&fakeNova{}, jobs)
+
+	at := time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)
+	job := &model.Job{
+		ID:       42,
+		DeviceID: "dev_owner",
+		Status:   StatusSuccess,
+		Result: &model.SolveResult{WCS: &wcs.WCS{
+			CRVAL: [2]float64{83.8, -5.4}, CRPIX: [2]float64{16.5, 12.5},
+			CD: [2][2]float64{{-1e-3, 0}, {0, -1e-3}}, ImageWidth: 32, ImageHeight: 24,
+		}},
+		Observation: &model.Observation{CapturedAt: &at, Location: &model.Location{Latitude: 51.5, Longitude: -0.1}},
+	}
+	if err := jobs.Save(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.images.Put(ctx, 42, store.ImageOriginal, jpegWithEXIF(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		caller   Caller
+		wantEXIF bool
+	}{
+		{"owner", Caller{DeviceID: "dev_owner"}, true},
+		{"another device", Caller{DeviceID: "dev_other"}, false},
+		{"anonymous", Caller{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kmz, err := s.KMZ(ctx, 42, tt.caller)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = imaging.FindEXIF(kmzImage(t, kmz))
+			if hasEXIF := err == nil; hasEXIF != tt.wantEXIF {
+				t.Errorf("image.jpg has EXIF = %v (%v), want %v", hasEXIF, err, tt.wantEXIF)
+			}
+			if err != nil && !errors.Is(err, imaging.ErrNoEXIF) {
+				t.Errorf("image.jpg is malformed: %v", err)
+			}
+		})
+	}
+}
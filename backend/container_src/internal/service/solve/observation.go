@@ -0,0 +1,70 @@
+package solve
+
+import (
+	"strings"
+
+	"server/internal/imaging"
+	"server/internal/model"
+)
+
+// mergeEXIF fills the parts of an observation the submitter left out from
+// the image's EXIF. Submitted values always win.
+func mergeEXIF(observation *model.Observation, imageData []byte) *model.Observation {
+	exif, err := imaging.ReadEXIF(imageData)
+	if err != nil {
+		if observation.IsEmpty() {
+			return nil
+		}
+		return observation
+	}
+
+	merged := model.Observation{}
+	if observation != nil {
+		merged = *observation
+	}
+
+	if merged.CapturedAt == nil {
+		merged.CapturedAt = exif.DateTimeOriginal
+	}
+	if merged.Location == nil && exif.Latitude != nil && exif.Longitude != nil {
+		merged.Location = &model.Location{
+			Latitude:  *exif.Latitude,
+			Longitude: *exif.Longitude,
+			Elevation: exif.Altitude,
+		}
+	}
+
+	equipment := model.Equipment{}
+	if merged.Equipment != nil {
+		equipment = *merged.Equipment
+	}
+	if equipment.Camera == "" {
+		equipment.Camera = cameraName(exif.Make, exif.Model)
+	}
+	if equipment.Telescope == "" {
+		equipment.Telescope = exif.LensModel
+	}
+	if equipment.FocalLength == 0 {
+		equipment.FocalLength = exif.FocalLength
+	}
+	if equipment != (model.Equipment{}) {
+		merged.Equipment = &equipment
+	}
+
+	if merged.IsEmpty() {
+		return nil
+	}
+	return &merged
+}
+
+// cameraName joins make and model, skipping the make when the model already
+// starts with it ("Canon" + "Canon EOS R6").
+func cameraName(vendor, model string) string {
+	if vendor == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(vendor)) {
+		return model
+	}
+	if model == "" {
+		return vendor
+	}
+	return vendor + " " + model
+}
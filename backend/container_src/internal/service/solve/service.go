@@ -3,9 +3,18 @@ package solve
 import (
 	"context"
 	"errors"
+	"log"
+	"sync"
+	"time"
 
+	"server/internal/apperr"
 	"server/internal/client/astrometry"
+	"server/internal/imaging"
 	"server/internal/model"
+	"server/internal/overlay"
+	"server/internal/render"
+	"server/internal/store"
+	"server/internal/wcs"
 )
 
 const (
@@ -14,37 +23,288 @@ const (
 	StatusFailed     = "failed"
 )
 
+const (
+	solveTimeout        = 15 * time.Minute
+	deriveTimeout       = 2 * time.Minute
+	novaTimestampLayout = "2006-01-02 15:04:05.999999"
+	thumbnailSize       = 256
+)
+
 type AstrometryClient interface {
-	GetSession(ctx context.Context) (string, error)
-	Upload(ctx context.Context, session string, imageData []byte, filename string) (int, error)
+	UploadImage(ctx context.Context, imageData []byte, filename string) (int, error)
 	GetSubmission(ctx context.Context, subID int) (*astrometry.SubmissionResponse, error)
 	GetJob(ctx context.Context, jobID int) (*astrometry.JobResponse, error)
 	GetAnnotations(ctx context.Context, jobID int) ([]astrometry.Annotation, error)
+	GetCalibration(ctx context.Context, jobID int) (*astrometry.CalibrationResponse, error)
+	GetWCSFile(ctx context.Context, jobID int) ([]byte, error)
+}
+
+type JobStore interface {
+	Get(ctx context.Context, id int) (*model.Job, error)
+	Save(ctx context.Context, job *model.Job) error
+}
+
+type ImageStore interface {
+	Put(ctx context.Context, jobID int, kind store.ImageKind, data []byte) error
+	Get(ctx context.Context, jobID int, kind store.ImageKind) ([]byte, error)
+}
+
+type MinorBodies interface {
+	InField(field *wcs.WCS, at time.Time) []model.CelestialObject
+}
+
+type Satellites interface {
+	Identify(streaks []model.Streak, field *wcs.WCS, observation *model.Observation)
+}
+
+type MeteorShowers interface {
+	Classify(streaks []model.Streak, observation *model.Observation)
 }
 
 type Service struct {
-	client AstrometryClient
+	client      AstrometryClient
+	jobs        JobStore
+	images      ImageStore
+	minorBodies MinorBodies
+	satellites  Satellites
+	showers     MeteorShowers
+	renders     *renderCache
+
+	mu       sync.Mutex
+	deriving map[int]bool
 }
 
-func NewService(client AstrometryClient) *Service {
-	return &Service{client: client}
+func NewService(client AstrometryClient, jobs JobStore, images ImageStore, minorBodies MinorBodies, satellites Satellites, showers MeteorShowers) *Service {
+	return &Service{client: client, jobs: jobs, images: images, minorBodies: minorBodies, satellites: satellites, showers: showers, renders: newRenderCache(), deriving: make(map[int]bool)}
 }
 
-func (s *Service) SubmitImage(ctx context.Context, imageData []byte, filename string) (int, error) {
-	session, err := s.client.GetSession(ctx)
+type Submission struct {
+	ImageData   []byte
+	Filename    string
+	DeviceID    string
+	KeyID       string
+	Observation *model.Observation
+}
+
+func (s *Service) SubmitImage(ctx context.Context, submission Submission) (int, error) {
+	subID, err := s.client.UploadImage(ctx, submission.ImageData, submission.Filename)
 	if err != nil {
 		return 0, err
 	}
-	return s.client.Upload(ctx, session, imageData, filename)
+
+	now := time.Now().UTC()
+	job := &model.Job{
+		ID:          subID,
+		DeviceID:    submission.DeviceID,
+		KeyID:       submission.KeyID,
+		Filename:    submission.Filename,
+		Observation: mergeEXIF(submission.Observation, submission.ImageData),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Status:      StatusProcessing,
+	}
+	if err := s.jobs.Save(ctx, job); err != nil {
+		log.Printf("Failed to record job %d: %v", subID, err)
+	}
+
+	s.storeImages(ctx, subID, submission.ImageData)
+	return subID, nil
+}
+
+func (s *Service) storeImages(ctx context.Context, subID int, imageData []byte) {
+	if err := s.images.Put(ctx, subID, store.ImageOriginal, imageData); err != nil {
+		log.Printf("Failed to store image for job %d: %v", subID, err)
+	}
+
+	img, err := imaging.Decode(imageData)
+	if err != nil {
+		return
+	}
+
+	thumbnail, err := imaging.EncodeJPEG(imaging.Thumbnail(img, thumbnailSize), 80)
+	if err != nil {
+		log.Printf("Failed to encode thumbnail for job %d: %v", subID, err)
+		return
+	}
+
+	if err := s.images.Put(ctx, subID, store.ImageThumbnail, thumbnail); err != nil {
+		log.Printf("Failed to store thumbnail for job %d: %v", subID, err)
+	}
 }
 
 type JobStatus struct {
-	Status string
-	Result *model.SolveResult
-	Error  string
+	Status      string
+	Result      *model.SolveResult
+	Error       string
+	Observation *model.Observation
+
+	// deviceID and keyID identify who submitted the job, when known.
+	deviceID string
+	keyID    string
+}
+
+// Caller identifies who is asking about a job.
+type Caller struct {
+	DeviceID string
+	KeyID    string
+}
+
+// OwnedBy reports whether caller submitted the job. A job submitted from a
+// device belongs to that device alone, since an app may share one API key
+// across all its users; otherwise the submitting key owns it.
+func (s *JobStatus) OwnedBy(caller Caller) bool {
+	if s.deviceID != "" {
+		return caller.DeviceID == s.deviceID
+	}
+	return s.keyID != "" && caller.KeyID == s.keyID
+}
+
+// For returns the status as caller may see it. Job IDs are sequential, so
+// anyone can look a job up; only its owner sees where it was taken. Others
+// also lose each object's altitude and azimuth, which together with the
+// capture time give the location away.
+func (s *JobStatus) For(caller Caller) *JobStatus {
+	if s.Observation == nil || s.Observation.Location == nil || s.OwnedBy(caller) {
+		return s
+	}
+	redacted := *s
+	observation := *s.Observation
+	observation.Location = nil
+	redacted.Observation = &observation
+	if s.Result != nil {
+		result := *s.Result
+		result.Objects = make([]model.CelestialObject, len(s.Result.Objects))
+		for i, o := range s.Result.Objects {
+			o.Sky = nil
+			result.Objects[i] = o
+		}
+		redacted.Result = &result
+	}
+	return &redacted
 }
 
 func (s *Service) GetJobStatus(ctx context.Context, subID int) (*JobStatus, error) {
+	// Without the stored job, recording the result would replace its owner
+	// and observation with an empty one, so a load error fails the poll.
+	job, err := s.jobs.Get(ctx, subID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, "Failed to load job", err)
+	}
+
+	if job != nil && job.Status != StatusProcessing {
+		return &JobStatus{
+			Status:      job.Status,
+			Result:      job.Result,
+			Error:       job.Error,
+			Observation: job.Observation,
+			deviceID:    job.DeviceID,
+			keyID:       job.KeyID,
+		}, nil
+	}
+
+	// Nova has already answered for a job being derived; asking again
+	// would only repeat its calls.
+	if s.isDeriving(subID) {
+		status := &JobStatus{Status: StatusProcessing}
+		if job != nil {
+			status.Observation = job.Observation
+			status.deviceID, status.keyID = job.DeviceID, job.KeyID
+		}
+		return status, nil
+	}
+
+	status, err := s.fetchJobStatus(ctx, subID)
+	if err != nil || status == nil {
+		return status, err
+	}
+	if job != nil {
+		status.Observation = job.Observation
+		status.deviceID, status.keyID = job.DeviceID, job.KeyID
+	}
+	if status.Status == StatusProcessing {
+		return status, nil
+	}
+	if status.Status == StatusFailed {
+		s.recordResult(ctx, job, subID, status)
+		return status, nil
+	}
+
+	s.derive(ctx, job, subID, status)
+	return &JobStatus{Status: StatusProcessing, Observation: status.Observation, deviceID: status.deviceID, keyID: status.keyID}, nil
+}
+
+// derive completes a solved result in the background, once per job, and
+// records it: minor bodies, sky positions, the solar system, constellations
+// and streaks, which means decoding the original image. Until it is
+// recorded the job still reads as processing, so no poll waits on it.
+func (s *Service) derive(ctx context.Context, job *model.Job, subID int, status *JobStatus) {
+	s.mu.Lock()
+	if s.deriving[subID] {
+		s.mu.Unlock()
+		return
+	}
+	s.deriving[subID] = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.deriving, subID)
+			s.mu.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deriveTimeout)
+		defer cancel()
+
+		// A poll that read the job just before the last derivation saved it
+		// finds it done here. If the job cannot be read, a later poll tries
+		// again rather than recording over it.
+		recorded, err := s.jobs.Get(ctx, subID)
+		if err != nil {
+			log.Printf("Failed to load job %d: %v", subID, err)
+			return
+		}
+		if recorded != nil && recorded.Status != StatusProcessing {
+			return
+		}
+		s.addMinorBodies(status.Result, status.Observation)
+		annotateSky(status.Result, status.Observation)
+		addSolarSystem(status.Result, status.Observation)
+		addConstellations(status.Result)
+		addConstellationLines(status.Result)
+		s.detectStreaks(ctx, subID, status.Result, status.Observation)
+		s.recordResult(ctx, job, subID, status)
+	}()
+}
+
+func (s *Service) isDeriving(subID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deriving[subID]
+}
+
+func (s *Service) addMinorBodies(result *model.SolveResult, observation *model.Observation) {
+	if result == nil || result.WCS == nil || observation == nil || observation.CapturedAt == nil {
+		return
+	}
+	result.Objects = append(result.Objects, s.minorBodies.InField(result.WCS, *observation.CapturedAt)...)
+}
+
+func (s *Service) recordResult(ctx context.Context, job *model.Job, subID int, status *JobStatus) {
+	now := time.Now().UTC()
+	if job == nil {
+		job = &model.Job{ID: subID, CreatedAt: now}
+	}
+
+	job.Status = status.Status
+	job.Result = status.Result
+	job.Error = status.Error
+	job.UpdatedAt = now
+	if err := s.jobs.Save(ctx, job); err != nil {
+		log.Printf("Failed to record result for job %d: %v", subID, err)
+	}
+}
+
+func (s *Service) fetchJobStatus(ctx context.Context, subID int) (*JobStatus, error) {
 	submission, err := s.client.GetSubmission(ctx, subID)
 	if err != nil {
 		if errors.Is(err, astrometry.ErrNotFound) {
@@ -54,14 +314,14 @@ func (s *Service) GetJobStatus(ctx context.Context, subID int) (*JobStatus, erro
 	}
 
 	if len(submission.Jobs) == 0 || submission.Jobs[0] == 0 {
-		return &JobStatus{Status: StatusProcessing}, nil
+		return processing(submission)
 	}
 
 	actualJobID := submission.Jobs[0]
 	job, err := s.client.GetJob(ctx, actualJobID)
 	if err != nil {
 		if errors.Is(err, astrometry.ErrNotFound) {
-			return &JobStatus{Status: StatusProcessing}, nil
+			return processing(submission)
 		}
 		return nil, err
 	}
@@ -73,9 +333,18 @@ func (s *Service) GetJobStatus(ctx context.Context, subID int) (*JobStatus, erro
 			return nil, err
 		}
 
+		result := TransformAnnotations(annotations, job.ObjectsInField)
+		calibration, err := s.client.GetCalibration(ctx, actualJobID)
+		if err != nil {
+			log.Printf("Calibration fetch failed for job %d: %v", actualJobID, err)
+		} else {
+			result.Calibration = TransformCalibration(calibration)
+		}
+		result.WCS = s.fetchWCS(ctx, actualJobID)
+
 		return &JobStatus{
 			Status: StatusSuccess,
-			Result: TransformAnnotations(annotations, job.ObjectsInField),
+			Result: result,
 		}, nil
 	case "failure":
 		return &JobStatus{
@@ -83,6 +352,63 @@ func (s *Service) GetJobStatus(ctx context.Context, subID int) (*JobStatus, erro
 			Error:  "Could not identify objects in image",
 		}, nil
 	default:
-		return &JobStatus{Status: StatusProcessing}, nil
+		return processing(submission)
+	}
+}
+
+func (s *Service) fetchWCS(ctx context.Context, jobID int) *wcs.WCS {
+	header, err := s.client.GetWCSFile(ctx, jobID)
+	if err != nil {
+		log.Printf("WCS fetch failed for job %d: %v", jobID, err)
+		return nil
+	}
+
+	solution, err := wcs.Parse(header)
+	if err != nil {
+		log.Printf("Invalid WCS for job %d: %v", jobID, err)
+		return nil
+	}
+	return solution
+}
+
+func processing(submission *astrometry.SubmissionResponse) (*JobStatus, error) {
+	started, err := time.Parse(novaTimestampLayout, submission.ProcessingStarted)
+	if err == nil && time.Since(started) > solveTimeout {
+		return nil, apperr.ErrSolveTimedOut.WithDetail("timeoutSeconds", int(solveTimeout.Seconds()))
+	}
+	return &JobStatus{Status: StatusProcessing}, nil
+}
+
+// Grid computes an RA/Dec grid over a solved job's image.
+func (s *Service) Grid(ctx context.Context, subID int, density overlay.Density) (*overlay.Grid, error) {
+	status, err := s.solved(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	return overlay.NewGrid(status.Result.WCS, density), nil
+}
+
+// Chart draws an SVG finder chart around a solved job's field.
+func (s *Service) Chart(ctx context.Context, subID int) ([]byte, error) {
+	status, err := s.solved(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	return render.Chart(status.Result.WCS), nil
+}
+
+// solved returns the status of a job that has a plate solution, or a not
+// found error.
+func (s *Service) solved(ctx context.Context, subID int) (*JobStatus, error) {
+	status, err := s.GetJobStatus(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Job not found")
+	}
+	if status.Result == nil || status.Result.WCS == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "Plate solution not available").WithDetail("status", status.Status)
 	}
+	return status, nil
 }
@@ -0,0 +1,137 @@
+package solve
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"server/internal/client/astrometry"
+	"server/internal/model"
+	"server/internal/store"
+	"server/internal/wcs"
+)
+
+// fakeNova answers as Nova does for one submission that solved as job 7.
+type fakeNova struct {
+	mu          sync.Mutex
+	submissions int
+}
+
+func (f *fakeNova) UploadImage(ctx context.Context, imageData []byte, filename string) (int, error) {
+	return 42, nil
+}
+
+func (f *fakeNova) GetSubmission(ctx context.Context, subID int) (*astrometry.SubmissionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.submissions++
+	return &astrometry.SubmissionResponse{Jobs: []int{7}}, nil
+}
+
+func (f *fakeNova) GetJob(ctx context.Context, jobID int) (*astrometry.JobResponse, error) {
+	return &astrometry.JobResponse{Status: "success"}, nil
+}
+
+func (f *fakeNova) GetAnnotations(ctx context.Context, jobID int) ([]astrometry.Annotation, error) {
+	return nil, nil
+}
+
+func (f *fakeNova) GetCalibration(ctx context.Context, jobID int) (*astrometry.CalibrationResponse, error) {
+	return &astrometry.CalibrationResponse{RA: 10, Dec: 41, PixScale: 2}, nil
+}
+
+func (f *fakeNova) GetWCSFile(ctx context.Context, jobID int) ([]byte, error) {
+	return nil, errors.New("no WCS")
+}
+
+// failingJobs is a job store whose reads fail.
+type failingJobs struct {
+	*store.MemoryJobStore
+	saves int
+}
+
+func (f *failingJobs) Get(ctx context.Context, id int) (*model.Job, error) {
+	return nil, errors.New("KV unavailable")
+}
+
+func (f *failingJobs) Save(ctx context.Context, job *model.Job) error {
+	f.saves++
+	return f.MemoryJobStore.Save(ctx, job)
+}
+
+type noMinorBodies struct{}
+
+func (noMinorBodies) InField(field *wcs.WCS, at time.Time) []model.CelestialObject { return nil }
+
+type noSatellites struct{}
+
+func (noSatellites) Identify(streaks []model.Streak, field *wcs.WCS, observation *model.Observation) {
+}
+
+type noShowers struct{}
+
+func (noShowers) Classify(streaks []model.Streak, observation *model.Observation) {}
+
+func newTestService(nova AstrometryClient, jobs JobStore) *Service {
+	return NewService(nova, jobs, store.NewMemoryImageStore(), noMinorBodies{}, noSatellites{}, noShowers{})
+}
+
+func TestJobLoadErrorDoesNotOverwriteJob(t *testing.T) {
+	jobs := &failingJobs{MemoryJobStore: store.NewMemoryJobStore()}
+	s := newTestService(&fakeNova{}, jobs)
+
+	if _, err := s.GetJobStatus(context.Background(), 42); err == nil {
+		t.Fatal("GetJobStatus succeeded without the stored job")
+	}
+	if jobs.saves != 0 {
+		t.Errorf("job saved %d times after a failed load", jobs.saves)
+	}
+}
+
+// slowJobs holds back saves until release is closed.
+type slowJobs struct {
+	*store.MemoryJobStore
+	release chan struct{}
+}
+
+func (s *slowJobs) Save(ctx context.Context, job *model.Job) error {
+	if job.Status != StatusProcessing {
+		<-s.release
+	}
+	return s.MemoryJobStore.Save(ctx, job)
+}
+
+func TestPollsDuringDerivationSkipNova(t *testing.T) {
+	ctx := context.Background()
+	nova := &fakeNova{}
+	jobs := &slowJobs{MemoryJobStore: store.NewMemoryJobStore(), release: make(chan struct{})}
+	s := newTestService(nova, jobs)
+
+	for i := range 3 {
+		status, err := s.GetJobStatus(ctx, 42)
+		if err != nil || status == nil || status.Status != StatusProcessing {
+			t.Fatalf("poll %d = %+v, %v; want processing", i, status, err)
+		}
+	}
+	if nova.submissions != 1 {
+		t.Errorf("Nova asked %d times while the result was being derived, want 1", nova.submissions)
+	}
+
+	close(jobs.release)
+	for deadline := time.Now().Add(5 * time.Second); s.isDeriving(42); {
+		if time.Now().After(deadline) {
+			t.Fatal("derivation did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	status, err := s.GetJobStatus(ctx, 42)
+	if err != nil || status == nil || status.Status != StatusSuccess {
+		t.Fatalf("poll after derivation = %+v, %v; want success", status, err)
+	}
+	if nova.submissions != 1 {
+		t.Errorf("Nova asked %d times after the result was recorded, want 1", nova.submissions)
+	}
+}
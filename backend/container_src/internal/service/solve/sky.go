@@ -0,0 +1,55 @@
+package solve
+
+import (
+	"server/internal/astro"
+	"server/internal/model"
+)
+
+// annotateSky adds altitude, azimuth, airmass and that night's rise, transit
+// and set to every catalog object, when the observation has both a capture
+// time and a location.
+func annotateSky(result *model.SolveResult, observation *model.Observation) {
+	if result == nil || observation == nil || observation.CapturedAt == nil || observation.Location == nil {
+		return
+	}
+
+	observer := astro.Observer{
+		Latitude:  observation.Location.Latitude,
+		Longitude: observation.Location.Longitude,
+	}
+	jd := astro.JulianDate(*observation.CapturedAt)
+	midnight := astro.LocalMidnight(*observation.CapturedAt, observer.Longitude)
+
+	for i := range result.Objects {
+		obj := &result.Objects[i]
+		if obj.Coordinates == nil {
+			continue
+		}
+
+		body := astro.Fixed(astro.Equatorial{RA: obj.Coordinates.RA, Dec: obj.Coordinates.Dec})
+		passage := astro.RiseTransitSet(body, observer, midnight, astro.HorizonStar)
+		obj.Sky = skyPosition(body(jd), passage, observer, jd)
+	}
+}
+
+// skyPosition places a position of date, as seen by the observer, on the
+// sky at jd alongside the night's rise, transit and set.
+func skyPosition(eq astro.Equatorial, passage astro.RiseSet, observer astro.Observer, jd float64) *model.SkyPosition {
+	horizontal := astro.ToHorizontal(eq, observer, jd)
+	altitude := astro.ApparentAltitude(horizontal.Altitude)
+
+	sky := &model.SkyPosition{
+		Altitude:    altitude,
+		Azimuth:     horizontal.Azimuth,
+		HourAngle:   astro.HourAngle(eq, observer, jd) / 15,
+		Rise:        passage.Rise,
+		Transit:     passage.Transit,
+		Set:         passage.Set,
+		Circumpolar: passage.Circumpolar,
+		NeverRises:  passage.NeverRises,
+	}
+	if airmass, ok := astro.Airmass(altitude); ok {
+		sky.Airmass = &airmass
+	}
+	return sky
+}
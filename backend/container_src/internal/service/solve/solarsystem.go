@@ -0,0 +1,109 @@
+package solve
+
+import (
+	"server/internal/astro"
+	"server/internal/model"
+)
+
+// solarBody is a Sun, Moon or planet position at capture time.
+type solarBody struct {
+	name string
+	typ  string
+	// position is the J2000 position as seen by the observer, comparable
+	// with the plate solution.
+	position astro.Equatorial
+	// apparent is the position of date as seen by the observer.
+	apparent astro.Equatorial
+	// radius is the apparent radius of the disc in degrees.
+	radius  float64
+	body    astro.Body
+	horizon float64
+}
+
+// addSolarSystem adds the Sun, Moon and planets that fall inside the solved
+// image at the capture time, with pixel positions from the plate solution.
+// Without a location the positions are geocentric, which can misplace the
+// Moon by up to a degree.
+func addSolarSystem(result *model.SolveResult, observation *model.Observation) {
+	if result == nil || result.WCS == nil || observation == nil || observation.CapturedAt == nil {
+		return
+	}
+
+	jd := astro.JulianDate(*observation.CapturedAt)
+	var observer *astro.Observer
+	if loc := observation.Location; loc != nil {
+		observer = &astro.Observer{Latitude: loc.Latitude, Longitude: loc.Longitude}
+		if loc.Elevation != nil {
+			observer.Elevation = *loc.Elevation
+		}
+	}
+
+	scale := result.WCS.PixelScale() / 3600
+	for _, b := range solarSystem(jd, observer) {
+		x, y, ok := result.WCS.SkyToPixel(b.position.RA, b.position.Dec)
+		if !ok || !result.WCS.Contains(x, y) {
+			continue
+		}
+
+		obj := model.CelestialObject{
+			Name:        b.name,
+			Type:        b.typ,
+			PixelX:      &x,
+			PixelY:      &y,
+			Footprint:   &model.Footprint{PixelX: x, PixelY: y, Radius: b.radius / scale},
+			Coordinates: &model.Coordinates{RA: b.position.RA, Dec: b.position.Dec},
+		}
+		if observer != nil {
+			midnight := astro.LocalMidnight(*observation.CapturedAt, observer.Longitude)
+			passage := astro.RiseTransitSet(b.body, *observer, midnight, b.horizon)
+			obj.Sky = skyPosition(b.apparent, passage, *observer, jd)
+		}
+		result.Objects = append(result.Objects, obj)
+	}
+}
+
+func solarSystem(jd float64, observer *astro.Observer) []solarBody {
+	sun := astro.Sun(jd)
+	_, sunDistance := astro.SunEcliptic(jd)
+
+	moon := astro.Moon(jd)
+	_, moonDistance := astro.MoonEcliptic(jd)
+	if observer != nil {
+		moon = astro.Topocentric(moon, astro.MoonParallax(moonDistance), *observer, jd)
+	}
+
+	bodies := []solarBody{
+		{
+			name:     "Sun",
+			typ:      "sun",
+			position: astro.CatalogPlace(sun, jd),
+			apparent: sun,
+			radius:   astro.AngularRadius(astro.SunRadiusKm, sunDistance*astro.AU),
+			body:     astro.Sun,
+			horizon:  astro.HorizonSun,
+		},
+		{
+			name:     "Moon",
+			typ:      "moon",
+			position: astro.CatalogPlace(moon, jd),
+			apparent: moon,
+			radius:   astro.AngularRadius(astro.MoonRadiusKm, moonDistance),
+			body:     astro.Moon,
+			horizon:  astro.MoonHorizon(moonDistance),
+		},
+	}
+
+	for _, name := range astro.Planets {
+		position, distance, _ := astro.PlanetPosition(name, jd)
+		bodies = append(bodies, solarBody{
+			name:     name,
+			typ:      "planet",
+			position: position,
+			apparent: astro.ApparentPlace(position, jd),
+			radius:   astro.AngularRadius(astro.PlanetRadius(name), distance*astro.AU),
+			body:     astro.Planet(name),
+			horizon:  astro.HorizonStar,
+		})
+	}
+	return bodies
+}
@@ -0,0 +1,58 @@
+package solve
+
+import (
+	"context"
+	"log"
+
+	"server/internal/imaging"
+	"server/internal/model"
+	"server/internal/store"
+)
+
+// detectStreaks looks for satellite, aircraft and meteor trails in the
+// stored original image, places them on the sky, matches them against
+// known satellites and labels the rest with a possible meteor shower.
+func (s *Service) detectStreaks(ctx context.Context, subID int, result *model.SolveResult, observation *model.Observation) {
+	if result == nil {
+		return
+	}
+
+	data, err := s.images.Get(ctx, subID, store.ImageOriginal)
+	if err != nil {
+		log.Printf("Failed to load image for job %d: %v", subID, err)
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return
+	}
+
+	// The plate solution may describe a resampled copy of the upload.
+	scaleX, scaleY := 1.0, 1.0
+	if field := result.WCS; field != nil && field.ImageWidth > 0 && field.ImageHeight > 0 {
+		scaleX = float64(field.ImageWidth) / float64(img.Bounds().Dx())
+		scaleY = float64(field.ImageHeight) / float64(img.Bounds().Dy())
+	}
+
+	for _, found := range imaging.DetectStreaks(img) {
+		streak := model.Streak{
+			X1: (found.X1+0.5)*scaleX - 0.5,
+			Y1: (found.Y1+0.5)*scaleY - 0.5,
+			X2: (found.X2+0.5)*scaleX - 0.5,
+			Y2: (found.Y2+0.5)*scaleY - 0.5,
+		}
+		if result.WCS != nil {
+			ra, dec := result.WCS.PixelToSky(streak.X1, streak.Y1)
+			streak.Start = &model.Coordinates{RA: ra, Dec: dec}
+			ra, dec = result.WCS.PixelToSky(streak.X2, streak.Y2)
+			streak.End = &model.Coordinates{RA: ra, Dec: dec}
+		}
+		result.Streaks = append(result.Streaks, streak)
+	}
+	s.satellites.Identify(result.Streaks, result.WCS, observation)
+	s.showers.Classify(result.Streaks, observation)
+}
@@ -42,12 +42,16 @@ func TransformAnnotations(annotations []astrometry.Annotation, objectsInField []
 			Type:          info.Type,
 			Constellation: info.Constellation,
 			DisplayName:   info.DisplayName,
+			Coordinates:   model.CoordinatesOf(info),
 		}
 
-		if info.Type == "star" && ann.PixelX != 0 && ann.PixelY != 0 {
-			x, y := ann.PixelX, ann.PixelY
-			obj.PixelX = &x
-			obj.PixelY = &y
+		if ann.PixelX != 0 && ann.PixelY != 0 {
+			obj.Footprint = &model.Footprint{PixelX: ann.PixelX, PixelY: ann.PixelY, Radius: ann.Radius}
+			if info.Type == "star" {
+				x, y := ann.PixelX, ann.PixelY
+				obj.PixelX = &x
+				obj.PixelY = &y
+			}
 		}
 
 		objects = append(objects, obj)
@@ -64,8 +68,29 @@ func TransformAnnotations(annotations []astrometry.Annotation, objectsInField []
 			Type:          info.Type,
 			Constellation: info.Constellation,
 			DisplayName:   info.DisplayName,
+			Coordinates:   model.CoordinatesOf(info),
 		})
 	}
 
 	return &model.SolveResult{Objects: objects}
 }
+
+func TransformCalibration(c *astrometry.CalibrationResponse) *model.Calibration {
+	if c == nil {
+		return nil
+	}
+
+	parity := 1
+	if c.Parity < 0 {
+		parity = -1
+	}
+
+	return &model.Calibration{
+		RA:          c.RA,
+		Dec:         c.Dec,
+		Radius:      c.Radius,
+		PixelScale:  c.PixScale,
+		Orientation: c.Orientation,
+		Parity:      parity,
+	}
+}
@@ -0,0 +1,330 @@
+package tonight
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"server/internal/astro"
+	"server/internal/model/data"
+)
+
+const (
+	DefaultMinAltitude = 30.0
+	DefaultLimit       = 10
+	MaxLimit           = 50
+
+	sampleInterval = 10 * time.Minute
+)
+
+// typeOrder lists result groups, most requested first.
+var typeOrder = []string{"galaxy", "nebula", "cluster", "star"}
+
+type Query struct {
+	Latitude       float64
+	Longitude      float64
+	Date           time.Time
+	LimitMagnitude *float64
+	MinAltitude    float64
+	Limit          int
+}
+
+type Night struct {
+	Date     time.Time
+	Darkness *Window
+	// NoAstronomicalDarkness is set on nights when the Sun stays less than
+	// 18° below the horizon, as in summer at high latitudes. Darkness is
+	// nil and there are no recommendations.
+	NoAstronomicalDarkness bool
+	Moon                   Moon
+	Groups                 []Group
+}
+
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+type Moon struct {
+	Phase        string
+	Illumination float64
+	Rise         *time.Time
+	Set          *time.Time
+	// UpDuringDarkness is true when the Moon is above the horizon at any
+	// point of the dark window.
+	UpDuringDarkness bool
+	// Bright flags nights where a Moon more than half lit is up during
+	// darkness, which washes out faint galaxies and nebulae.
+	Bright bool
+}
+
+type Group struct {
+	Type    string
+	Objects []Recommendation
+}
+
+type Recommendation struct {
+	Name           string
+	DisplayName    string
+	Type           string
+	Constellation  string
+	Magnitude      *float64
+	Size           float64
+	RA             float64
+	Dec            float64
+	PeakAltitude   float64
+	PeakTime       time.Time
+	Visible        Window
+	Hours          float64
+	MoonSeparation float64
+	Score          float64
+}
+
+type Service struct {
+	objects []data.ObjectInfo
+}
+
+func NewService() *Service {
+	return &Service{objects: uniqueObjects(data.All())}
+}
+
+// DefaultDate returns the evening date of the night in progress or coming
+// up at a longitude: before local noon that is the previous day.
+func DefaultDate(now time.Time, longitude float64) time.Time {
+	local := now.UTC().Add(time.Duration(longitude / 15 * float64(time.Hour)))
+	if local.Hour() < 12 {
+		local = local.AddDate(0, 0, -1)
+	}
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *Service) Recommend(q Query) *Night {
+	obs := astro.Observer{Latitude: q.Latitude, Longitude: q.Longitude}
+	offset := time.Duration(q.Longitude / 15 * float64(time.Hour))
+	noon := time.Date(q.Date.Year(), q.Date.Month(), q.Date.Day(), 12, 0, 0, 0, time.UTC).Add(-offset)
+	midnight := noon.Add(12 * time.Hour)
+
+	night := &Night{Date: q.Date, Groups: []Group{}}
+	night.Darkness = darkness(obs, noon)
+	night.Moon = moonAt(obs, midnight)
+	if night.Darkness == nil {
+		night.NoAstronomicalDarkness = true
+		return night
+	}
+
+	samples := sampleTimes(*night.Darkness)
+	moon, moonUp := moonTrack(obs, samples)
+	night.Moon.UpDuringDarkness = moonUp
+	night.Moon.Bright = moonUp && night.Moon.Illumination >= 0.5
+
+	groups := make(map[string][]Recommendation)
+	midJD := astro.JulianDate(midnight)
+	for _, info := range s.objects {
+		p := info.Position
+		if q.LimitMagnitude != nil && (p.Magnitude == nil || *p.Magnitude > *q.LimitMagnitude) {
+			continue
+		}
+
+		eq := astro.ApparentPlace(astro.Equatorial{RA: p.RA, Dec: p.Dec}, midJD)
+		rec, peak, ok := track(eq, obs, samples, q.MinAltitude)
+		if !ok {
+			continue
+		}
+
+		rec.Name = info.Name
+		rec.DisplayName = info.GetDisplayName()
+		rec.Type = info.Type
+		rec.Constellation = info.Constellation
+		rec.Magnitude = p.Magnitude
+		rec.Size = p.Size
+		rec.RA, rec.Dec = p.RA, p.Dec
+		rec.MoonSeparation = astro.Separation(eq, moon[peak])
+		rec.Score = score(rec, night.Darkness, night.Moon)
+		groups[info.Type] = append(groups[info.Type], rec)
+	}
+
+	for _, typ := range groupTypes(groups) {
+		recs := groups[typ]
+		sort.SliceStable(recs, func(i, j int) bool { return recs[i].Score > recs[j].Score })
+		night.Groups = append(night.Groups, Group{Type: typ, Objects: recs[:min(len(recs), q.Limit)]})
+	}
+	return night
+}
+
+// darkness finds the night's window of astronomical darkness, or nil if
+// the Sun never gets 18° below the horizon.
+func darkness(obs astro.Observer, noon time.Time) *Window {
+	today := astro.RiseTransitSet(astro.Sun, obs, noon, astro.HorizonAstronomicalTwilight)
+	if today.Circumpolar {
+		return nil
+	}
+
+	window := &Window{Start: noon, End: noon.Add(24 * time.Hour)}
+	if today.Set != nil {
+		window.Start = *today.Set
+	}
+	if tomorrow := astro.RiseTransitSet(astro.Sun, obs, window.End, astro.HorizonAstronomicalTwilight); tomorrow.Rise != nil {
+		window.End = *tomorrow.Rise
+	}
+	return window
+}
+
+func moonAt(obs astro.Observer, midnight time.Time) Moon {
+	jd := astro.JulianDate(midnight)
+	phase := astro.MoonPhaseAt(jd)
+	_, distance := astro.MoonEcliptic(jd)
+	passage := astro.RiseTransitSet(astro.Moon, obs, midnight, astro.MoonHorizon(distance))
+
+	return Moon{
+		Phase:        phase.Name,
+		Illumination: phase.Illumination,
+		Rise:         passage.Rise,
+		Set:          passage.Set,
+	}
+}
+
+func sampleTimes(w Window) []time.Time {
+	var samples []time.Time
+	for t := w.Start; !t.After(w.End); t = t.Add(sampleInterval) {
+		samples = append(samples, t)
+	}
+	return samples
+}
+
+// moonTrack returns the Moon's position at each sample and whether it is
+// above the horizon at any of them.
+func moonTrack(obs astro.Observer, samples []time.Time) ([]astro.Equatorial, bool) {
+	positions := make([]astro.Equatorial, len(samples))
+	up := false
+	for i, t := range samples {
+		jd := astro.JulianDate(t)
+		_, distance := astro.MoonEcliptic(jd)
+		positions[i] = astro.Moon(jd)
+		altitude := astro.ToHorizontal(positions[i], obs, jd).Altitude
+		if astro.TopocentricAltitude(altitude, astro.MoonParallax(distance)) > 0 {
+			up = true
+		}
+	}
+	return positions, up
+}
+
+// track samples an object's altitude across the dark window and reports the
+// stretch it spends above minAltitude, with the index of the highest sample.
+func track(eq astro.Equatorial, obs astro.Observer, samples []time.Time, minAltitude float64) (Recommendation, int, bool) {
+	var rec Recommendation
+	rec.PeakAltitude = math.Inf(-1)
+	peak, above := 0, 0
+
+	for i, t := range samples {
+		altitude := astro.ApparentAltitude(astro.ToHorizontal(eq, obs, astro.JulianDate(t)).Altitude)
+		if altitude > rec.PeakAltitude {
+			rec.PeakAltitude, rec.PeakTime, peak = altitude, t, i
+		}
+		if altitude < minAltitude {
+			continue
+		}
+		if above == 0 {
+			rec.Visible.Start = t
+		}
+		rec.Visible.End = t
+		above++
+	}
+
+	if above < 2 {
+		return rec, peak, false
+	}
+	rec.Hours = math.Round(float64(above-1)*sampleInterval.Hours()*10) / 10
+	return rec, peak, true
+}
+
+// score ranks recommendations from 0 to 1. Half the weight goes to how much
+// of the night the object spends above the altitude limit, the rest to how
+// bright and how large it is. Faint extended objects near a bright Moon are
+// marked down.
+func score(rec Recommendation, dark *Window, moon Moon) float64 {
+	window := rec.Hours / max(dark.End.Sub(dark.Start).Hours(), 1)
+	window = min(window, 1)
+
+	brightness := 0.0
+	if rec.Magnitude != nil {
+		brightness = clamp((12-*rec.Magnitude)/12, 0, 1)
+	}
+	size := clamp(math.Log10(rec.Size+1)/math.Log10(300), 0, 1)
+	s := 0.5*window + 0.3*brightness + 0.2*size
+
+	if moon.UpDuringDarkness && rec.Type != "star" && rec.Type != "cluster" {
+		closeness := clamp((60-rec.MoonSeparation)/60, 0, 1)
+		s *= 1 - 0.5*moon.Illumination*closeness
+	}
+	return math.Round(s*1000) / 1000
+}
+
+func groupTypes(groups map[string][]Recommendation) []string {
+	var types []string
+	for _, typ := range typeOrder {
+		if len(groups[typ]) > 0 {
+			types = append(types, typ)
+		}
+	}
+
+	var other []string
+	for typ := range groups {
+		if !slices.Contains(typeOrder, typ) {
+			other = append(other, typ)
+		}
+	}
+	sort.Strings(other)
+	return append(types, other...)
+}
+
+// uniqueObjects keeps one entry per catalog position, since common names
+// ("Orion Nebula") share theirs with designations ("M42", "NGC 1976").
+// The designation with a display name wins, then any designation, then the
+// longest common name.
+func uniqueObjects(all []data.ObjectInfo) []data.ObjectInfo {
+	best := make(map[*data.Position]data.ObjectInfo)
+	aliases := make(map[*data.Position]string)
+	var order []*data.Position
+
+	for _, info := range all {
+		if info.Position == nil {
+			continue
+		}
+		current, seen := best[info.Position]
+		if !seen {
+			order = append(order, info.Position)
+		}
+		if !seen || rank(info) < rank(current) {
+			best[info.Position] = info
+		}
+		if !data.IsDesignation(info.Name) && len(info.Name) > len(aliases[info.Position]) {
+			aliases[info.Position] = info.Name
+		}
+	}
+
+	objects := make([]data.ObjectInfo, 0, len(order))
+	for _, p := range order {
+		info := best[p]
+		if info.DisplayName == "" && aliases[p] != info.Name {
+			info.DisplayName = aliases[p]
+		}
+		objects = append(objects, info)
+	}
+	return objects
+}
+
+func rank(info data.ObjectInfo) int {
+	switch {
+	case data.IsDesignation(info.Name) && info.DisplayName != "":
+		return 0
+	case data.IsDesignation(info.Name):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
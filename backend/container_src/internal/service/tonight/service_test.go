@@ -0,0 +1,157 @@
+package tonight
+
+import (
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	d, _ := time.Parse(time.DateOnly, value)
+	return d
+}
+
+func near(got, want time.Time, tolerance time.Duration) bool {
+	d := got.Sub(want)
+	return d >= -tolerance && d <= tolerance
+}
+
+func TestDarknessWindow(t *testing.T) {
+	// Published astronomical twilight times, in UTC.
+	tests := []struct {
+		name       string
+		latitude   float64
+		longitude  float64
+		date       string
+		dusk, dawn string
+	}{
+		{"Greenwich winter solstice", 51.48, 0, "2026-12-21", "2026-12-21T17:58:00Z", "2026-12-22T06:01:00Z"},
+		{"equator at the equinox", 0, 0, "2026-03-20", "2026-03-20T19:18:00Z", "2026-03-21T04:56:00Z"},
+		{"Sydney winter solstice", -33.87, 151.21, "2026-06-21", "2026-06-21T08:23:00Z", "2026-06-21T19:31:00Z"},
+	}
+	s := NewService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			night := s.Recommend(Query{Latitude: tt.latitude, Longitude: tt.longitude, Date: day(tt.date), MinAltitude: DefaultMinAltitude, Limit: DefaultLimit})
+			if night.Darkness == nil || night.NoAstronomicalDarkness {
+				t.Fatal("no darkness window")
+			}
+			dusk, _ := time.Parse(time.RFC3339, tt.dusk)
+			dawn, _ := time.Parse(time.RFC3339, tt.dawn)
+			if !near(night.Darkness.Start, dusk, 10*time.Minute) || !near(night.Darkness.End, dawn, 10*time.Minute) {
+				t.Errorf("darkness = %v to %v, want about %v to %v", night.Darkness.Start, night.Darkness.End, dusk, dawn)
+			}
+
+			for _, group := range night.Groups {
+				for _, rec := range group.Objects {
+					if rec.Visible.Start.Before(night.Darkness.Start) || rec.Visible.End.After(night.Darkness.End) {
+						t.Errorf("%s visible %v to %v, outside the dark window", rec.Name, rec.Visible.Start, rec.Visible.End)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNoAstronomicalDarkness(t *testing.T) {
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+	}{
+		{"London", 51.5, -0.13},
+		{"Oslo", 59.91, 10.75},
+		{"Tromsø", 69.65, 18.96},
+	}
+	s := NewService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			night := s.Recommend(Query{Latitude: tt.latitude, Longitude: tt.longitude, Date: day("2026-06-21"), MinAltitude: DefaultMinAltitude, Limit: DefaultLimit})
+			if !night.NoAstronomicalDarkness || night.Darkness != nil {
+				t.Errorf("darkness = %v, want none at midsummer", night.Darkness)
+			}
+			if night.Groups == nil || len(night.Groups) != 0 {
+				t.Errorf("groups = %v, want an empty list", night.Groups)
+			}
+		})
+	}
+}
+
+func TestMoonInterference(t *testing.T) {
+	s := NewService()
+	full := s.Recommend(Query{Latitude: 51.48, Longitude: 0, Date: day("2026-10-26"), MinAltitude: DefaultMinAltitude, Limit: DefaultLimit})
+	if full.Moon.Illumination < 0.95 || !full.Moon.UpDuringDarkness || !full.Moon.Bright {
+		t.Errorf("full Moon = %+v, want bright and up during darkness", full.Moon)
+	}
+	dark := s.Recommend(Query{Latitude: 51.48, Longitude: 0, Date: day("2026-11-09"), MinAltitude: DefaultMinAltitude, Limit: DefaultLimit})
+	if dark.Moon.Illumination > 0.05 || dark.Moon.Bright {
+		t.Errorf("new Moon = %+v, want dark", dark.Moon)
+	}
+
+	window := &Window{Start: day("2026-10-26"), End: day("2026-10-26").Add(10 * time.Hour)}
+	mag := 6.0
+	fullMoon := Moon{Illumination: 1, UpDuringDarkness: true}
+	tests := []struct {
+		name       string
+		typ        string
+		separation float64
+		moon       Moon
+		penalized  bool
+	}{
+		{"galaxy beside a full Moon", "galaxy", 5, fullMoon, true},
+		{"nebula beside a full Moon", "nebula", 30, fullMoon, true},
+		{"galaxy far from the Moon", "galaxy", 90, fullMoon, false},
+		{"galaxy with the Moon set", "galaxy", 5, Moon{Illumination: 1}, false},
+		{"cluster beside a full Moon", "cluster", 5, fullMoon, false},
+		{"star beside a full Moon", "star", 5, fullMoon, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Recommendation{Type: tt.typ, Magnitude: &mag, Size: 20, Hours: 6, MoonSeparation: tt.separation}
+			got, moonless := score(rec, window, tt.moon), score(rec, window, Moon{})
+			if penalized := got < moonless; penalized != tt.penalized {
+				t.Errorf("score = %g without the Moon %g, want penalized = %v", got, moonless, tt.penalized)
+			}
+		})
+	}
+}
+
+func TestScoreOrdering(t *testing.T) {
+	window := &Window{Start: day("2026-11-09"), End: day("2026-11-09").Add(10 * time.Hour)}
+	bright, faint := 4.0, 11.0
+	tests := []struct {
+		name          string
+		better, worse Recommendation
+	}{
+		{"more hours up", Recommendation{Magnitude: &faint, Size: 5, Hours: 9}, Recommendation{Magnitude: &faint, Size: 5, Hours: 2}},
+		{"brighter", Recommendation{Magnitude: &bright, Size: 5, Hours: 5}, Recommendation{Magnitude: &faint, Size: 5, Hours: 5}},
+		{"larger", Recommendation{Magnitude: &faint, Size: 120, Hours: 5}, Recommendation{Magnitude: &faint, Size: 2, Hours: 5}},
+		{"known magnitude", Recommendation{Magnitude: &faint, Size: 5, Hours: 5}, Recommendation{Size: 5, Hours: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b, w := score(tt.better, window, Moon{}), score(tt.worse, window, Moon{}); b <= w {
+				t.Errorf("score = %g, want more than %g", b, w)
+			}
+		})
+	}
+
+	night := NewService().Recommend(Query{Latitude: 51.48, Longitude: 0, Date: day("2026-11-09"), MinAltitude: DefaultMinAltitude, Limit: 5})
+	var types []string
+	for _, group := range night.Groups {
+		types = append(types, group.Type)
+		if len(group.Objects) == 0 || len(group.Objects) > 5 {
+			t.Errorf("%s group has %d objects, want 1 to 5", group.Type, len(group.Objects))
+		}
+		for i := 1; i < len(group.Objects); i++ {
+			if group.Objects[i].Score > group.Objects[i-1].Score {
+				t.Errorf("%s group out of order at %d: %g after %g", group.Type, i, group.Objects[i].Score, group.Objects[i-1].Score)
+			}
+		}
+	}
+	for i, want := range typeOrder {
+		if i >= len(types) || types[i] != want {
+			t.Errorf("group order = %v, want %v first", types, typeOrder)
+			break
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package sgp4
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// WGS-72 constants, which the element sets are fitted with.
+const (
+	earthRadius = 6378.135 // km
+	mu          = 398600.8 // km³/s²
+	j2          = 0.001082616
+	j3          = -0.00000253881
+	j4          = -0.00000165597
+	j3oj2       = j3 / j2
+
+	minutesPerDay = 1440.0
+	twoPi         = 2 * math.Pi
+
+	// deepSpacePeriod is the orbital period in minutes from which SDP4's
+	// lunar and solar terms are needed.
+	deepSpacePeriod = 225.0
+)
+
+var xke = 60 / math.Sqrt(earthRadius*earthRadius*earthRadius/mu)
+
+var (
+	ErrDeepSpace = errors.New("orbit needs the deep-space SDP4 model")
+	ErrDecayed   = errors.New("satellite has decayed")
+	ErrElements  = errors.New("invalid orbital elements")
+)
+
+// Satellite holds the SGP4 state initialised from an element set. Only
+// near-Earth orbits (period under 225 minutes) are supported, which covers
+// the low satellites that trail across long exposures.
+type Satellite struct {
+	TLE TLE
+
+	simple bool
+	no     float64 // un-Kozai'd mean motion, rad/min
+
+	ao, con41, x1mth2, x7thm1 float64
+	cc1, cc4, cc5, d2, d3, d4 float64
+	delmo, eta, sinmao        float64
+	mdot, argpdot, nodedot    float64
+	omgcof, xmcof, nodecf     float64
+	t2cof, t3cof, t4cof       float64
+	t5cof, xlcof, aycof       float64
+}
+
+// New initialises a satellite (Vallado's sgp4init).
+func New(tle TLE) (*Satellite, error) {
+	s := &Satellite{TLE: tle}
+	e, incl, argp := tle.Eccentricity, tle.Inclination, tle.ArgPerigee
+	if !(tle.MeanMotion > 0) || !(e >= 0 && e < 1) {
+		return nil, fmt.Errorf("%w: mean motion %g, eccentricity %g", ErrElements, tle.MeanMotion, e)
+	}
+
+	// Recover the original mean motion and semi-major axis from the
+	// Kozai mean motion in the element set.
+	eccsq := e * e
+	omeosq := 1 - eccsq
+	rteosq := math.Sqrt(omeosq)
+	cosio := math.Cos(incl)
+	cosio2 := cosio * cosio
+
+	ak := math.Pow(xke/tle.MeanMotion, 2.0/3)
+	d1 := 0.75 * j2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3+134*del*del/81))
+	del = d1 / (adel * adel)
+	s.no = tle.MeanMotion / (1 + del)
+	if twoPi/s.no >= deepSpacePeriod {
+		return nil, ErrDeepSpace
+	}
+
+	s.ao = math.Pow(xke/s.no, 2.0/3)
+	sinio := math.Sin(incl)
+	po := s.ao * omeosq
+	con42 := 1 - 5*cosio2
+	s.con41 = -con42 - 2*cosio2
+	posq := po * po
+	rp := s.ao * (1 - e)
+	s.simple = rp < 220/earthRadius+1
+
+	// Atmospheric density parameters depend on perigee height.
+	sfour := 78/earthRadius + 1
+	qzms24 := math.Pow((120-78)/earthRadius, 4)
+	if perigee := (rp - 1) * earthRadius; perigee < 156 {
+		sfour = perigee - 78
+		if perigee < 98 {
+			sfour = 20
+		}
+		qzms24 = math.Pow((120-sfour)/earthRadius, 4)
+		sfour = sfour/earthRadius + 1
+	}
+
+	pinvsq := 1 / posq
+	tsi := 1 / (s.ao - sfour)
+	s.eta = s.ao * e * tsi
+	etasq := s.eta * s.eta
+	eeta := e * s.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * s.no * (s.ao*(1+1.5*etasq+eeta*(4+etasq)) +
+		0.375*j2*tsi/psisq*s.con41*(8+3*etasq*(8+etasq)))
+	s.cc1 = tle.BStar * cc2
+	cc3 := 0.0
+	if e > 1e-4 {
+		cc3 = -2 * coef * tsi * j3oj2 * s.no * sinio / e
+	}
+	s.x1mth2 = 1 - cosio2
+	s.cc4 = 2 * s.no * coef1 * s.ao * omeosq * (s.eta*(2+0.5*etasq) + e*(0.5+2*etasq) -
+		j2*tsi/(s.ao*psisq)*(-3*s.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+
+			0.75*s.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*argp)))
+	s.cc5 = 2 * coef1 * s.ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * j2 * pinvsq * s.no
+	temp2 := 0.5 * temp1 * j2 * pinvsq
+	temp3 := -0.46875 * j4 * pinvsq * pinvsq * s.no
+	s.mdot = s.no + 0.5*temp1*rteosq*s.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	s.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) + temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	s.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+
+	s.omgcof = tle.BStar * cc3 * math.Cos(argp)
+	if e > 1e-4 {
+		s.xmcof = -2.0 / 3 * coef * tle.BStar / eeta
+	}
+	s.nodecf = 3.5 * omeosq * xhdot1 * s.cc1
+	s.t2cof = 1.5 * s.cc1
+	denominator := 1 + cosio
+	if math.Abs(denominator) < 1.5e-12 {
+		denominator = 1.5e-12
+	}
+	s.xlcof = -0.25 * j3oj2 * sinio * (3 + 5*cosio) / denominator
+	s.aycof = -0.5 * j3oj2 * sinio
+	s.delmo = math.Pow(1+s.eta*math.Cos(tle.MeanAnomaly), 3)
+	s.sinmao = math.Sin(tle.MeanAnomaly)
+	s.x7thm1 = 7*cosio2 - 1
+
+	if !s.simple {
+		cc1sq := s.cc1 * s.cc1
+		s.d2 = 4 * s.ao * tsi * cc1sq
+		temp := s.d2 * tsi * s.cc1 / 3
+		s.d3 = (17*s.ao + sfour) * temp
+		s.d4 = 0.5 * temp * s.ao * tsi * (221*s.ao + 31*sfour) * s.cc1
+		s.t3cof = s.d2 + 2*cc1sq
+		s.t4cof = 0.25 * (3*s.d3 + s.cc1*(12*s.d2+10*cc1sq))
+		s.t5cof = 0.2 * (3*s.d4 + 12*s.cc1*s.d3 + 6*s.d2*s.d2 + 15*cc1sq*(2*s.d2+cc1sq))
+	}
+	return s, nil
+}
+
+// PositionAt returns the satellite's position in kilometres in the TEME
+// frame (true equator, mean equinox of date) at time t.
+func (s *Satellite) PositionAt(t time.Time) ([3]float64, error) {
+	return s.Propagate(t.Sub(s.TLE.Epoch).Minutes())
+}
+
+// Propagate returns the TEME position in kilometres tsince minutes after
+// the element set epoch.
+func (s *Satellite) Propagate(tsince float64) ([3]float64, error) {
+	tle := s.TLE
+	t := tsince
+
+	// Secular gravity and atmospheric drag.
+	xmdf := tle.MeanAnomaly + s.mdot*t
+	argpdf := tle.ArgPerigee + s.argpdot*t
+	nodedf := tle.Node + s.nodedot*t
+	argpm, mm := argpdf, xmdf
+	t2 := t * t
+	nodem := nodedf + s.nodecf*t2
+	tempa := 1 - s.cc1*t
+	tempe := tle.BStar * s.cc4 * t
+	templ := s.t2cof * t2
+
+	if !s.simple {
+		delomg := s.omgcof * t
+		delm := s.xmcof * (math.Pow(1+s.eta*math.Cos(xmdf), 3) - s.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * t
+		t4 := t3 * t
+		tempa = tempa - s.d2*t2 - s.d3*t3 - s.d4*t4
+		tempe += tle.BStar * s.cc5 * (math.Sin(mm) - s.sinmao)
+		templ += s.t3cof*t3 + t4*(s.t4cof+t*s.t5cof)
+	}
+
+	am := math.Pow(xke/s.no, 2.0/3) * tempa * tempa
+	em := tle.Eccentricity - tempe
+	if em >= 1 || em < -0.001 || am < 0.95 {
+		return [3]float64{}, ErrDecayed
+	}
+	em = math.Max(em, 1e-6)
+	mm += s.no * templ
+	xlm := mm + argpm + nodem
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+
+	// Long-period periodics.
+	sinip, cosip := math.Sin(tle.Inclination), math.Cos(tle.Inclination)
+	axnl := em * math.Cos(argpm)
+	temp := 1 / (am * (1 - em*em))
+	aynl := em*math.Sin(argpm) + temp*s.aycof
+	xl := mm + argpm + nodem + temp*s.xlcof*axnl
+
+	// Kepler's equation for the eccentric longitude.
+	u := math.Mod(xl-nodem, twoPi)
+	eo1 := u
+	var sineo1, coseo1 float64
+	for range 10 {
+		sineo1, coseo1 = math.Sin(eo1), math.Cos(eo1)
+		step := (u - aynl*coseo1 + axnl*sineo1 - eo1) / (1 - coseo1*axnl - sineo1*aynl)
+		step = math.Max(-0.95, math.Min(0.95, step))
+		eo1 += step
+		if math.Abs(step) < 1e-12 {
+			break
+		}
+	}
+
+	// Short-period periodics.
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return [3]float64{}, ErrDecayed
+	}
+	rl := am * (1 - ecose)
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := 2 * cosu * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * j2 * temp
+	temp2 := temp1 * temp
+
+	mrt := rl*(1-1.5*temp2*betal*s.con41) + 0.5*temp1*s.x1mth2*cos2u
+	if mrt < 1 {
+		return [3]float64{}, ErrDecayed
+	}
+	su -= 0.25 * temp2 * s.x7thm1 * sin2u
+	xnode := nodem + 1.5*temp2*cosip*sin2u
+	xinc := tle.Inclination + 1.5*temp2*cosip*sinip*cos2u
+
+	sinsu, cossu := math.Sin(su), math.Cos(su)
+	snod, cnod := math.Sin(xnode), math.Cos(xnode)
+	sini, cosi := math.Sin(xinc), math.Cos(xinc)
+	xmx := -snod * cosi
+	xmy := cnod * cosi
+	return [3]float64{
+		mrt * (xmx*sinsu + cnod*cossu) * earthRadius,
+		mrt * (xmy*sinsu + snod*cossu) * earthRadius,
+		mrt * sini * sinsu * earthRadius,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package sgp4
+
+import (
+	"math"
+	"testing"
+)
+
+// Vanguard 1 (00005) from the SGP4 verification set of Vallado et al.
+// (2006), "Revisiting Spacetrack Report #3".
+const (
+	vanguardLine1 = "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753"
+	vanguardLine2 = "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667"
+)
+
+func TestParseTLE(t *testing.T) {
+	tle, err := ParseTLE("VANGUARD 1", vanguardLine1, vanguardLine2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tle.NoradID != 5 {
+		t.Errorf("NoradID = %d, want 5", tle.NoradID)
+	}
+	if math.Abs(tle.BStar-0.28098e-4) > 1e-12 {
+		t.Errorf("BStar = %g, want 2.8098e-5", tle.BStar)
+	}
+	if math.Abs(tle.Eccentricity-0.1859667) > 1e-9 {
+		t.Errorf("Eccentricity = %g, want 0.1859667", tle.Eccentricity)
+	}
+	if got := tle.Epoch.Format("2006-01-02"); got != "2000-06-27" {
+		t.Errorf("Epoch = %s, want 2000-06-27", got)
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	tle, _ := ParseTLE("VANGUARD 1", vanguardLine1, vanguardLine2)
+	sat, err := New(tle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		tsince float64
+		want   [3]float64
+	}{
+		{0, [3]float64{7022.46529266, -1400.08296755, 0.03995155}},
+		{360, [3]float64{-7154.03120202, -3783.17682504, -3536.19412294}},
+	} {
+		got, err := sat.Propagate(tc.tsince)
+		if err != nil {
+			t.Fatalf("tsince %g: %v", tc.tsince, err)
+		}
+		for i := range got {
+			if math.Abs(got[i]-tc.want[i]) > 0.001 {
+				t.Errorf("tsince %g: r[%d] = %.6f, want %.6f", tc.tsince, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestParseTLEsWithNames(t *testing.T) {
+	data := "VANGUARD 1\n" + vanguardLine1 + "\n" + vanguardLine2 + "\n" + vanguardLine1 + "\n" + vanguardLine2 + "\n"
+	tles := ParseTLEs([]byte(data))
+	if len(tles) != 2 {
+		t.Fatalf("got %d element sets, want 2", len(tles))
+	}
+	if tles[0].Name != "VANGUARD 1" || tles[1].Name != "5" {
+		t.Errorf("names = %q, %q", tles[0].Name, tles[1].Name)
+	}
+}
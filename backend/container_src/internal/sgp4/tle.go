@@ -0,0 +1,123 @@
+// Package sgp4 propagates NORAD two-line element sets with the SGP4 model
+// (Spacetrack Report #3, as revised by Vallado et al. 2006).
+package sgp4
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLE is a parsed two-line element set. Angles are in radians and the mean
+// motion in radians per minute.
+type TLE struct {
+	Name         string
+	NoradID      int
+	Epoch        time.Time
+	BStar        float64
+	Inclination  float64
+	Node         float64
+	Eccentricity float64
+	ArgPerigee   float64
+	MeanAnomaly  float64
+	MeanMotion   float64
+}
+
+// ParseTLEs reads a file of element sets, each optionally preceded by a name
+// line as in CelesTrak's three-line format. Malformed sets are skipped.
+func ParseTLEs(data []byte) []TLE {
+	var tles []TLE
+	var name, line1 string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		switch {
+		case strings.HasPrefix(line, "1 ") && len(line) >= 64:
+			line1 = line
+		case strings.HasPrefix(line, "2 ") && len(line) >= 63 && line1 != "":
+			if tle, err := ParseTLE(name, line1, line); err == nil {
+				tles = append(tles, tle)
+			}
+			name, line1 = "", ""
+		default:
+			name, line1 = strings.TrimSpace(strings.TrimPrefix(line, "0 ")), ""
+		}
+	}
+	return tles
+}
+
+// ParseTLE parses one element set.
+func ParseTLE(name, line1, line2 string) (TLE, error) {
+	if len(line1) < 64 || len(line2) < 63 {
+		return TLE{}, fmt.Errorf("TLE lines too short")
+	}
+
+	var f fields
+	tle := TLE{Name: name}
+	tle.NoradID = int(f.float(line1[2:7]))
+	year := int(f.float(line1[18:20]))
+	day := f.float(line1[20:32])
+	tle.BStar = f.exponent(line1[53:61])
+
+	tle.Inclination = f.float(line2[8:16]) * math.Pi / 180
+	tle.Node = f.float(line2[17:25]) * math.Pi / 180
+	tle.Eccentricity = f.float("0." + strings.TrimSpace(line2[26:33]))
+	tle.ArgPerigee = f.float(line2[34:42]) * math.Pi / 180
+	tle.MeanAnomaly = f.float(line2[43:51]) * math.Pi / 180
+	tle.MeanMotion = f.float(line2[52:63]) * 2 * math.Pi / minutesPerDay
+	if f.err != nil {
+		return TLE{}, f.err
+	}
+
+	// Two-digit years: 57-99 are 1957-1999.
+	if year < 57 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tle.Epoch = start.Add(time.Duration((day - 1) * 24 * float64(time.Hour)))
+	if tle.Name == "" {
+		tle.Name = strconv.Itoa(tle.NoradID)
+	}
+	return tle, nil
+}
+
+// fields parses fixed-width numbers, keeping the first error.
+type fields struct {
+	err error
+}
+
+func (f *fields) float(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil && f.err == nil {
+		f.err = fmt.Errorf("invalid TLE field %q: %w", s, err)
+	}
+	return v
+}
+
+// exponent parses the TLE's assumed-decimal notation, e.g. " 28098-4"
+// for 0.28098e-4.
+func (f *fields) exponent(s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	sign := 1.0
+	if s[0] == '-' || s[0] == '+' {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	cut := strings.LastIndexAny(s, "+-")
+	if cut <= 0 {
+		return sign * f.float("0."+s)
+	}
+	return sign * f.float("0."+s[:cut]+"e"+s[cut:])
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+type ImageKind string
+
+const (
+	ImageOriginal  ImageKind = "original"
+	ImageThumbnail ImageKind = "thumbnail"
+)
+
+const (
+	kvImagePrefix   = "image:"
+	maxKVValueBytes = 25 << 20
+	maxMemoryImages = 200
+)
+
+var imageKinds = []ImageKind{ImageOriginal, ImageThumbnail}
+
+type MemoryImageStore struct {
+	mu     sync.RWMutex
+	images map[int]map[ImageKind][]byte
+	order  []int
+}
+
+func NewMemoryImageStore() *MemoryImageStore {
+	return &MemoryImageStore{images: make(map[int]map[ImageKind][]byte)}
+}
+
+func (s *MemoryImageStore) Put(ctx context.Context, jobID int, kind ImageKind, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.images[jobID]; !ok {
+		s.images[jobID] = make(map[ImageKind][]byte)
+		s.order = append(s.order, jobID)
+		for len(s.order) > maxMemoryImages {
+			delete(s.images, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	s.images[jobID][kind] = data
+	return nil
+}
+
+func (s *MemoryImageStore) Get(ctx context.Context, jobID int, kind ImageKind) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.images[jobID][kind], nil
+}
+
+func (s *MemoryImageStore) Delete(ctx context.Context, jobID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.images, jobID)
+	return nil
+}
+
+type KVImageStore struct {
+	kv KVClient
+}
+
+func NewKVImageStore(kv KVClient) *KVImageStore {
+	return &KVImageStore{kv: kv}
+}
+
+func (s *KVImageStore) Put(ctx context.Context, jobID int, kind ImageKind, data []byte) error {
+	if len(data) > maxKVValueBytes {
+		return fmt.Errorf("image for job %d is too large to store (%d bytes)", jobID, len(data))
+	}
+	return s.kv.Put(ctx, imageKey(jobID, kind), string(data))
+}
+
+func (s *KVImageStore) Get(ctx context.Context, jobID int, kind ImageKind) ([]byte, error) {
+	value, found, err := s.kv.Get(ctx, imageKey(jobID, kind))
+	if err != nil || !found {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+func (s *KVImageStore) Delete(ctx context.Context, jobID int) error {
+	for _, kind := range imageKinds {
+		if err := s.kv.Delete(ctx, imageKey(jobID, kind)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func imageKey(jobID int, kind ImageKind) string {
+	return kvImagePrefix + strconv.Itoa(jobID) + ":" + string(kind)
+}
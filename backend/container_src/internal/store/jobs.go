@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"server/internal/client/kv"
+	"server/internal/model"
+)
+
+const (
+	kvJobPrefix         = "job:"
+	kvDeviceIndexPrefix = "device-jobs:"
+	kvListLimit         = 1000
+	maxMemoryJobs       = 1000
+)
+
+type KVClient interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Put(ctx context.Context, key, value string) error
+	Delete(ctx context.Context, key string) error
+}
+
+// KVLister is the part of the KV client the device index needs on top of
+// KVClient.
+type KVLister interface {
+	KVClient
+	List(ctx context.Context, prefix, cursor string, limit int) ([]kv.ListedKey, string, error)
+}
+
+// MemoryJobStore keeps the most recent maxMemoryJobs jobs.
+type MemoryJobStore struct {
+	mu       sync.RWMutex
+	jobs     map[int]*model.Job
+	order    []int
+	byDevice map[string][]int
+}
+
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[int]*model.Job), byDevice: make(map[string][]int)}
+}
+
+func (s *MemoryJobStore) Get(ctx context.Context, id int) (*model.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	clone := *job
+	return &clone, nil
+}
+
+func (s *MemoryJobStore) Save(ctx context.Context, job *model.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.ID]; !ok {
+		s.order = append(s.order, job.ID)
+		for len(s.order) > maxMemoryJobs {
+			s.remove(s.order[0])
+		}
+	}
+
+	clone := *job
+	s.jobs[job.ID] = &clone
+	if job.DeviceID != "" && !slices.Contains(s.byDevice[job.DeviceID], job.ID) {
+		s.byDevice[job.DeviceID] = append(s.byDevice[job.DeviceID], job.ID)
+	}
+	return nil
+}
+
+func (s *MemoryJobStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(id)
+	return nil
+}
+
+func (s *MemoryJobStore) remove(id int) {
+	s.order = slices.DeleteFunc(s.order, func(v int) bool { return v == id })
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+
+	delete(s.jobs, id)
+	if job.DeviceID != "" {
+		ids := slices.DeleteFunc(s.byDevice[job.DeviceID], func(v int) bool { return v == id })
+		if len(ids) == 0 {
+			delete(s.byDevice, job.DeviceID)
+		} else {
+			s.byDevice[job.DeviceID] = ids
+		}
+	}
+}
+
+// ListByDevice returns the device's history index, newest first.
+func (s *MemoryJobStore) ListByDevice(ctx context.Context, deviceID string) ([]IndexEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := make([]IndexEntry, 0, len(s.byDevice[deviceID]))
+	for _, id := range s.byDevice[deviceID] {
+		entries = append(entries, IndexEntry{ID: id, CreatedAt: s.jobs[id].CreatedAt})
+	}
+	slices.SortFunc(entries, func(a, b IndexEntry) int {
+		return strings.Compare(a.suffix(), b.suffix())
+	})
+	return entries, nil
+}
+
+// Summary returns the listing fields of an indexed job, or nil if it has
+// been deleted since the index was read.
+func (s *MemoryJobStore) Summary(ctx context.Context, deviceID string, entry IndexEntry) (*model.JobSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[entry.ID]
+	if !ok || job.DeviceID != deviceID {
+		return nil, nil
+	}
+	return job.Summary(), nil
+}
+
+// KVJobStore keeps one index key per job under the device's prefix rather
+// than a single list, so instances recording jobs for the same device never
+// overwrite each other. Key names sort newest first and hold the job's
+// summary, so a history page costs one Get per item shown.
+type KVJobStore struct {
+	kv KVLister
+}
+
+func NewKVJobStore(kv KVLister) *KVJobStore {
+	return &KVJobStore{kv: kv}
+}
+
+func (s *KVJobStore) Get(ctx context.Context, id int) (*model.Job, error) {
+	value, found, err := s.kv.Get(ctx, kvJobPrefix+strconv.Itoa(id))
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var job model.Job
+	if err := json.Unmarshal([]byte(value), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %d: %w", id, err)
+	}
+	return &job, nil
+}
+
+func (s *KVJobStore) Save(ctx context.Context, job *model.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %d: %w", job.ID, err)
+	}
+
+	if err := s.kv.Put(ctx, kvJobPrefix+strconv.Itoa(job.ID), string(data)); err != nil {
+		return err
+	}
+	if job.DeviceID == "" {
+		return nil
+	}
+	summary, err := json.Marshal(job.Summary())
+	if err != nil {
+		return fmt.Errorf("failed to encode summary of job %d: %w", job.ID, err)
+	}
+	return s.kv.Put(ctx, indexKey(job), string(summary))
+}
+
+func (s *KVJobStore) Delete(ctx context.Context, id int) error {
+	job, err := s.Get(ctx, id)
+	if err != nil || job == nil {
+		return err
+	}
+
+	if job.DeviceID != "" {
+		if err := s.kv.Delete(ctx, indexKey(job)); err != nil {
+			return err
+		}
+	}
+	return s.kv.Delete(ctx, kvJobPrefix+strconv.Itoa(id))
+}
+
+// ListByDevice returns the device's history index, newest first, from key
+// names alone. KV listings lag writes by up to a minute, so a job submitted
+// just now may be missing.
+func (s *KVJobStore) ListByDevice(ctx context.Context, deviceID string) ([]IndexEntry, error) {
+	prefix := devicePrefix(deviceID)
+	var entries []IndexEntry
+	cursor := ""
+	for {
+		keys, next, err := s.kv.List(ctx, prefix, cursor, kvListLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if entry, ok := parseIndexEntry(strings.TrimPrefix(key.Name, prefix)); ok {
+				entries = append(entries, entry)
+			}
+		}
+		if next == "" {
+			return entries, nil
+		}
+		cursor = next
+	}
+}
+
+// Summary returns the listing fields stored under an index key, or nil if
+// the job has been deleted since the index was read. Keys written before
+// summaries were stored hold only the job ID; those fall back to the job.
+func (s *KVJobStore) Summary(ctx context.Context, deviceID string, entry IndexEntry) (*model.JobSummary, error) {
+	value, found, err := s.kv.Get(ctx, devicePrefix(deviceID)+entry.suffix())
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var summary model.JobSummary
+	if err := json.Unmarshal([]byte(value), &summary); err == nil {
+		return &summary, nil
+	}
+	job, err := s.Get(ctx, entry.ID)
+	if err != nil || job == nil || job.DeviceID != deviceID {
+		return nil, err
+	}
+	return job.Summary(), nil
+}
+
+// IndexEntry places a job in its device's history.
+type IndexEntry struct {
+	ID        int
+	CreatedAt time.Time
+}
+
+func devicePrefix(deviceID string) string {
+	return kvDeviceIndexPrefix + deviceID + ":"
+}
+
+func indexKey(job *model.Job) string {
+	return devicePrefix(job.DeviceID) + IndexEntry{ID: job.ID, CreatedAt: job.CreatedAt}.suffix()
+}
+
+// suffix orders entries newest first, then by descending ID, when compared
+// as strings.
+func (e IndexEntry) suffix() string {
+	return fmt.Sprintf("%019d:%019d", math.MaxInt64-e.CreatedAt.UnixNano(), math.MaxInt64-int64(e.ID))
+}
+
+func parseIndexEntry(suffix string) (IndexEntry, bool) {
+	createdAt, id, ok := strings.Cut(suffix, ":")
+	if !ok {
+		return IndexEntry{}, false
+	}
+	invertedTime, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return IndexEntry{}, false
+	}
+	invertedID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return IndexEntry{}, false
+	}
+	return IndexEntry{ID: int(math.MaxInt64 - invertedID), CreatedAt: time.Unix(0, math.MaxInt64-invertedTime).UTC()}, true
+}
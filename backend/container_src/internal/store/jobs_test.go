@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"server/internal/client/kv"
+	"server/internal/model"
+)
+
+type fakeKV struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{values: make(map[string]string)}
+}
+
+func (f *fakeKV) Get(ctx context.Context, key string) (string, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.values[key]
+	return value, ok, nil
+}
+
+func (f *fakeKV) Put(ctx context.Context, key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeKV) Delete(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.values, key)
+	return nil
+}
+
+// List pages two keys at a time so callers have to follow the cursor.
+func (f *fakeKV) List(ctx context.Context, prefix, cursor string, limit int) ([]kv.ListedKey, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range f.values {
+		if strings.HasPrefix(name, prefix) && name > cursor {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var keys []kv.ListedKey
+	for _, name := range names[:min(len(names), 2, limit)] {
+		keys = append(keys, kv.ListedKey{Name: name})
+	}
+	next := ""
+	if len(names) > len(keys) {
+		next = keys[len(keys)-1].Name
+	}
+	return keys, next, nil
+}
+
+var base = time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+
+func job(id int, deviceID string, minutes int) *model.Job {
+	return &model.Job{ID: id, DeviceID: deviceID, CreatedAt: base.Add(time.Duration(minutes) * time.Minute)}
+}
+
+func TestKVJobStoreIndexFromSeveralInstances(t *testing.T) {
+	ctx := context.Background()
+	shared := newFakeKV()
+	first, second := NewKVJobStore(shared), NewKVJobStore(shared)
+
+	var wg sync.WaitGroup
+	for i, s := range []*KVJobStore{first, second, first, second, first} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Save(ctx, job(100+i, "dev_a", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := first.Save(ctx, job(200, "dev_ab", 10)); err != nil {
+		t.Fatal(err)
+	}
+	// Saving again, as when the result comes in, must not duplicate the entry.
+	if err := second.Save(ctx, job(101, "dev_a", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := second.ListByDevice(ctx, "dev_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{104, 103, 102, 101, 100}; !slices.Equal(entryIDs(entries), want) {
+		t.Errorf("ListByDevice = %v, want %v", entryIDs(entries), want)
+	}
+	if !entries[3].CreatedAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("entry 101 created at %v, want %v", entries[3].CreatedAt, base.Add(time.Minute))
+	}
+
+	if err := first.Delete(ctx, 102); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = first.ListByDevice(ctx, "dev_a")
+	if want := []int{104, 103, 101, 100}; !slices.Equal(entryIDs(entries), want) {
+		t.Errorf("ListByDevice after delete = %v, want %v", entryIDs(entries), want)
+	}
+}
+
+func TestKVJobStoreSummaryFollowsSaves(t *testing.T) {
+	ctx := context.Background()
+	s := NewKVJobStore(newFakeKV())
+	j := job(7, "dev_a", 0)
+	j.Status = "processing"
+	if err := s.Save(ctx, j); err != nil {
+		t.Fatal(err)
+	}
+	j.Status = "success"
+	j.Result = &model.SolveResult{Objects: []model.CelestialObject{{Name: "M31", Type: "galaxy", Constellation: "And"}}}
+	if err := s.Save(ctx, j); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := s.ListByDevice(ctx, "dev_a")
+	if len(entries) != 1 {
+		t.Fatalf("ListByDevice returned %d entries, want 1", len(entries))
+	}
+	summary, err := s.Summary(ctx, "dev_a", entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.ObjectRef{Name: "M31", DisplayName: "M31", Type: "galaxy", Constellation: "And"}
+	if summary == nil || summary.Status != "success" || len(summary.Objects) != 1 || summary.Objects[0] != want {
+		t.Errorf("Summary = %+v, want status success and %+v", summary, want)
+	}
+
+	if err := s.Delete(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if summary, _ := s.Summary(ctx, "dev_a", entries[0]); summary != nil {
+		t.Errorf("Summary after delete = %+v, want nil", summary)
+	}
+}
+
+func entryIDs(entries []IndexEntry) []int {
+	ids := make([]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func TestMemoryJobStoreIsBounded(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryJobStore()
+	for i := range maxMemoryJobs + 5 {
+		if err := s.Save(ctx, job(i, "dev_a", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(s.jobs) != maxMemoryJobs || len(s.order) != maxMemoryJobs {
+		t.Errorf("store holds %d jobs (%d ordered), want %d", len(s.jobs), len(s.order), maxMemoryJobs)
+	}
+	if j, _ := s.Get(ctx, 0); j != nil {
+		t.Error("oldest job was not evicted")
+	}
+	entries, _ := s.ListByDevice(ctx, "dev_a")
+	if len(entries) != maxMemoryJobs || entries[0].ID != maxMemoryJobs+4 {
+		t.Errorf("device index has %d jobs starting with %v", len(entries), entries[:1])
+	}
+}
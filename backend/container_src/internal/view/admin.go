@@ -0,0 +1,33 @@
+package view
+
+import "time"
+
+type APIKeyQuota struct {
+	DailySolves     int `json:"dailySolves"`
+	MonthlySolves   int `json:"monthlySolves"`
+	DailyFunFacts   int `json:"dailyFunFacts"`
+	MonthlyFunFacts int `json:"monthlyFunFacts"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string      `json:"name"`
+	Scopes []string    `json:"scopes"`
+	Quota  APIKeyQuota `json:"quota"`
+}
+
+type APIKeyResponse struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Scopes    []string    `json:"scopes"`
+	Quota     APIKeyQuota `json:"quota"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Revoked   bool        `json:"revoked"`
+	Key       string      `json:"key,omitempty"`
+}
+
+type MinorBodyRefreshResponse struct {
+	Comets    int       `json:"comets"`
+	Asteroids int       `json:"asteroids"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Persisted bool      `json:"persisted"`
+}
@@ -0,0 +1,23 @@
+package view
+
+import "time"
+
+type DeviceRegistrationResponse struct {
+	DeviceID  string    `json:"deviceId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type DeviceRevocationResponse struct {
+	DeviceID string `json:"deviceId"`
+	Revoked  bool   `json:"revoked"`
+}
+
+type DeviceKeyRotationResponse struct {
+	ActiveKeyID string `json:"activeKeyId"`
+}
+
+type DeviceKeyRetirementResponse struct {
+	KeyID   string `json:"keyId"`
+	Retired bool   `json:"retired"`
+}
@@ -0,0 +1,38 @@
+package view
+
+import "server/internal/overlay"
+
+type GridResponse struct {
+	RAStep  float64    `json:"raStep"`
+	DecStep float64    `json:"decStep"`
+	Lines   []GridLine `json:"lines"`
+}
+
+type GridLine struct {
+	Axis        string         `json:"axis"`
+	Value       float64        `json:"value"`
+	Label       string         `json:"label"`
+	LabelAnchor PixelPoint     `json:"labelAnchor"`
+	Polylines   [][]PixelPoint `json:"polylines"`
+}
+
+func FromGrid(g *overlay.Grid) GridResponse {
+	resp := GridResponse{RAStep: g.RAStep, DecStep: g.DecStep, Lines: make([]GridLine, len(g.Lines))}
+	for i, line := range g.Lines {
+		polylines := make([][]PixelPoint, len(line.Polylines))
+		for j, poly := range line.Polylines {
+			polylines[j] = make([]PixelPoint, len(poly))
+			for k, p := range poly {
+				polylines[j][k] = PixelPoint{PixelX: p.X, PixelY: p.Y}
+			}
+		}
+		resp.Lines[i] = GridLine{
+			Axis:        string(line.Axis),
+			Value:       line.Value,
+			Label:       line.Label,
+			LabelAnchor: PixelPoint{PixelX: line.LabelAt.X, PixelY: line.LabelAt.Y},
+			Polylines:   polylines,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,19 @@
+package view
+
+import "time"
+
+type SessionState struct {
+	Active        bool       `json:"active"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	LastLoginAt   *time.Time `json:"lastLoginAt,omitempty"`
+	Logins        int        `json:"logins"`
+	Invalidations int        `json:"invalidations"`
+	LastError     string     `json:"lastError,omitempty"`
+}
+
+func OptionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,75 @@
+package view
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"server/internal/model"
+)
+
+type HistoryPage struct {
+	Items      []HistoryItem `json:"items"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+type HistoryItem struct {
+	JobID          string          `json:"jobId"`
+	Status         string          `json:"status"`
+	Filename       string          `json:"filename,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	ThumbnailURL   string          `json:"thumbnailUrl"`
+	ObjectCount    int             `json:"objectCount"`
+	Objects        []ObjectSummary `json:"objects"`
+	Constellations []string        `json:"constellations"`
+}
+
+type ObjectSummary struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Type        string `json:"type"`
+}
+
+type HistoryDetail struct {
+	HistoryItem
+	Result      *SolveResultV2 `json:"result,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Observation *Observation   `json:"observation,omitempty"`
+}
+
+// maxSummaryObjects caps the objects listed per history item; stars make up
+// most of a solved field and the full list is available from the detail.
+const maxSummaryObjects = 10
+
+func FromJobSummary(summary *model.JobSummary, thumbnailURL string) HistoryItem {
+	item := HistoryItem{
+		JobID:          strconv.Itoa(summary.ID),
+		Status:         summary.Status,
+		Filename:       summary.Filename,
+		CreatedAt:      summary.CreatedAt,
+		ThumbnailURL:   thumbnailURL,
+		ObjectCount:    len(summary.Objects),
+		Objects:        []ObjectSummary{},
+		Constellations: []string{},
+	}
+
+	for _, o := range summary.Objects {
+		if len(item.Objects) < maxSummaryObjects && o.Type != "star" {
+			item.Objects = append(item.Objects, ObjectSummary{Name: o.Name, DisplayName: o.DisplayName, Type: o.Type})
+		}
+		if o.Constellation != "" && !slices.Contains(item.Constellations, o.Constellation) {
+			item.Constellations = append(item.Constellations, o.Constellation)
+		}
+	}
+	slices.Sort(item.Constellations)
+	return item
+}
+
+func FromJobDetail(job *model.Job, thumbnailURL string) HistoryDetail {
+	return HistoryDetail{
+		HistoryItem: FromJobSummary(job.Summary(), thumbnailURL),
+		Result:      FromSolveResultV2(job.Result),
+		Error:       job.Error,
+		Observation: FromObservation(job.Observation),
+	}
+}
@@ -0,0 +1,40 @@
+package view
+
+import (
+	"time"
+
+	"server/internal/model"
+)
+
+type Observation struct {
+	CapturedAt *time.Time `json:"capturedAt,omitempty"`
+	Location   *Location  `json:"location,omitempty"`
+	Equipment  *Equipment `json:"equipment,omitempty"`
+}
+
+type Location struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Elevation *float64 `json:"elevation,omitempty"`
+}
+
+type Equipment struct {
+	Telescope   string  `json:"telescope,omitempty"`
+	FocalLength float64 `json:"focalLength,omitempty"`
+	Camera      string  `json:"camera,omitempty"`
+}
+
+func FromObservation(o *model.Observation) *Observation {
+	if o.IsEmpty() {
+		return nil
+	}
+
+	observation := &Observation{CapturedAt: o.CapturedAt}
+	if l := o.Location; l != nil {
+		observation.Location = &Location{Latitude: l.Latitude, Longitude: l.Longitude, Elevation: l.Elevation}
+	}
+	if e := o.Equipment; e != nil {
+		observation.Equipment = &Equipment{Telescope: e.Telescope, FocalLength: e.FocalLength, Camera: e.Camera}
+	}
+	return observation
+}
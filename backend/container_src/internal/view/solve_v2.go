@@ -0,0 +1,201 @@
+package view
+
+import (
+	"time"
+
+	"server/internal/model"
+)
+
+type JobStatusResponseV2 struct {
+	Status      string         `json:"status"`
+	Result      *SolveResultV2 `json:"result,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Observation *Observation   `json:"observation,omitempty"`
+}
+
+type SolveResultV2 struct {
+	Objects            []CelestialObjectV2  `json:"objects"`
+	Streaks            []StreakV2           `json:"streaks,omitempty"`
+	Constellations     []FieldConstellation `json:"constellations,omitempty"`
+	ConstellationLines []ConstellationLine  `json:"constellationLines,omitempty"`
+	Calibration        *Calibration         `json:"calibration,omitempty"`
+}
+
+type CelestialObjectV2 struct {
+	Name          string       `json:"name"`
+	DisplayName   string       `json:"displayName"`
+	Type          string       `json:"type"`
+	Constellation string       `json:"constellation"`
+	Footprint     *Footprint   `json:"footprint,omitempty"`
+	Coordinates   *Coordinates `json:"coordinates,omitempty"`
+	Sky           *SkyPosition `json:"sky,omitempty"`
+}
+
+type Coordinates struct {
+	RA  float64 `json:"ra"`
+	Dec float64 `json:"dec"`
+}
+
+type SkyPosition struct {
+	Altitude    float64    `json:"altitude"`
+	Azimuth     float64    `json:"azimuth"`
+	HourAngle   float64    `json:"hourAngle"`
+	Airmass     *float64   `json:"airmass,omitempty"`
+	Rise        *time.Time `json:"rise,omitempty"`
+	Transit     time.Time  `json:"transit"`
+	Set         *time.Time `json:"set,omitempty"`
+	Circumpolar bool       `json:"circumpolar,omitempty"`
+	NeverRises  bool       `json:"neverRises,omitempty"`
+}
+
+type Footprint struct {
+	PixelX float64 `json:"pixelX"`
+	PixelY float64 `json:"pixelY"`
+	Radius float64 `json:"radius"`
+}
+
+type StreakV2 struct {
+	Start      StreakEnd        `json:"start"`
+	End        StreakEnd        `json:"end"`
+	Satellites []SatelliteMatch `json:"satellites,omitempty"`
+	Shower     string           `json:"shower,omitempty"`
+}
+
+type StreakEnd struct {
+	PixelX      float64      `json:"pixelX"`
+	PixelY      float64      `json:"pixelY"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+}
+
+type SatelliteMatch struct {
+	Name    string  `json:"name"`
+	NoradID int     `json:"noradId"`
+	Offset  float64 `json:"offset"`
+}
+
+type FieldConstellation struct {
+	Abbreviation string  `json:"abbreviation"`
+	Name         string  `json:"name"`
+	Coverage     float64 `json:"coverage"`
+}
+
+type ConstellationLine struct {
+	Constellation string     `json:"constellation"`
+	FromHIP       int        `json:"fromHip"`
+	ToHIP         int        `json:"toHip"`
+	Start         PixelPoint `json:"start"`
+	End           PixelPoint `json:"end"`
+}
+
+type PixelPoint struct {
+	PixelX float64 `json:"pixelX"`
+	PixelY float64 `json:"pixelY"`
+}
+
+type Calibration struct {
+	RA          float64 `json:"ra"`
+	Dec         float64 `json:"dec"`
+	Radius      float64 `json:"radius"`
+	PixelScale  float64 `json:"pixelScale"`
+	Orientation float64 `json:"orientation"`
+	Parity      int     `json:"parity"`
+}
+
+type ObjectDetailResponseV2 struct {
+	Name          string `json:"name"`
+	DisplayName   string `json:"displayName"`
+	Type          string `json:"type"`
+	Constellation string `json:"constellation"`
+	FunFact       string `json:"funFact"`
+}
+
+func FromSolveResultV2(r *model.SolveResult) *SolveResultV2 {
+	if r == nil {
+		return nil
+	}
+
+	objects := make([]CelestialObjectV2, len(r.Objects))
+	for i, o := range r.Objects {
+		objects[i] = CelestialObjectV2{
+			Name:          o.Name,
+			DisplayName:   o.GetDisplayName(),
+			Type:          o.Type,
+			Constellation: o.Constellation,
+		}
+		if o.Footprint != nil {
+			objects[i].Footprint = &Footprint{
+				PixelX: o.Footprint.PixelX,
+				PixelY: o.Footprint.PixelY,
+				Radius: o.Footprint.Radius,
+			}
+		}
+		if o.Coordinates != nil {
+			objects[i].Coordinates = &Coordinates{RA: o.Coordinates.RA, Dec: o.Coordinates.Dec}
+		}
+		objects[i].Sky = FromSkyPosition(o.Sky)
+	}
+
+	result := &SolveResultV2{Objects: objects}
+	for _, st := range r.Streaks {
+		streak := StreakV2{
+			Start:  StreakEnd{PixelX: st.X1, PixelY: st.Y1, Coordinates: fromCoordinates(st.Start)},
+			End:    StreakEnd{PixelX: st.X2, PixelY: st.Y2, Coordinates: fromCoordinates(st.End)},
+			Shower: st.Shower,
+		}
+		for _, m := range st.Satellites {
+			streak.Satellites = append(streak.Satellites, SatelliteMatch{Name: m.Name, NoradID: m.NoradID, Offset: m.Offset})
+		}
+		result.Streaks = append(result.Streaks, streak)
+	}
+	for _, c := range r.Constellations {
+		result.Constellations = append(result.Constellations, FieldConstellation{
+			Abbreviation: c.Abbreviation,
+			Name:         c.Name,
+			Coverage:     c.Coverage,
+		})
+	}
+	for _, l := range r.ConstellationLines {
+		result.ConstellationLines = append(result.ConstellationLines, ConstellationLine{
+			Constellation: l.Constellation,
+			FromHIP:       l.FromHIP,
+			ToHIP:         l.ToHIP,
+			Start:         PixelPoint{PixelX: l.X1, PixelY: l.Y1},
+			End:           PixelPoint{PixelX: l.X2, PixelY: l.Y2},
+		})
+	}
+	if c := r.Calibration; c != nil {
+		result.Calibration = &Calibration{
+			RA:          c.RA,
+			Dec:         c.Dec,
+			Radius:      c.Radius,
+			PixelScale:  c.PixelScale,
+			Orientation: c.Orientation,
+			Parity:      c.Parity,
+		}
+	}
+	return result
+}
+
+func fromCoordinates(c *model.Coordinates) *Coordinates {
+	if c == nil {
+		return nil
+	}
+	return &Coordinates{RA: c.RA, Dec: c.Dec}
+}
+
+func FromSkyPosition(s *model.SkyPosition) *SkyPosition {
+	if s == nil {
+		return nil
+	}
+	return &SkyPosition{
+		Altitude:    s.Altitude,
+		Azimuth:     s.Azimuth,
+		HourAngle:   s.HourAngle,
+		Airmass:     s.Airmass,
+		Rise:        s.Rise,
+		Transit:     s.Transit,
+		Set:         s.Set,
+		Circumpolar: s.Circumpolar,
+		NeverRises:  s.NeverRises,
+	}
+}
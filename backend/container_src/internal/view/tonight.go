@@ -0,0 +1,92 @@
+package view
+
+import (
+	"time"
+
+	"server/internal/service/tonight"
+)
+
+type TonightResponse struct {
+	Date                   string         `json:"date"`
+	Darkness               *TimeWindow    `json:"darkness,omitempty"`
+	NoAstronomicalDarkness bool           `json:"noAstronomicalDarkness"`
+	Moon                   TonightMoon    `json:"moon"`
+	Groups                 []TonightGroup `json:"groups"`
+}
+
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type TonightMoon struct {
+	Phase            string     `json:"phase"`
+	Illumination     float64    `json:"illumination"`
+	Rise             *time.Time `json:"rise,omitempty"`
+	Set              *time.Time `json:"set,omitempty"`
+	UpDuringDarkness bool       `json:"upDuringDarkness"`
+	Bright           bool       `json:"bright"`
+}
+
+type TonightGroup struct {
+	Type    string          `json:"type"`
+	Objects []TonightObject `json:"objects"`
+}
+
+type TonightObject struct {
+	Name           string      `json:"name"`
+	DisplayName    string      `json:"displayName"`
+	Type           string      `json:"type"`
+	Constellation  string      `json:"constellation"`
+	Magnitude      *float64    `json:"magnitude,omitempty"`
+	Size           float64     `json:"size,omitempty"`
+	Coordinates    Coordinates `json:"coordinates"`
+	PeakAltitude   float64     `json:"peakAltitude"`
+	PeakTime       time.Time   `json:"peakTime"`
+	Visible        TimeWindow  `json:"visible"`
+	Hours          float64     `json:"hours"`
+	MoonSeparation float64     `json:"moonSeparation"`
+	Score          float64     `json:"score"`
+}
+
+func FromNight(n *tonight.Night) TonightResponse {
+	resp := TonightResponse{
+		Date:                   n.Date.Format(time.DateOnly),
+		NoAstronomicalDarkness: n.NoAstronomicalDarkness,
+		Moon: TonightMoon{
+			Phase:            n.Moon.Phase,
+			Illumination:     n.Moon.Illumination,
+			Rise:             n.Moon.Rise,
+			Set:              n.Moon.Set,
+			UpDuringDarkness: n.Moon.UpDuringDarkness,
+			Bright:           n.Moon.Bright,
+		},
+		Groups: make([]TonightGroup, len(n.Groups)),
+	}
+	if n.Darkness != nil {
+		resp.Darkness = &TimeWindow{Start: n.Darkness.Start, End: n.Darkness.End}
+	}
+
+	for i, g := range n.Groups {
+		objects := make([]TonightObject, len(g.Objects))
+		for j, r := range g.Objects {
+			objects[j] = TonightObject{
+				Name:           r.Name,
+				DisplayName:    r.DisplayName,
+				Type:           r.Type,
+				Constellation:  r.Constellation,
+				Magnitude:      r.Magnitude,
+				Size:           r.Size,
+				Coordinates:    Coordinates{RA: r.RA, Dec: r.Dec},
+				PeakAltitude:   r.PeakAltitude,
+				PeakTime:       r.PeakTime,
+				Visible:        TimeWindow{Start: r.Visible.Start, End: r.Visible.End},
+				Hours:          r.Hours,
+				MoonSeparation: r.MoonSeparation,
+				Score:          r.Score,
+			}
+		}
+		resp.Groups[i] = TonightGroup{Type: g.Type, Objects: objects}
+	}
+	return resp
+}
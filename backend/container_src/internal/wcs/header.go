@@ -0,0 +1,180 @@
+package wcs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"server/internal/fits"
+)
+
+const cardSize = 80
+
+var ErrNotTAN = errors.New("WCS is not a TAN projection")
+
+// Parse reads a WCS from a FITS header such as astrometry.net's wcs.fits.
+// Only the primary header is read; the file may or may not carry data.
+func Parse(header []byte) (*WCS, error) {
+	cards := make(map[string]string)
+	for pos := 0; pos+cardSize <= len(header); pos += cardSize {
+		card := string(header[pos : pos+cardSize])
+		key := strings.TrimSpace(card[:8])
+		if key == "END" {
+			break
+		}
+		if card[8:10] == "= " {
+			cards[key] = cardValue(card[10:])
+		}
+	}
+
+	if !strings.HasPrefix(cards["CTYPE1"], "RA---TAN") || !strings.HasPrefix(cards["CTYPE2"], "DEC--TAN") {
+		return nil, ErrNotTAN
+	}
+
+	w := &WCS{}
+	var err error
+	for i, axis := range []string{"1", "2"} {
+		if w.CRVAL[i], err = number(cards, "CRVAL"+axis); err != nil {
+			return nil, err
+		}
+		if w.CRPIX[i], err = number(cards, "CRPIX"+axis); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := cards["CD1_1"]; ok {
+		for i, row := range []string{"1", "2"} {
+			for j, col := range []string{"1", "2"} {
+				// Missing off-diagonal terms default to zero.
+				w.CD[i][j], _ = number(cards, "CD"+row+"_"+col)
+			}
+		}
+	} else {
+		cdelt1, err := number(cards, "CDELT1")
+		if err != nil {
+			return nil, err
+		}
+		cdelt2, err := number(cards, "CDELT2")
+		if err != nil {
+			return nil, err
+		}
+		w.CD = [2][2]float64{{cdelt1, 0}, {0, cdelt2}}
+	}
+
+	w.A = sip(cards, "A")
+	w.B = sip(cards, "B")
+	w.AP = sip(cards, "AP")
+	w.BP = sip(cards, "BP")
+
+	width, _ := number(cards, "IMAGEW")
+	height, _ := number(cards, "IMAGEH")
+	if width == 0 {
+		width, _ = number(cards, "NAXIS1")
+	}
+	if height == 0 {
+		height, _ = number(cards, "NAXIS2")
+	}
+	w.ImageWidth, w.ImageHeight = int(width), int(height)
+	return w, nil
+}
+
+// cardValue strips the comment and quotes from a card's value field.
+func cardValue(field string) string {
+	field = strings.TrimSpace(field)
+	if strings.HasPrefix(field, "'") {
+		end := strings.Index(field[1:], "'")
+		if end < 0 {
+			return strings.TrimSpace(field[1:])
+		}
+		return strings.TrimSpace(field[1 : end+1])
+	}
+	if value, _, ok := strings.Cut(field, "/"); ok {
+		return strings.TrimSpace(value)
+	}
+	return field
+}
+
+func number(cards map[string]string, key string) (float64, error) {
+	raw, ok := cards[key]
+	if !ok {
+		return 0, fmt.Errorf("FITS header has no %s", key)
+	}
+	// FITS allows D as the exponent marker.
+	v, err := strconv.ParseFloat(strings.Replace(raw, "D", "E", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+// sip reads the coefficients of one SIP polynomial, or nil when its order
+// is absent.
+func sip(cards map[string]string, name string) [][]float64 {
+	order, err := number(cards, name+"_ORDER")
+	if err != nil || order < 1 {
+		return nil
+	}
+
+	n := int(order)
+	coeffs := make([][]float64, n+1)
+	for p := range coeffs {
+		coeffs[p] = make([]float64, n+1-p)
+		for q := range coeffs[p] {
+			coeffs[p][q], _ = number(cards, fmt.Sprintf("%s_%d_%d", name, p, q))
+		}
+	}
+	return coeffs
+}
+
+// Header writes the solution as a header-only FITS file in the layout
+// astrometry.net uses: TAN or TAN-SIP keywords, with the image size in
+// IMAGEW and IMAGEH since there is no data. Callers may add more cards.
+func (w *WCS) Header() *fits.Header {
+	h := &fits.Header{}
+	h.Bool("SIMPLE", true, "Standard FITS file")
+	h.Int("BITPIX", 8, "ASCII or bytes array")
+	h.Int("NAXIS", 0, "Minimal header")
+	h.Bool("EXTEND", true, "There may be FITS ext")
+	h.Int("WCSAXES", 2, "no comment")
+
+	ctype := "TAN"
+	if w.A != nil || w.B != nil {
+		ctype = "TAN-SIP"
+	}
+	h.String("CTYPE1", "RA---"+ctype, "TAN (gnomic) projection + SIP distortions")
+	h.String("CTYPE2", "DEC--"+ctype, "TAN (gnomic) projection + SIP distortions")
+	h.Float("EQUINOX", 2000, "Equatorial coordinates definition (yr)")
+	h.Float("LONPOLE", 180, "no comment")
+	h.Float("LATPOLE", 0, "no comment")
+	h.Float("CRVAL1", w.CRVAL[0], "RA  of reference point")
+	h.Float("CRVAL2", w.CRVAL[1], "DEC of reference point")
+	h.Float("CRPIX1", w.CRPIX[0], "X reference pixel")
+	h.Float("CRPIX2", w.CRPIX[1], "Y reference pixel")
+	h.String("CUNIT1", "deg", "X pixel scale units")
+	h.String("CUNIT2", "deg", "Y pixel scale units")
+	h.Float("CD1_1", w.CD[0][0], "Transformation matrix")
+	h.Float("CD1_2", w.CD[0][1], "no comment")
+	h.Float("CD2_1", w.CD[1][0], "no comment")
+	h.Float("CD2_2", w.CD[1][1], "no comment")
+	h.Int("IMAGEW", w.ImageWidth, "Image width,  in pixels.")
+	h.Int("IMAGEH", w.ImageHeight, "Image height, in pixels.")
+
+	writeSIP(h, "A", w.A, "Polynomial order, axis 1")
+	writeSIP(h, "B", w.B, "Polynomial order, axis 2")
+	writeSIP(h, "AP", w.AP, "Inv polynomial order, axis 1")
+	writeSIP(h, "BP", w.BP, "Inv polynomial order, axis 2")
+	return h
+}
+
+func writeSIP(h *fits.Header, name string, coeffs [][]float64, comment string) {
+	if coeffs == nil {
+		return
+	}
+	h.Int(name+"_ORDER", len(coeffs)-1, comment)
+	for p := range coeffs {
+		for q := range coeffs[p] {
+			h.Float(fmt.Sprintf("%s_%d_%d", name, p, q), coeffs[p][q], "")
+		}
+	}
+}
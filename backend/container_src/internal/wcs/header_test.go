@@ -0,0 +1,82 @@
+package wcs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	cases := map[string]*WCS{
+		"tan": {
+			CRVAL:       [2]float64{83.8221, -5.3911},
+			CRPIX:       [2]float64{600.5, 400.5},
+			CD:          [2][2]float64{{-2.7e-4, 1.3e-5}, {-1.3e-5, -2.7e-4}},
+			ImageWidth:  1200,
+			ImageHeight: 800,
+		},
+		"sip": {
+			CRVAL:       [2]float64{10.684708333, 41.26875},
+			CRPIX:       [2]float64{1024.123456789, 768.987654321},
+			CD:          [2][2]float64{{-1.2345678901234567e-3, 3.3e-6}, {2.9e-6, 1.2345e-3}},
+			A:           [][]float64{{0, 0, 1.1e-6}, {0, -2.2e-7}, {3.3e-6}},
+			B:           [][]float64{{0, 0, -4.4e-6}, {0, 5.5e-7}, {-6.6e-6}},
+			AP:          [][]float64{{1e-9, 2e-9, -1.1e-6}, {3e-9, 2.2e-7}, {-3.3e-6}},
+			BP:          [][]float64{{-1e-9, 4e-9, 4.4e-6}, {-5e-9, -5.5e-7}, {6.6e-6}},
+			ImageWidth:  2048,
+			ImageHeight: 1536,
+		},
+	}
+
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			data := want.Header().Bytes()
+			if len(data)%2880 != 0 {
+				t.Fatalf("header is %d bytes, not whole FITS blocks", len(data))
+			}
+			got, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the solution:\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestHeaderProjection(t *testing.T) {
+	w := &WCS{
+		CRVAL:       [2]float64{83.8221, -5.3911},
+		CRPIX:       [2]float64{600.5, 400.5},
+		CD:          [2][2]float64{{-2.7e-4, 0}, {0, -2.7e-4}},
+		A:           [][]float64{{0, 0, 1e-6}, {0, 0}, {0}},
+		B:           [][]float64{{0, 0, 0}, {0, 0}, {1e-6}},
+		ImageWidth:  1200,
+		ImageHeight: 800,
+	}
+	header := string(w.Header().Bytes())
+	for _, card := range []string{"CTYPE1  = 'RA---TAN-SIP'", "CTYPE2  = 'DEC--TAN-SIP'", "A_ORDER =                    2"} {
+		if !containsCard(header, card) {
+			t.Errorf("header has no card starting %q", card)
+		}
+	}
+
+	parsed, err := Parse([]byte(header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ra1, dec1 := w.PixelToSky(10, 20)
+	ra2, dec2 := parsed.PixelToSky(10, 20)
+	if ra1 != ra2 || dec1 != dec2 {
+		t.Errorf("PixelToSky = %v %v after the round trip, want %v %v", ra2, dec2, ra1, dec1)
+	}
+}
+
+func containsCard(header, prefix string) bool {
+	for i := 0; i+80 <= len(header); i += 80 {
+		if header[i:i+len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
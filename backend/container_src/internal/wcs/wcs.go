@@ -0,0 +1,119 @@
+// Package wcs maps between image pixels and sky coordinates using the FITS
+// TAN-SIP world coordinate system astrometry.net writes for a solved image.
+package wcs
+
+import "math"
+
+// WCS is a gnomonic (TAN) projection with optional SIP distortion. CRPIX and
+// the SIP polynomials use FITS pixel coordinates, where the centre of the
+// first pixel is (1, 1); the methods below take and return image
+// coordinates measured from the centre of the top-left pixel, matching
+// astrometry.net's annotations.
+type WCS struct {
+	// CRVAL is the J2000 RA and Dec of the reference point, in degrees.
+	CRVAL [2]float64
+	CRPIX [2]float64
+	// CD maps pixel offsets to intermediate world coordinates in degrees.
+	CD [2][2]float64
+	// A and B are the forward SIP coefficients, AP and BP the inverse ones,
+	// indexed [p][q]. They are nil when the solution has no distortion.
+	A, B   [][]float64
+	AP, BP [][]float64
+
+	ImageWidth  int
+	ImageHeight int
+}
+
+// PixelToSky returns the J2000 RA and Dec of an image position.
+func (w *WCS) PixelToSky(x, y float64) (ra, dec float64) {
+	u, v := x+1-w.CRPIX[0], y+1-w.CRPIX[1]
+	u, v = u+poly(w.A, u, v), v+poly(w.B, u, v)
+	xi := w.CD[0][0]*u + w.CD[0][1]*v
+	eta := w.CD[1][0]*u + w.CD[1][1]*v
+	return deproject(xi, eta, w.CRVAL[0], w.CRVAL[1])
+}
+
+// SkyToPixel returns the image position of a J2000 RA and Dec. It reports
+// false for points 90° or more from the reference point, which the tangent
+// plane cannot show; the position may still fall outside the image.
+func (w *WCS) SkyToPixel(ra, dec float64) (x, y float64, ok bool) {
+	xi, eta, ok := project(ra, dec, w.CRVAL[0], w.CRVAL[1])
+	if !ok {
+		return 0, 0, false
+	}
+
+	det := w.CD[0][0]*w.CD[1][1] - w.CD[0][1]*w.CD[1][0]
+	if det == 0 {
+		return 0, 0, false
+	}
+	u := (w.CD[1][1]*xi - w.CD[0][1]*eta) / det
+	v := (-w.CD[1][0]*xi + w.CD[0][0]*eta) / det
+
+	if w.AP != nil || w.BP != nil {
+		u, v = u+poly(w.AP, u, v), v+poly(w.BP, u, v)
+	} else if w.A != nil || w.B != nil {
+		u, v = w.undistort(u, v)
+	}
+	return u + w.CRPIX[0] - 1, v + w.CRPIX[1] - 1, true
+}
+
+// Contains reports whether an image position lies on the image. It is
+// always false when the image size is unknown.
+func (w *WCS) Contains(x, y float64) bool {
+	return x >= -0.5 && y >= -0.5 && x < float64(w.ImageWidth)-0.5 && y < float64(w.ImageHeight)-0.5
+}
+
+// PixelScale returns the mean scale at the reference point in arcseconds
+// per pixel.
+func (w *WCS) PixelScale() float64 {
+	return math.Sqrt(math.Abs(w.CD[0][0]*w.CD[1][1]-w.CD[0][1]*w.CD[1][0])) * 3600
+}
+
+// undistort inverts the forward SIP polynomials by fixed-point iteration,
+// for solutions written without AP and BP.
+func (w *WCS) undistort(up, vp float64) (float64, float64) {
+	u, v := up, vp
+	for range 20 {
+		u, v = up-poly(w.A, u, v), vp-poly(w.B, u, v)
+	}
+	return u, v
+}
+
+func poly(coeffs [][]float64, u, v float64) float64 {
+	var sum float64
+	for p, row := range coeffs {
+		for q, c := range row {
+			if c != 0 {
+				sum += c * math.Pow(u, float64(p)) * math.Pow(v, float64(q))
+			}
+		}
+	}
+	return sum
+}
+
+// project is the gnomonic projection onto the plane tangent at (ra0, dec0);
+// xi points to increasing RA and eta to the north, both in degrees.
+func project(ra, dec, ra0, dec0 float64) (xi, eta float64, ok bool) {
+	dRA := radians(ra - ra0)
+	d, d0 := radians(dec), radians(dec0)
+	cosC := math.Sin(d0)*math.Sin(d) + math.Cos(d0)*math.Cos(d)*math.Cos(dRA)
+	if cosC <= 0 {
+		return 0, 0, false
+	}
+	xi = math.Cos(d) * math.Sin(dRA) / cosC
+	eta = (math.Cos(d0)*math.Sin(d) - math.Sin(d0)*math.Cos(d)*math.Cos(dRA)) / cosC
+	return degrees(xi), degrees(eta), true
+}
+
+// deproject is the inverse of project.
+func deproject(xi, eta, ra0, dec0 float64) (ra, dec float64) {
+	x, y := radians(xi), radians(eta)
+	d0 := radians(dec0)
+	denominator := math.Cos(d0) - y*math.Sin(d0)
+	ra = ra0 + degrees(math.Atan2(x, denominator))
+	dec = degrees(math.Atan2(math.Sin(d0)+y*math.Cos(d0), math.Hypot(x, denominator)))
+	return math.Mod(ra+360, 360), dec
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }